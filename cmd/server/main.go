@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,9 +11,16 @@ import (
 	"go-smart/internal/config"
 	"go-smart/internal/handler"
 	"go-smart/internal/logger"
-	"go-smart/internal/modelmgr"
 	"go-smart/internal/server"
 	"go-smart/internal/service"
+	"go-smart/pkg/billing"
+	"go-smart/pkg/business"
+	"go-smart/pkg/cassette"
+	"go-smart/pkg/experiment"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+	"go-smart/pkg/trace"
+	"go-smart/pkg/usage"
 )
 
 func main() {
@@ -37,23 +46,101 @@ func main() {
 
 	log.Info("应用程序启动", nil)
 
-	// 创建模型服务
-	modelService, err := modelmgr.NewService(&cfg.AI)
+	// 创建用量统计器
+	usageTracker := usage.NewTracker()
+
+	// 创建计费器，挂载到用量统计器上执行配额检查和记账
+	biller, err := billing.NewBiller(&cfg.Billing, log)
 	if err != nil {
-		log.Error("创建模型服务失败", map[string]interface{}{
+		log.Error("创建计费器失败", map[string]interface{}{
 			"error": err.Error(),
 		})
-		panic("创建模型服务失败: " + err.Error())
+		panic("创建计费器失败: " + err.Error())
+	}
+	defer biller.Close()
+	biller.Attach(usageTracker)
+
+	// 启用录制/回放时，模型HTTP请求经过磁带
+	var httpClient *http.Client
+	if cfg.AI.Cassette.Mode != "" {
+		recorder, err := cassette.NewRecorder(cassette.Config{
+			Path:    cfg.AI.Cassette.Path,
+			Mode:    cassette.Mode(cfg.AI.Cassette.Mode),
+			Secrets: []string{cfg.AI.OpenAI.APIKey},
+		})
+		if err != nil {
+			log.Error("创建磁带录制器失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+			panic("创建磁带录制器失败: " + err.Error())
+		}
+		httpClient = recorder.Client()
+		log.Info("模型HTTP流量经过磁带", map[string]interface{}{
+			"mode": cfg.AI.Cassette.Mode,
+			"path": cfg.AI.Cassette.Path,
+		})
+	}
+
+	// 创建模型管理器，所有处理链路共享同一个当前模型
+	modelManager := model.NewModelManager(cfg, log, usageTracker, httpClient)
+	if modelManager.GetCurrentModel() == nil {
+		panic("创建模型失败，请检查AI配置")
 	}
 
-	log.Info("模型服务创建成功", map[string]interface{}{
-		"provider": modelService.GetProvider(),
+	log.Info("模型管理器创建成功", map[string]interface{}{
+		"provider": modelManager.GetProvider(),
 	})
 
+	// 加载提示词模板，并在文件变化时自动重新加载
+	prompts, err := prompt.NewRegistry(cfg.PromptsDir, log)
+	if err != nil {
+		log.Error("加载提示词模板失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		panic("加载提示词模板失败: " + err.Error())
+	}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if err := prompts.Watch(watchCtx); err != nil {
+		log.Warn("提示词热加载未启用", map[string]interface{}{
+			"dir":   cfg.PromptsDir,
+			"error": err.Error(),
+		})
+	}
+
+	// 创建A/B实验管理器
+	experiments, err := experiment.NewManager(cfg.Experiments, log)
+	if err == nil {
+		err = experiments.CheckReferences(modelManager.HasProfile, prompts.HasVersion)
+	}
+	if err != nil {
+		log.Error("加载实验配置失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		panic("加载实验配置失败: " + err.Error())
+	}
+
+	// 打开订单存储
+	orders, err := business.OpenOrderRepository(context.Background(), cfg.Database)
+	if err != nil {
+		log.Error("打开订单存储失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		panic("打开订单存储失败: " + err.Error())
+	}
+	if closer, ok := orders.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// 订单、退款和发票服务，多轮对话和工作流共用
+	services := business.NewServicesWithOrders(orders)
+
 	// 创建对话服务
 	conversationService, err := service.NewConversationService(
 		context.Background(),
-		modelService.GetChatModel(),
+		modelManager,
+		prompts,
+		services,
 		log,
 		cfg,
 	)
@@ -65,7 +152,7 @@ func main() {
 	}
 
 	// 创建工作流服务
-	workflowService, err := service.NewWorkflowService(cfg, log)
+	workflowService, err := service.NewWorkflowService(modelManager, prompts, services, &cfg.Workflow, log)
 	if err != nil {
 		log.Error("创建工作流服务失败", map[string]interface{}{
 			"error": err.Error(),
@@ -73,12 +160,48 @@ func main() {
 		panic("创建工作流服务失败: " + err.Error())
 	}
 
+	// 从检查点继续服务重启前被中断的工作流处理
+	go workflowService.ResumeInterrupted(context.Background())
+
+	// 定期删除超过保留时长的已完成检查点
+	go workflowService.PruneCheckpoints(context.Background())
+
+	// 创建执行记录存储
+	var traces *trace.Store
+	if cfg.Trace.Enabled {
+		traces = trace.NewStore(cfg.Trace.MaxSessions, cfg.Trace.MaxTurns)
+	}
+
 	// 创建聊天处理器
-	chatHandler := handler.NewChatHandler(conversationService, workflowService, log)
+	chatHandler := handler.NewChatHandler(conversationService, workflowService, experiments, traces, log)
+
+	// 创建用量处理器
+	usageHandler := handler.NewUsageHandler(usageTracker, log)
+
+	// 创建计费报表处理器
+	billingHandler := handler.NewBillingHandler(biller, log)
+
+	// 创建模型档位管理处理器
+	profileHandler := handler.NewProfileHandler(modelManager, log)
+
+	// 创建提示词管理处理器
+	promptHandler := handler.NewPromptHandler(prompts, log)
+
+	// 创建A/B实验处理器
+	experimentHandler := handler.NewExperimentHandler(experiments, log)
+
+	// 创建工具调用确认处理器
+	approvalHandler := handler.NewApprovalHandler(workflowService, traces, log)
+
+	// 创建执行记录处理器
+	traceHandler := handler.NewTraceHandler(traces, log)
+
+	// 创建工具管理处理器
+	toolHandler := handler.NewToolHandler(workflowService, log)
 
 	// 创建HTTP服务器
 	httpServer := server.NewServer(&cfg.Server, log)
-	httpServer.SetupRoutes(chatHandler)
+	httpServer.SetupRoutes(chatHandler, usageHandler, billingHandler, profileHandler, promptHandler, experimentHandler, approvalHandler, traceHandler, toolHandler)
 
 	// 启动HTTP服务器
 	go func() {
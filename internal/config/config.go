@@ -8,11 +8,16 @@ import (
 
 // Config 应用程序配置
 type Config struct {
-	Server    ServerConfig   `mapstructure:"server"`
-	Logger    LoggerConfig   `mapstructure:"logger"`
-	Database  DatabaseConfig `mapstructure:"database"`
-	AI        AIConfig       `mapstructure:"ai"`
-	PluginsDir string        `mapstructure:"plugins_dir"`
+	Server      ServerConfig       `mapstructure:"server"`
+	Logger      LoggerConfig       `mapstructure:"logger"`
+	Database    DatabaseConfig     `mapstructure:"database"`
+	AI          AIConfig           `mapstructure:"ai"`
+	Billing     BillingConfig      `mapstructure:"billing"`
+	Experiments []ExperimentConfig `mapstructure:"experiments"`
+	Workflow    WorkflowConfig     `mapstructure:"workflow"`
+	Trace       TraceConfig        `mapstructure:"trace"`
+	PluginsDir  string             `mapstructure:"plugins_dir"`
+	PromptsDir  string             `mapstructure:"prompts_dir"`
 }
 
 // ServerConfig 服务器配置
@@ -37,34 +42,160 @@ type LoggerConfig struct {
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
-	SSLMode  string `mapstructure:"sslmode"`
+	Type       string `mapstructure:"type"`       // 订单存储类型: memory, sqlite
+	Connection string `mapstructure:"connection"` // sqlite数据库文件路径
+	Fixtures   string `mapstructure:"fixtures"`   // 启动时导入的订单数据文件（.json 或 .csv），为空时空库写入示例订单
+	Host       string `mapstructure:"host"`
+	Port       int    `mapstructure:"port"`
+	User       string `mapstructure:"user"`
+	Password   string `mapstructure:"password"`
+	DBName     string `mapstructure:"dbname"`
+	SSLMode    string `mapstructure:"sslmode"`
 }
 
 // AIConfig AI模型配置
 type AIConfig struct {
-	Provider string       `mapstructure:"provider"`
-	OpenAI   OpenAIConfig `mapstructure:"openai"`
-	Mock     MockConfig   `mapstructure:"mock"`
+	Provider        string          `mapstructure:"provider"`
+	MaxPromptTokens int             `mapstructure:"max_prompt_tokens"` // 单次请求预估token上限，0表示不限制
+	OpenAI          OpenAIConfig    `mapstructure:"openai"`
+	Spark           SparkConfig     `mapstructure:"spark"`
+	Mock            MockConfig      `mapstructure:"mock"`
+	Cassette        CassetteConfig  `mapstructure:"cassette"`
+	Profiles        []ModelProfile  `mapstructure:"profiles"`        // 命名模型档位，请求可按名称选择
+	TenantProfiles  []TenantProfile `mapstructure:"tenant_profiles"` // 租户默认档位
+}
+
+// ModelProfile 命名模型档位，未设置的生成参数沿用对应提供商的配置
+type ModelProfile struct {
+	Name           string   `mapstructure:"name"`
+	Provider       string   `mapstructure:"provider"` // openai, spark, mock
+	Model          string   `mapstructure:"model"`    // 为空时使用对应提供商配置中的模型
+	Temperature    *float64 `mapstructure:"temperature"`
+	MaxTokens      int      `mapstructure:"max_tokens"`
+	TopP           *float64 `mapstructure:"top_p"`
+	Stop           []string `mapstructure:"stop"`
+	Seed           *int     `mapstructure:"seed"`
+	ResponseFormat string   `mapstructure:"response_format"` // text, json_object
+	APIKey         string   `mapstructure:"api_key"`         // 为空时使用对应提供商配置中的密钥
+	BaseURL        string   `mapstructure:"base_url"`        // 为空时使用对应提供商配置中的地址
+}
+
+// TenantProfile 租户默认档位
+type TenantProfile struct {
+	TenantID string `mapstructure:"tenant_id"`
+	Profile  string `mapstructure:"profile"`
 }
 
 // OpenAIConfig OpenAI配置
 type OpenAIConfig struct {
+	APIKey         string   `mapstructure:"api_key"`
+	BaseURL        string   `mapstructure:"base_url"`
+	Model          string   `mapstructure:"model"`
+	MaxTokens      int      `mapstructure:"max_tokens"`
+	Temperature    *float64 `mapstructure:"temperature"` // 为空时使用服务端默认值
+	TopP           *float64 `mapstructure:"top_p"`
+	Stop           []string `mapstructure:"stop"`
+	Seed           *int     `mapstructure:"seed"`
+	ResponseFormat string   `mapstructure:"response_format"` // text, json_object，为空时使用服务端默认值
+}
+
+// SparkConfig 讯飞星火配置
+type SparkConfig struct {
+	AppID       string  `mapstructure:"app_id"`
 	APIKey      string  `mapstructure:"api_key"`
-	BaseURL     string  `mapstructure:"base_url"`
-	Model       string  `mapstructure:"model"`
+	APISecret   string  `mapstructure:"api_secret"`
+	HostURL     string  `mapstructure:"host_url"`
+	Domain      string  `mapstructure:"domain"`
 	MaxTokens   int     `mapstructure:"max_tokens"`
 	Temperature float64 `mapstructure:"temperature"`
+	TopK        int     `mapstructure:"top_k"`
 }
 
 // MockConfig Mock配置
 type MockConfig struct {
 	ResponseDelay  string `mapstructure:"response_delay"`
 	DefaultResponse string `mapstructure:"default_response"`
+	FixturesFile   string `mapstructure:"fixtures_file"` // 规则fixture文件（YAML或JSON），为空时使用内置规则
+}
+
+// CassetteConfig 模型HTTP流量录制/回放配置
+type CassetteConfig struct {
+	Mode string `mapstructure:"mode"` // record, replay，为空表示不启用
+	Path string `mapstructure:"path"` // 磁带文件路径
+}
+
+// BillingConfig 计费配置
+type BillingConfig struct {
+	LedgerPath string        `mapstructure:"ledger_path"` // 账本文件路径，为空时仅保存在内存中
+	Currency   string        `mapstructure:"currency"`
+	Prices     []ModelPrice  `mapstructure:"prices"`
+	Quotas     []QuotaConfig `mapstructure:"quotas"`
+}
+
+// ModelPrice 模型单价，单位为每1000 tokens的价格
+type ModelPrice struct {
+	Model           string  `mapstructure:"model"` // 模型名，"*" 表示未单独定价模型的默认价格
+	PromptPer1K     float64 `mapstructure:"prompt_per_1k"`
+	CompletionPer1K float64 `mapstructure:"completion_per_1k"`
+}
+
+// QuotaConfig 租户消费配额
+type QuotaConfig struct {
+	TenantID       string  `mapstructure:"tenant_id"`       // 租户ID，"*" 表示未单独配置租户的默认配额
+	DailyLimit     float64 `mapstructure:"daily_limit"`     // 每日消费上限，0表示不限制
+	MonthlyLimit   float64 `mapstructure:"monthly_limit"`   // 每月消费上限，0表示不限制
+	Action         string  `mapstructure:"action"`          // 超额后的处理方式: block, downgrade
+	DowngradeModel string  `mapstructure:"downgrade_model"` // action为downgrade时改用的模型
+}
+
+// WorkflowConfig 工作流配置，限制单条消息的处理，0表示使用默认值，负数表示不限制
+type WorkflowConfig struct {
+	MaxModelTurns        int                 `mapstructure:"max_model_turns"`         // 最多调用模型的次数
+	MaxToolCalls         int                 `mapstructure:"max_tool_calls"`          // 最多执行的工具调用数
+	MaxRepeatedToolCalls int                 `mapstructure:"max_repeated_tool_calls"` // 同一工具以相同参数最多调用的次数
+	Timeout              string              `mapstructure:"timeout"`                 // 处理时限，如 60s
+	FallbackAnswer       string              `mapstructure:"fallback_answer"`         // 触发限制时的回复
+	MaxParallelTools     int                 `mapstructure:"max_parallel_tools"`      // 同一轮工具调用的最大并发数
+	ToolTimeout          string              `mapstructure:"tool_timeout"`            // 单个工具调用的时限，如 10s
+	ToolTimeouts         map[string]string   `mapstructure:"tool_timeouts"`           // 工具名称 -> 该工具调用的时限，覆盖 tool_timeout
+	RequireApproval      bool                `mapstructure:"require_approval"`        // 执行会修改数据的工具前是否需要确认
+	CheckpointDir        string              `mapstructure:"checkpoint_dir"`          // 检查点保存目录，为空时仅保存在内存中
+	CheckpointTTL        string              `mapstructure:"checkpoint_ttl"`          // 处理完成的检查点保留的时长，如 168h，为空或0表示一直保留
+	Supervisor           bool                `mapstructure:"supervisor"`              // 是否由主管智能体将每条消息交给订单、退款、发票或通用专员处理
+	ToolSelection        ToolSelectionConfig `mapstructure:"tool_selection"`
+}
+
+// ToolSelectionConfig 工具选择配置，每条消息只向模型提供相关的工具
+type ToolSelectionConfig struct {
+	Enabled     bool                `mapstructure:"enabled"`
+	MaxTools    int                 `mapstructure:"max_tools"`    // 每条消息最多提供的工具数，0表示不限制
+	IntentTools map[string][]string `mapstructure:"intent_tools"` // 意图（专员）-> 优先提供的工具
+}
+
+// TraceConfig 执行记录配置，记录每轮对话的提示词、模型调用、工具调用和步骤变化
+type TraceConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	MaxSessions int  `mapstructure:"max_sessions"` // 最多保留的会话数，超出时淘汰最久未更新的会话，0表示不限制
+	MaxTurns    int  `mapstructure:"max_turns"`    // 每个会话最多保留的轮次，0表示不限制
+}
+
+// ExperimentConfig A/B实验配置
+type ExperimentConfig struct {
+	Name        string              `mapstructure:"name"`
+	Description string              `mapstructure:"description"`
+	Enabled     bool                `mapstructure:"enabled"`
+	BucketBy    string              `mapstructure:"bucket_by"` // session, user，按会话或用户分桶，默认session
+	Traffic     *int                `mapstructure:"traffic"`   // 进入实验的流量百分比，未设置表示100，0表示不分配流量
+	Variants    []ExperimentVariant `mapstructure:"variants"`
+}
+
+// ExperimentVariant 实验分组，未设置的字段沿用默认行为
+type ExperimentVariant struct {
+	Name        string            `mapstructure:"name"`
+	Weight      int               `mapstructure:"weight"`      // 分组权重，0表示1
+	Prompts     map[string]string `mapstructure:"prompts"`     // 提示词名称 -> 版本
+	Profile     string            `mapstructure:"profile"`     // 模型档位
+	Temperature *float64          `mapstructure:"temperature"` // 生成温度
 }
 
 // min 返回两个整数中的较小值
@@ -129,7 +260,44 @@ func setDefaults() {
 	viper.SetDefault("ai.provider", "openai")
 	viper.SetDefault("ai.temperature", 0.7)
 	viper.SetDefault("ai.openai.model", "gpt-3.5-turbo")
+	viper.SetDefault("ai.spark.host_url", "wss://spark-api.xf-yun.com/v3.5/chat")
+	viper.SetDefault("ai.spark.domain", "generalv3.5")
 	
+	// 计费默认配置
+	viper.SetDefault("billing.ledger_path", "data/billing_ledger.jsonl")
+	viper.SetDefault("billing.currency", "USD")
+
+	// 订单存储默认配置
+	viper.SetDefault("database.type", "memory")
+	viper.SetDefault("database.connection", "data/app.db")
+
 	// 插件目录默认配置
 	viper.SetDefault("plugins_dir", "plugins")
+
+	// 工作流默认配置
+	viper.SetDefault("workflow.max_model_turns", 8)
+	viper.SetDefault("workflow.max_tool_calls", 16)
+	viper.SetDefault("workflow.max_repeated_tool_calls", 2)
+	viper.SetDefault("workflow.timeout", "60s")
+	viper.SetDefault("workflow.max_parallel_tools", 4)
+	viper.SetDefault("workflow.tool_timeout", "10s")
+	viper.SetDefault("workflow.require_approval", true)
+	viper.SetDefault("workflow.checkpoint_dir", "data/checkpoints")
+	viper.SetDefault("workflow.checkpoint_ttl", "168h")
+	viper.SetDefault("workflow.supervisor", true)
+	viper.SetDefault("workflow.tool_selection.enabled", true)
+	viper.SetDefault("workflow.tool_selection.max_tools", 5)
+	viper.SetDefault("workflow.tool_selection.intent_tools", map[string][]string{
+		"order":   {"order_query", "order_list"},
+		"refund":  {"refund_request", "order_query"},
+		"invoice": {"invoice_tool"},
+	})
+
+	// 执行记录默认配置
+	viper.SetDefault("trace.enabled", true)
+	viper.SetDefault("trace.max_sessions", 1000)
+	viper.SetDefault("trace.max_turns", 50)
+
+	// 提示词目录默认配置
+	viper.SetDefault("prompts_dir", "prompts")
 }
\ No newline at end of file
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/internal/service"
+	"go-smart/pkg/billing"
+	"go-smart/pkg/graph"
+	"go-smart/pkg/trace"
+	"go-smart/pkg/usage"
+)
+
+// ApprovalHandler 工具调用确认处理器
+type ApprovalHandler struct {
+	workflowService *service.WorkflowService
+	traces          *trace.Store
+	logger          *logger.Logger
+}
+
+// NewApprovalHandler 创建工具调用确认处理器
+// traces 为nil时不记录执行过程
+func NewApprovalHandler(workflowService *service.WorkflowService, traces *trace.Store, log *logger.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		workflowService: workflowService,
+		traces:          traces,
+		logger:          log,
+	}
+}
+
+// ApprovalInfo 确认请求信息
+type ApprovalInfo struct {
+	SessionID string `json:"session_id,omitempty"`
+	*graph.PendingApproval
+}
+
+// ListApprovals 获取未处理的确认请求
+func (h *ApprovalHandler) ListApprovals(c *gin.Context) {
+	checkpoints := h.workflowService.ListApprovals()
+	approvals := make([]ApprovalInfo, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		approvals = append(approvals, ApprovalInfo{
+			SessionID:       sessionOf(checkpoint),
+			PendingApproval: checkpoint.State.Pending,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": approvals,
+	})
+}
+
+// Approve 确认执行工具调用并继续处理
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	h.resolve(c, true)
+}
+
+// Reject 拒绝执行工具调用，结束本条消息的处理
+func (h *ApprovalHandler) Reject(c *gin.Context) {
+	h.resolve(c, false)
+}
+
+// resolve 处理确认结果，返回继续处理后的回复
+func (h *ApprovalHandler) resolve(c *gin.Context, approved bool) {
+	id := c.Param("id")
+	checkpoint, exists := h.workflowService.GetApproval(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "确认请求不存在或已处理: " + id,
+		})
+		return
+	}
+
+	h.logger.Info("收到工具调用确认结果", map[string]interface{}{
+		"approval_id": id,
+		"session_id":  sessionOf(checkpoint),
+		"approved":    approved,
+	})
+
+	// 继续处理产生的用量计入原会话
+	ctx, collector := usage.WithCollector(requestScope(c, sessionOf(checkpoint)))
+	input := "拒绝 " + id
+	if approved {
+		input = "确认 " + id
+	}
+	ctx, recorder := h.traces.Begin(ctx, sessionOf(checkpoint), "approval", input)
+	response, approval, err := h.workflowService.ResolveApproval(ctx, id, approved)
+	recorder.Finish(response, err)
+	if err != nil {
+		h.logger.Error("处理确认结果失败", map[string]interface{}{
+			"approval_id": id,
+			"error":       err.Error(),
+		})
+		switch {
+		case errors.Is(err, graph.ErrApprovalNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "确认请求不存在或已处理: " + id,
+			})
+		case errors.Is(err, billing.ErrQuotaExceeded):
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":  "已超出消费配额",
+				"detail": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "处理确认结果失败",
+			})
+		}
+		return
+	}
+
+	chatUsage := collector.Totals()
+	c.JSON(http.StatusOK, ChatResponse{
+		Response: response,
+		Usage:    &chatUsage,
+		Approval: approval,
+		Trace:    recorder.Ref(),
+	})
+}
+
+// sessionOf 获取检查点所属的会话ID，未绑定会话的检查点返回空
+func sessionOf(checkpoint graph.Checkpoint) string {
+	if checkpoint.Ephemeral {
+		return ""
+	}
+	return checkpoint.ID
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/pkg/billing"
+)
+
+// reportDateLayout 报表查询参数中的日期格式
+const reportDateLayout = "2006-01-02"
+
+// BillingHandler 计费报表处理器
+type BillingHandler struct {
+	biller *billing.Biller
+	logger *logger.Logger
+}
+
+// NewBillingHandler 创建计费报表处理器
+func NewBillingHandler(biller *billing.Biller, log *logger.Logger) *BillingHandler {
+	return &BillingHandler{
+		biller: biller,
+		logger: log,
+	}
+}
+
+// GetReport 查询消费报表
+// 支持 tenant_id 筛选租户，from/to 按日期（YYYY-MM-DD，to 包含当日）筛选时间范围
+func (h *BillingHandler) GetReport(c *gin.Context) {
+	filter := billing.ReportFilter{
+		TenantID: c.Query("tenant_id"),
+	}
+
+	if from := c.Query("from"); from != "" {
+		date, err := time.ParseInLocation(reportDateLayout, from, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from 日期格式无效，应为 YYYY-MM-DD",
+			})
+			return
+		}
+		filter.From = date
+	}
+	if to := c.Query("to"); to != "" {
+		date, err := time.ParseInLocation(reportDateLayout, to, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to 日期格式无效，应为 YYYY-MM-DD",
+			})
+			return
+		}
+		filter.To = date.AddDate(0, 0, 1)
+	}
+
+	h.logger.Info("查询消费报表", map[string]interface{}{
+		"tenant_id": filter.TenantID,
+		"from":      c.Query("from"),
+		"to":        c.Query("to"),
+	})
+
+	c.JSON(http.StatusOK, h.biller.Report(filter))
+}
@@ -1,26 +1,38 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/cloudwego/eino/schema"
 	"github.com/gin-gonic/gin"
 	"go-smart/internal/logger"
 	"go-smart/internal/service"
+	"go-smart/pkg/billing"
+	"go-smart/pkg/experiment"
+	"go-smart/pkg/graph"
+	"go-smart/pkg/model"
+	"go-smart/pkg/trace"
+	"go-smart/pkg/usage"
 )
 
 // ChatHandler 聊天处理器
 type ChatHandler struct {
 	conversationService *service.ConversationService
 	workflowService    *service.WorkflowService
+	experiments        *experiment.Manager
+	traces             *trace.Store
 	logger             *logger.Logger
 }
 
 // NewChatHandler 创建聊天处理器
-func NewChatHandler(conversationService *service.ConversationService, workflowService *service.WorkflowService, log *logger.Logger) *ChatHandler {
+// traces 为nil时不记录执行过程
+func NewChatHandler(conversationService *service.ConversationService, workflowService *service.WorkflowService, experiments *experiment.Manager, traces *trace.Store, log *logger.Logger) *ChatHandler {
 	return &ChatHandler{
 		conversationService: conversationService,
 		workflowService:    workflowService,
+		experiments:        experiments,
+		traces:             traces,
 		logger:             log,
 	}
 }
@@ -30,12 +42,18 @@ type ChatRequest struct {
 	Message   string `json:"message" binding:"required"`
 	SessionID string `json:"session_id,omitempty"`
 	UseWorkflow bool `json:"use_workflow,omitempty"`
+	Profile   string `json:"profile,omitempty"` // 模型档位，为空时使用租户默认档位或当前模型
+	Options   *model.GenerationOptions `json:"options,omitempty"` // 本次请求的生成参数，覆盖档位和配置中的参数
 }
 
 // ChatResponse 聊天响应
 type ChatResponse struct {
-	Response string `json:"response"`
-	Date     string `json:"date,omitempty"`
+	Response    string                  `json:"response"`
+	Date        string                  `json:"date,omitempty"`
+	Usage       *usage.Totals           `json:"usage,omitempty"`
+	Experiments []experiment.Assignment `json:"experiments,omitempty"` // 本次请求所在的实验分组
+	Approval    *graph.PendingApproval  `json:"approval,omitempty"`    // 等待确认的工具调用，确认或拒绝后继续处理
+	Trace       *trace.Ref              `json:"trace,omitempty"`       // 本轮执行记录的位置，可通过管理接口查询
 }
 
 // Chat 处理聊天请求
@@ -55,20 +73,61 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 		"message":     req.Message,
 		"session_id":  req.SessionID,
 		"use_workflow": req.UseWorkflow,
+		"profile":     req.Profile,
 	})
 
+	if req.Profile != "" && !h.conversationService.GetModelManager().HasProfile(req.Profile) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "模型档位不存在: " + req.Profile,
+		})
+		return
+	}
+
+	if req.Options != nil {
+		if err := req.Options.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "无效的生成参数: " + err.Error(),
+			})
+			return
+		}
+	}
+
 	var response string
 	var err error
+	var approval *graph.PendingApproval
+
+	// 为本次请求挂载用量归属和收集器
+	ctx, collector := usage.WithCollector(requestScope(c, req.SessionID))
+	if req.Profile != "" {
+		ctx = model.WithProfile(ctx, req.Profile)
+	}
+	if req.Options != nil {
+		ctx = model.WithGenerationOptions(ctx, *req.Options)
+	}
+
+	// 实验分组在请求参数之后应用，请求显式指定的档位和参数优先
+	ctx, assignments := h.experiments.Enroll(ctx, experiment.Subject{
+		SessionID: req.SessionID,
+		UserID:    c.GetHeader(headerUserID),
+	})
+
+	// 记录本轮的执行过程
+	source := "conversation"
+	if req.UseWorkflow {
+		source = "workflow"
+	}
+	ctx, recorder := h.traces.Begin(ctx, req.SessionID, source, req.Message)
 
 	// 根据请求决定使用哪种处理方式
 	if req.UseWorkflow {
 		// 使用新的工作流处理
 		if req.SessionID != "" {
-			response, err = h.workflowService.ProcessMultiTurnMessage(c.Request.Context(), req.SessionID, req.Message)
+			response, approval, err = h.workflowService.ProcessMultiTurnMessage(ctx, req.SessionID, req.Message)
 		} else {
-			result, procErr := h.workflowService.ProcessMessage(c.Request.Context(), req.Message)
+			result, procErr := h.workflowService.ProcessMessage(ctx, req.Message)
 			if procErr == nil {
 				response = result["response"].(string)
+				approval, _ = result["approval"].(*graph.PendingApproval)
 			}
 			if procErr != nil {
 				err = procErr
@@ -77,9 +136,9 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 	} else {
 		// 使用原有的对话服务处理
 		if req.SessionID != "" {
-			response, err = h.conversationService.ProcessMultiTurnMessage(c.Request.Context(), req.SessionID, req.Message)
+			response, err = h.conversationService.ProcessMultiTurnMessage(ctx, req.SessionID, req.Message)
 		} else {
-			result, procErr := h.conversationService.ProcessMessage(c.Request.Context(), req.Message)
+			result, procErr := h.conversationService.ProcessMessage(ctx, req.Message)
 			if procErr == nil {
 				response = result["response"].(string)
 			}
@@ -89,10 +148,19 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 		}
 	}
 
+	recorder.Finish(response, err)
+
 	if err != nil {
 		h.logger.Error("处理聊天消息失败", map[string]interface{}{
 			"error": err.Error(),
 		})
+		if errors.Is(err, billing.ErrQuotaExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":  "已超出消费配额",
+				"detail": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "处理消息失败",
 		})
@@ -100,8 +168,13 @@ func (h *ChatHandler) Chat(c *gin.Context) {
 	}
 
 	// 返回响应
+	chatUsage := collector.Totals()
 	chatResponse := ChatResponse{
-		Response: response,
+		Response:    response,
+		Usage:       &chatUsage,
+		Experiments: assignments,
+		Approval:    approval,
+		Trace:       recorder.Ref(),
 	}
 
 	c.JSON(http.StatusOK, chatResponse)
@@ -134,8 +207,8 @@ func (h *ChatHandler) OrderQuery(c *gin.Context) {
 		"query": req.Query,
 	})
 
-	// 处理订单查询
-	result, err := h.conversationService.ProcessOrderQuery(c.Request.Context(), req.Query)
+	// 处理订单查询，没有订单号时按请求头中的用户查找订单
+	result, err := h.conversationService.ProcessOrderQuery(requestScope(c, ""), req.Query)
 	if err != nil {
 		h.logger.Error("处理订单查询失败", map[string]interface{}{
 			"error": err.Error(),
@@ -265,17 +338,12 @@ func (h *ChatHandler) Clear(c *gin.Context) {
 }
 
 // InvoiceRequest 发票请求
+// 商品项原样交给对话服务，按发票工具的参数Schema（tools.InvoiceItemArgs）转换和校验
 type InvoiceRequest struct {
-	CustomerName string                 `json:"customer_name" binding:"required"`
-	CustomerEmail string                `json:"customer_email" binding:"required"`
-	Items        []InvoiceItemRequest   `json:"items" binding:"required,min=1"`
-}
-
-// InvoiceItemRequest 发票项目请求
-type InvoiceItemRequest struct {
-	Name     string  `json:"name" binding:"required"`
-	Quantity int     `json:"quantity" binding:"required,min=1"`
-	Price    float64 `json:"price" binding:"required,min=0"`
+	CustomerName  string                   `json:"customer_name" binding:"required"`
+	CustomerTaxID string                   `json:"customer_tax_id" binding:"required"`
+	CustomerEmail string                   `json:"customer_email" binding:"required"`
+	Items         []map[string]interface{} `json:"items" binding:"required,min=1"`
 }
 
 // InvoiceResponse 发票响应
@@ -306,9 +374,10 @@ func (h *ChatHandler) CreateInvoice(c *gin.Context) {
 
 	// 准备参数
 	params := map[string]interface{}{
-		"customer_name":  req.CustomerName,
-		"customer_email": req.CustomerEmail,
-		"items":          req.Items,
+		"customer_name":   req.CustomerName,
+		"customer_tax_id": req.CustomerTaxID,
+		"customer_email":  req.CustomerEmail,
+		"items":           req.Items,
 	}
 
 	// 处理发票请求
@@ -435,7 +504,8 @@ func (h *ChatHandler) GetCurrentModel(c *gin.Context) {
 
 // UpdateModelRequest 更新模型请求
 type UpdateModelRequest struct {
-	Model string `json:"model" binding:"required"`
+	Model    string `json:"model" binding:"required"`
+	Provider string `json:"provider,omitempty"` // 为空时保持当前提供商
 }
 
 // UpdateModelResponse 更新模型响应
@@ -459,14 +529,15 @@ func (h *ChatHandler) UpdateModel(c *gin.Context) {
 	}
 
 	h.logger.Info("收到更新模型请求", map[string]interface{}{
-		"model": req.Model,
+		"model":    req.Model,
+		"provider": req.Provider,
 	})
 
 	// 获取模型管理器
 	modelManager := h.conversationService.GetModelManager()
 	
-	// 更新模型
-	err := modelManager.UpdateModel("openai", req.Model, "", "") // 使用空字符串表示不更新API密钥和API基础URL
+	// 更新模型，对话、多轮对话和工作流共享同一个模型管理器，更新后立即生效
+	err := modelManager.UpdateModel(req.Provider, req.Model, "", "") // 使用空字符串表示不更新API密钥和API基础URL
 	if err != nil {
 		h.logger.Error("更新模型失败", map[string]interface{}{
 			"error": err.Error(),
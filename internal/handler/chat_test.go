@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/internal/service"
+	"go-smart/pkg/business"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+)
+
+func TestCreateInvoiceAcceptsJSONNumbers(t *testing.T) {
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	cfg := &config.Config{AI: config.AIConfig{Provider: "mock"}}
+	conversationService, err := service.NewConversationService(context.Background(), model.NewModelManager(cfg, log, nil, nil), prompt.Builtin(), business.NewMockServices(), log, cfg)
+	if err != nil {
+		t.Fatalf("NewConversationService() error: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/invoice/create", NewChatHandler(conversationService, nil, nil, nil, log).CreateInvoice)
+
+	// JSON中的数量解码为float64，单价以字符串形式提供
+	body := `{
+		"customer_name": "张三",
+		"customer_tax_id": "91110000123456789X",
+		"customer_email": "zhangsan@example.com",
+		"items": [{"name": "智能手机", "quantity": 2, "unit_price": "2999.00"}]
+	}`
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/invoice/create", strings.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body.String())
+	}
+
+	var response struct {
+		Invoice struct {
+			CustomerTaxID string                 `json:"customer_tax_id"`
+			Items         []business.InvoiceItem `json:"items"`
+			Subtotal      float64                `json:"subtotal"`
+		} `json:"invoice"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	invoice := response.Invoice
+	if invoice.CustomerTaxID != "91110000123456789X" {
+		t.Errorf("customer_tax_id = %q, want it forwarded from the request", invoice.CustomerTaxID)
+	}
+	if len(invoice.Items) != 1 || invoice.Items[0].Quantity != 2 || invoice.Subtotal != 5998 {
+		t.Errorf("invoice = %+v, want 2 x 2999.00", invoice)
+	}
+
+	// 数量不符合Schema时拒绝开票
+	invalid := strings.Replace(body, `"quantity": 2`, `"quantity": 0`, 1)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/invoice/create", strings.NewReader(invalid)))
+	if recorder.Code == http.StatusOK {
+		t.Errorf("quantity 0 accepted: %s", recorder.Body.String())
+	}
+}
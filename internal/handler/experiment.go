@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/pkg/experiment"
+)
+
+// ExperimentHandler A/B实验处理器
+type ExperimentHandler struct {
+	experiments *experiment.Manager
+	logger      *logger.Logger
+}
+
+// NewExperimentHandler 创建A/B实验处理器
+func NewExperimentHandler(experiments *experiment.Manager, log *logger.Logger) *ExperimentHandler {
+	return &ExperimentHandler{
+		experiments: experiments,
+		logger:      log,
+	}
+}
+
+// FeedbackRequest 会话评分请求
+type FeedbackRequest struct {
+	SessionID string `json:"session_id" binding:"required"`
+	Rating    int    `json:"rating" binding:"required"` // 1-5
+}
+
+// Feedback 记录用户对会话的评分，计入会话所在实验分组的结果
+func (h *ExperimentHandler) Feedback(c *gin.Context) {
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("无效的评分请求", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求格式",
+		})
+		return
+	}
+
+	err := h.experiments.Rate(req.SessionID, req.Rating)
+	if err != nil && !errors.Is(err, experiment.ErrSessionNotEnrolled) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("收到会话评分", map[string]interface{}{
+		"session_id": req.SessionID,
+		"rating":     req.Rating,
+		"recorded":   err == nil,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"recorded": err == nil, // 会话未参与实验时不计入结果
+	})
+}
+
+// ListResults 获取所有实验各分组的结果
+func (h *ExperimentHandler) ListResults(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"experiments": h.experiments.Results(),
+	})
+}
+
+// GetResult 获取指定实验各分组的结果
+func (h *ExperimentHandler) GetResult(c *gin.Context) {
+	result, err := h.experiments.Result(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/model"
+)
+
+// ProfileHandler 模型档位管理处理器
+type ProfileHandler struct {
+	modelManager *model.ModelManager
+	logger       *logger.Logger
+}
+
+// NewProfileHandler 创建模型档位管理处理器
+func NewProfileHandler(modelManager *model.ModelManager, log *logger.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		modelManager: modelManager,
+		logger:       log,
+	}
+}
+
+// ProfileListResponse 档位列表响应
+type ProfileListResponse struct {
+	Profiles       []model.ProfileInfo `json:"profiles"`
+	TenantProfiles map[string]string   `json:"tenant_profiles"`
+}
+
+// SaveProfileRequest 新增或更新档位请求
+type SaveProfileRequest struct {
+	Provider       string   `json:"provider" binding:"required"`
+	Model          string   `json:"model,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+	MaxTokens      int      `json:"max_tokens,omitempty"`
+	TopP           *float64 `json:"top_p,omitempty"`
+	Stop           []string `json:"stop,omitempty"`
+	Seed           *int     `json:"seed,omitempty"`
+	ResponseFormat string   `json:"response_format,omitempty"`
+	APIKey         string   `json:"api_key,omitempty"` // 更新时为空表示沿用原密钥
+	BaseURL        string   `json:"base_url,omitempty"`
+}
+
+// SetTenantProfileRequest 设置租户默认档位请求
+type SetTenantProfileRequest struct {
+	Profile string `json:"profile"` // 为空时清除租户默认档位
+}
+
+// ListProfiles 获取所有档位和租户默认档位
+func (h *ProfileHandler) ListProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, ProfileListResponse{
+		Profiles:       h.modelManager.ListProfiles(),
+		TenantProfiles: h.modelManager.TenantProfiles(),
+	})
+}
+
+// SaveProfile 新增或更新档位，只影响使用该档位的请求
+func (h *ProfileHandler) SaveProfile(c *gin.Context) {
+	name := c.Param("name")
+
+	var req SaveProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("无效的档位请求", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求格式",
+		})
+		return
+	}
+
+	h.logger.Info("保存模型档位", map[string]interface{}{
+		"profile":  name,
+		"provider": req.Provider,
+		"model":    req.Model,
+	})
+
+	err := h.modelManager.SaveProfile(config.ModelProfile{
+		Name:           name,
+		Provider:       req.Provider,
+		Model:          req.Model,
+		Temperature:    req.Temperature,
+		MaxTokens:      req.MaxTokens,
+		TopP:           req.TopP,
+		Stop:           req.Stop,
+		Seed:           req.Seed,
+		ResponseFormat: req.ResponseFormat,
+		APIKey:         req.APIKey,
+		BaseURL:        req.BaseURL,
+	})
+	if err != nil {
+		h.logger.Error("保存模型档位失败", map[string]interface{}{
+			"error":   err.Error(),
+			"profile": name,
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"profile": name,
+	})
+}
+
+// DeleteProfile 删除档位
+func (h *ProfileHandler) DeleteProfile(c *gin.Context) {
+	name := c.Param("name")
+
+	if err := h.modelManager.DeleteProfile(name); err != nil {
+		h.logger.Error("删除模型档位失败", map[string]interface{}{
+			"error":   err.Error(),
+			"profile": name,
+		})
+		status := http.StatusBadRequest
+		if errors.Is(err, model.ErrProfileNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"profile": name,
+	})
+}
+
+// SetTenantProfile 设置租户默认档位
+func (h *ProfileHandler) SetTenantProfile(c *gin.Context) {
+	tenantID := c.Param("tenant_id")
+
+	var req SetTenantProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的请求格式",
+		})
+		return
+	}
+
+	if err := h.modelManager.SetTenantProfile(tenantID, req.Profile); err != nil {
+		h.logger.Error("设置租户默认档位失败", map[string]interface{}{
+			"error":     err.Error(),
+			"tenant_id": tenantID,
+			"profile":   req.Profile,
+		})
+		status := http.StatusBadRequest
+		if errors.Is(err, model.ErrProfileNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"tenant_id": tenantID,
+		"profile":   req.Profile,
+	})
+}
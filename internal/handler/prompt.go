@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/pkg/prompt"
+)
+
+// PromptHandler 提示词管理处理器
+type PromptHandler struct {
+	prompts *prompt.Registry
+	logger  *logger.Logger
+}
+
+// NewPromptHandler 创建提示词管理处理器
+func NewPromptHandler(prompts *prompt.Registry, log *logger.Logger) *PromptHandler {
+	return &PromptHandler{
+		prompts: prompts,
+		logger:  log,
+	}
+}
+
+// PromptListResponse 提示词列表响应
+type PromptListResponse struct {
+	Prompts  []prompt.Info `json:"prompts"`
+	LoadedAt time.Time     `json:"loaded_at"`
+}
+
+// ListPrompts 获取所有提示词及其生效版本
+func (h *PromptHandler) ListPrompts(c *gin.Context) {
+	c.JSON(http.StatusOK, PromptListResponse{
+		Prompts:  h.prompts.List(),
+		LoadedAt: h.prompts.LoadedAt(),
+	})
+}
+
+// ReloadPrompts 立即重新加载提示词目录，模板无效时保留原有模板
+func (h *PromptHandler) ReloadPrompts(c *gin.Context) {
+	if err := h.prompts.Reload(); err != nil {
+		h.logger.Error("重新加载提示词失败", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PromptListResponse{
+		Prompts:  h.prompts.List(),
+		LoadedAt: h.prompts.LoadedAt(),
+	})
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/internal/service"
+)
+
+// ToolHandler 工具管理处理器
+type ToolHandler struct {
+	workflowService *service.WorkflowService
+	logger          *logger.Logger
+}
+
+// NewToolHandler 创建工具管理处理器
+func NewToolHandler(workflowService *service.WorkflowService, log *logger.Logger) *ToolHandler {
+	return &ToolHandler{
+		workflowService: workflowService,
+		logger:          log,
+	}
+}
+
+// ListTools 获取工作流可用的工具
+func (h *ToolHandler) ListTools(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"tools": h.workflowService.GetTools(),
+	})
+}
+
+// GetStats 获取各工具的调用次数、失败次数和耗时
+func (h *ToolHandler) GetStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"stats": h.workflowService.GetToolStats(),
+	})
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/pkg/trace"
+)
+
+// TraceHandler 执行记录处理器
+type TraceHandler struct {
+	traces *trace.Store
+	logger *logger.Logger
+}
+
+// NewTraceHandler 创建执行记录处理器，traces 为nil表示未开启执行记录
+func NewTraceHandler(traces *trace.Store, log *logger.Logger) *TraceHandler {
+	return &TraceHandler{
+		traces: traces,
+		logger: log,
+	}
+}
+
+// ListSessions 获取有执行记录的会话，最近更新的在前
+func (h *TraceHandler) ListSessions(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": h.traces.Sessions(),
+	})
+}
+
+// GetSession 获取会话每一轮的执行记录
+func (h *TraceHandler) GetSession(c *gin.Context) {
+	turns, ok := h.session(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": c.Param("session_id"),
+		"turns":      turns,
+	})
+}
+
+// GetTurn 获取会话指定轮次的执行记录
+func (h *TraceHandler) GetTurn(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	sessionID := c.Param("session_id")
+	turnNumber, err := strconv.Atoi(c.Param("turn"))
+	if err != nil || turnNumber <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "无效的轮次: " + c.Param("turn"),
+		})
+		return
+	}
+
+	turn, exists := h.traces.Turn(sessionID, turnNumber)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("执行记录不存在: %s 第%d轮", sessionID, turnNumber),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, turn)
+}
+
+// ExportSession 以JSON文件导出会话的全部执行记录
+func (h *TraceHandler) ExportSession(c *gin.Context) {
+	turns, ok := h.session(c)
+	if !ok {
+		return
+	}
+	sessionID := c.Param("session_id")
+
+	data, err := json.MarshalIndent(gin.H{
+		"session_id": sessionID,
+		"turns":      turns,
+	}, "", "  ")
+	if err != nil {
+		h.logger.Error("导出执行记录失败", map[string]interface{}{
+			"session_id": sessionID,
+			"error":      err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "导出执行记录失败",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "trace_"+sessionID+".json"))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// session 获取请求的会话的执行记录，不存在时返回404
+func (h *TraceHandler) session(c *gin.Context) ([]trace.Turn, bool) {
+	if !h.enabled(c) {
+		return nil, false
+	}
+	sessionID := c.Param("session_id")
+	turns, exists := h.traces.Session(sessionID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "会话没有执行记录: " + sessionID,
+		})
+		return nil, false
+	}
+	return turns, true
+}
+
+// enabled 判断是否开启了执行记录，未开启时返回404
+func (h *TraceHandler) enabled(c *gin.Context) bool {
+	if h.traces == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "未开启执行记录",
+		})
+		return false
+	}
+	return true
+}
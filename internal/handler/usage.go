@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go-smart/internal/logger"
+	"go-smart/pkg/usage"
+)
+
+// 请求头中携带的调用方身份
+const (
+	headerTenantID = "X-Tenant-ID"
+	headerUserID   = "X-User-ID"
+	headerAPIKey   = "X-API-Key"
+)
+
+// UsageHandler 用量统计处理器
+type UsageHandler struct {
+	tracker *usage.Tracker
+	logger  *logger.Logger
+}
+
+// NewUsageHandler 创建用量统计处理器
+func NewUsageHandler(tracker *usage.Tracker, log *logger.Logger) *UsageHandler {
+	return &UsageHandler{
+		tracker: tracker,
+		logger:  log,
+	}
+}
+
+// UsageResponse 用量查询响应
+type UsageResponse struct {
+	SessionID string        `json:"session_id,omitempty"`
+	TenantID  string        `json:"tenant_id,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Usage     *usage.Totals `json:"usage,omitempty"`
+	Report    *usage.Report `json:"report,omitempty"`
+}
+
+// GetUsage 查询用量
+// 支持按 session_id、tenant_id 或 model 查询，不带参数时返回汇总报告
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	sessionID := c.Query("session_id")
+	tenantID := c.Query("tenant_id")
+	modelName := c.Query("model")
+
+	h.logger.Info("查询用量", map[string]interface{}{
+		"session_id": sessionID,
+		"tenant_id":  tenantID,
+		"model":      modelName,
+	})
+
+	var totals usage.Totals
+	var found bool
+	switch {
+	case sessionID != "":
+		totals, found = h.tracker.SessionUsage(sessionID)
+	case tenantID != "":
+		totals, found = h.tracker.TenantUsage(tenantID)
+	case modelName != "":
+		totals, found = h.tracker.ModelUsage(modelName)
+	default:
+		report := h.tracker.Report()
+		c.JSON(http.StatusOK, UsageResponse{Report: &report})
+		return
+	}
+
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "未找到用量记录",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, UsageResponse{
+		SessionID: sessionID,
+		TenantID:  tenantID,
+		Model:     modelName,
+		Usage:     &totals,
+	})
+}
+
+// requestScope 根据请求头和会话ID构建用量归属范围并写入上下文
+func requestScope(c *gin.Context, sessionID string) context.Context {
+	return usage.WithScope(c.Request.Context(), usage.Scope{
+		SessionID: sessionID,
+		TenantID:  c.GetHeader(headerTenantID),
+		UserID:    c.GetHeader(headerUserID),
+		APIKey:    c.GetHeader(headerAPIKey),
+	})
+}
@@ -89,7 +89,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 }
 
 // SetupRoutes 设置路由
-func (s *Server) SetupRoutes(chatHandler *handler.ChatHandler) {
+func (s *Server) SetupRoutes(chatHandler *handler.ChatHandler, usageHandler *handler.UsageHandler, billingHandler *handler.BillingHandler, profileHandler *handler.ProfileHandler, promptHandler *handler.PromptHandler, experimentHandler *handler.ExperimentHandler, approvalHandler *handler.ApprovalHandler, traceHandler *handler.TraceHandler, toolHandler *handler.ToolHandler) {
 	// 健康检查
 	s.router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -127,6 +127,45 @@ func (s *Server) SetupRoutes(chatHandler *handler.ChatHandler) {
 		// 清除对话历史接口
 		api.POST("/conversation/clear", chatHandler.Clear)
 		
+		// 工具调用确认接口
+		api.GET("/workflow/approvals", approvalHandler.ListApprovals)
+		api.POST("/workflow/approvals/:id/approve", approvalHandler.Approve)
+		api.POST("/workflow/approvals/:id/reject", approvalHandler.Reject)
+		
+		// 会话评分接口
+		api.POST("/conversation/feedback", experimentHandler.Feedback)
+		
+		// 用量统计接口
+		api.GET("/usage", usageHandler.GetUsage)
+		
+		// 计费报表接口
+		api.GET("/billing/report", billingHandler.GetReport)
+		
+		// 模型档位管理接口
+		admin := api.Group("/admin")
+		admin.GET("/profiles", profileHandler.ListProfiles)
+		admin.PUT("/profiles/:name", profileHandler.SaveProfile)
+		admin.DELETE("/profiles/:name", profileHandler.DeleteProfile)
+		admin.PUT("/tenants/:tenant_id/profile", profileHandler.SetTenantProfile)
+		
+		// 提示词管理接口
+		admin.GET("/prompts", promptHandler.ListPrompts)
+		admin.POST("/prompts/reload", promptHandler.ReloadPrompts)
+		
+		// A/B实验结果接口
+		admin.GET("/experiments", experimentHandler.ListResults)
+		admin.GET("/experiments/:name/results", experimentHandler.GetResult)
+		
+		// 执行记录接口
+		admin.GET("/traces", traceHandler.ListSessions)
+		admin.GET("/traces/:session_id", traceHandler.GetSession)
+		admin.GET("/traces/:session_id/turns/:turn", traceHandler.GetTurn)
+		admin.GET("/traces/:session_id/export", traceHandler.ExportSession)
+		
+		// 工具管理接口
+		admin.GET("/tools", toolHandler.ListTools)
+		admin.GET("/tools/stats", toolHandler.GetStats)
+		
 		// 测试接口
 		api.GET("/ping", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
@@ -8,17 +8,18 @@ import (
 	"strings"
 	"time"
 
-	"github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"go-smart/internal/config"
 	"go-smart/internal/logger"
+	"go-smart/pkg/business"
 	"go-smart/pkg/conversation"
 	"go-smart/pkg/date"
 	modelpkg "go-smart/pkg/model"
 	"go-smart/pkg/plugin"
+	"go-smart/pkg/prompt"
 	"go-smart/pkg/tools"
+	"go-smart/pkg/usage"
 )
 
 // ConversationService 对话服务
@@ -30,28 +31,26 @@ type ConversationService struct {
 	conversationMgr    *conversation.Manager
 	modelManager       *modelpkg.ModelManager
 	pluginManager      *plugin.PluginManager
-	invoiceTool        *tools.InvoiceTool
-	orderTool          *tools.QueryOrder
-	refundTool         *tools.RefundTool
+	invoices           *business.InvoiceService
+	orders             *business.OrderService
+	refunds            *business.RefundService
 }
 
 // NewConversationService 创建新的对话服务
-func NewConversationService(ctx context.Context, chatModel model.BaseChatModel, log *logger.Logger, cfg *config.Config) (*ConversationService, error) {
+// 对话链和多轮对话都通过模型管理器的代理模型调用，模型切换后立即生效
+// 系统提示从提示词注册表读取，模板热更新后立即生效
+func NewConversationService(ctx context.Context, modelManager *modelpkg.ModelManager, prompts *prompt.Registry, services *business.Services, log *logger.Logger, cfg *config.Config) (*ConversationService, error) {
 	// 创建日期处理器
 	dateParser := date.NewDateProcessor()
 	
-	// 创建模型管理器
-	modelManager := modelpkg.NewModelManager(cfg, log)
+	// 获取当前模型代理
+	chatModel := modelManager.ChatModel()
 	
 	// 创建插件管理器
 	pluginManager := plugin.NewPluginManager(log, cfg.PluginsDir)
 	
 	// 创建对话模板
-	chatTemplate := prompt.FromMessages(
-		schema.FString,
-		schema.SystemMessage("你是一个智能客服助手，专门帮助用户处理订单、发票和退款相关的问题。当前时间是 {current_date}。"),
-		schema.UserMessage("{query}"),
-	)
+	chatTemplate := prompts.ChatTemplate(prompt.CustomerService)
 	
 	// 创建输出解析器
 	outputParser := compose.InvokableLambda(func(ctx context.Context, msg *schema.Message) (map[string]any, error) {
@@ -84,15 +83,13 @@ func NewConversationService(ctx context.Context, chatModel model.BaseChatModel,
 	// 创建对话管理器
 	conversationMgr := conversation.NewManager()
 	
-	// 创建工具
-	orderTool := tools.NewQueryOrder()
-	refundTool := tools.NewRefundTool(orderTool)
-	invoiceTool := tools.NewInvoiceTool()
-	
 	// 创建多轮对话处理器
 	multiTurnConv := conversation.NewMultiTurnConversation(
 		conversationMgr,
 		chatModel,
+		prompts,
+		services.Orders,
+		services.Refunds,
 	)
 	
 	return &ConversationService{
@@ -103,9 +100,9 @@ func NewConversationService(ctx context.Context, chatModel model.BaseChatModel,
 		conversationMgr: conversationMgr,
 		modelManager:    modelManager,
 		pluginManager:   pluginManager,
-		invoiceTool:     invoiceTool,
-		orderTool:       orderTool,
-		refundTool:      refundTool,
+		invoices:        services.Invoices,
+		orders:          services.Orders,
+		refunds:         services.Refunds,
 	}, nil
 }
 
@@ -118,7 +115,7 @@ func (s *ConversationService) ProcessMessage(ctx context.Context, message string
 	// 准备输入参数
 	input := map[string]any{
 		"query":        message,
-		"current_date": time.Now().Format("2006-01-02"),
+		"current_date": s.dateParser.Now().Format("2006-01-02"),
 	}
 	
 	// 执行对话链
@@ -202,6 +199,7 @@ func (s *ConversationService) ClearConversation(sessionID string) {
 }
 
 // ProcessOrderQuery 处理订单查询
+// 有订单号时查询该订单，没有订单号但说了下单时间时列出请求中用户（见 usage.ScopeFromContext）在这段时间内的订单
 func (s *ConversationService) ProcessOrderQuery(ctx context.Context, query string) (string, error) {
 	s.logger.Info("处理订单查询", map[string]interface{}{
 		"query": query,
@@ -210,30 +208,38 @@ func (s *ConversationService) ProcessOrderQuery(ctx context.Context, query strin
 	// 尝试从查询中提取订单号
 	orderID := extractOrderID(query)
 	
-	// 尝试从查询中提取日期信息
-	_, dateStr, err := s.dateParser.ExtractDateFromText(query)
-	
 	// 根据查询内容生成回复
 	var response strings.Builder
 	
-	if strings.Contains(query, "昨天") && err == nil {
-		response.WriteString(fmt.Sprintf("您查询的是昨天(%s)的订单信息。\n", dateStr))
-	} else if strings.Contains(query, "前天") && err == nil {
-		response.WriteString(fmt.Sprintf("您查询的是前天(%s)的订单信息。\n", dateStr))
-	} else if strings.Contains(query, "今天") && err == nil {
-		response.WriteString(fmt.Sprintf("您查询的是今天(%s)的订单信息。\n", dateStr))
-	}
-	
 	if orderID != "" {
-		// 调用订单查询工具获取实际订单信息
-		orderInfo, err := s.orderTool.Query(ctx, orderID)
+		// 调用订单服务获取实际订单信息
+		orderInfo, err := s.orders.Query(ctx, orderID)
 		if err != nil {
 			response.WriteString(fmt.Sprintf("查询订单失败: %s\n", err.Error()))
 		} else {
 			// 格式化订单信息
-			formattedInfo := s.orderTool.FormatOrderInfo(orderInfo)
+			formattedInfo := s.orders.FormatOrderInfo(orderInfo)
 			response.WriteString(formattedInfo)
 		}
+	} else if dateRange, err := s.dateParser.ExtractRangeFromText(query); err == nil {
+		// 没有订单号但说了下单时间，按日期和状态列出用户的订单
+		response.WriteString(fmt.Sprintf("您查询的是 %s 的订单信息。\n", dateRange))
+		userID := usage.ScopeFromContext(ctx).UserID
+		if userID == "" {
+			response.WriteString("无法确认您的账户，请提供您的订单号，以便我为您查询具体的订单信息。\n")
+		} else {
+			orders, err := s.orders.List(ctx, business.OrderFilter{
+				UserID: userID,
+				From:   dateRange.Start,
+				To:     dateRange.End,
+				Status: business.MatchOrderStatus(query),
+			})
+			if err != nil {
+				response.WriteString(fmt.Sprintf("查询订单失败: %s\n", err.Error()))
+			} else {
+				response.WriteString(s.orders.FormatOrderList(orders))
+			}
+		}
 	} else {
 		response.WriteString("请提供您的订单号，以便我为您查询具体的订单信息。\n")
 	}
@@ -268,54 +274,39 @@ func (s *ConversationService) GetPluginManager() *plugin.PluginManager {
 }
 
 // ProcessInvoiceRequest 处理发票请求
+// 客户信息和商品列表与发票工具使用同一参数结构 tools.InvoiceArgs，先按Schema转换和校验再解码，
+// 因此JSON解码得到的float64数量、字符串形式的数字都能正确处理
 func (s *ConversationService) ProcessInvoiceRequest(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
 	s.logger.Info("处理发票请求", map[string]interface{}{
 		"params": params,
 	})
 	
-	// 从参数中提取所需信息
-	customerName, ok := params["customer_name"].(string)
-	if !ok {
+	args := map[string]interface{}{"action": "create"}
+	for _, key := range []string{"customer_name", "customer_tax_id", "items"} {
+		if value, ok := params[key]; ok {
+			args[key] = value
+		}
+	}
+	invoiceArgs, err := tools.DecodeArgs[tools.InvoiceArgs]("invoice_tool", args)
+	if err != nil {
+		return nil, fmt.Errorf("发票参数不正确: %w", err)
+	}
+	if invoiceArgs.CustomerName == "" {
 		return nil, fmt.Errorf("缺少customer_name参数")
 	}
-	
-	customerTaxID, ok := params["customer_tax_id"].(string)
-	if !ok {
+	if invoiceArgs.CustomerTaxID == "" {
 		return nil, fmt.Errorf("缺少customer_tax_id参数")
 	}
-	
-	// 处理商品列表
-	itemsInterface, ok := params["items"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("缺少items参数或格式不正确")
+	if len(invoiceArgs.Items) == 0 {
+		return nil, fmt.Errorf("缺少items参数")
 	}
 	
-	items := make([]tools.InvoiceItem, 0, len(itemsInterface))
-	for _, itemInterface := range itemsInterface {
-		itemMap, ok := itemInterface.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("商品项格式不正确")
-		}
-		
-		name, ok := itemMap["name"].(string)
-		if !ok {
-			return nil, fmt.Errorf("商品缺少name字段")
-		}
-		
-		quantity, ok := itemMap["quantity"].(int)
-		if !ok {
-			return nil, fmt.Errorf("商品缺少quantity字段")
-		}
-		
-		unitPrice, ok := itemMap["unit_price"].(float64)
-		if !ok {
-			return nil, fmt.Errorf("商品缺少unit_price字段")
-		}
-		
-		items = append(items, tools.InvoiceItem{
-			Name:      name,
-			Quantity:  quantity,
-			UnitPrice: unitPrice,
+	items := make([]business.InvoiceItem, 0, len(invoiceArgs.Items))
+	for _, item := range invoiceArgs.Items {
+		items = append(items, business.InvoiceItem{
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
 		})
 	}
 	
@@ -329,8 +320,8 @@ func (s *ConversationService) ProcessInvoiceRequest(ctx context.Context, params
 		issueDate = parsedDate
 	}
 	
-	// 调用发票工具处理请求
-	invoice, err := s.invoiceTool.CreateInvoice(ctx, customerName, customerTaxID, items, issueDate)
+	// 调用发票服务处理请求
+	invoice, err := s.invoices.CreateInvoice(ctx, invoiceArgs.CustomerName, invoiceArgs.CustomerTaxID, items, issueDate)
 	if err != nil {
 		s.logger.Error("处理发票请求失败", map[string]interface{}{
 			"error": err.Error(),
@@ -366,8 +357,8 @@ func (s *ConversationService) ProcessInvoiceQuery(ctx context.Context, invoiceID
 		"invoice_id": invoiceID,
 	})
 	
-	// 调用发票工具查询发票
-	invoice, err := s.invoiceTool.QueryInvoice(ctx, invoiceID)
+	// 调用发票服务查询发票
+	invoice, err := s.invoices.QueryInvoice(ctx, invoiceID)
 	if err != nil {
 		s.logger.Error("处理发票查询失败", map[string]interface{}{
 			"error": err.Error(),
@@ -405,7 +396,7 @@ func (s *ConversationService) ProcessRefundRequest(ctx context.Context, orderID,
 	})
 	
 	// 检查退款资格
-	eligible, message, err := s.refundTool.CheckRefundEligibility(ctx, orderID)
+	eligible, message, err := s.refunds.CheckRefundEligibility(ctx, orderID)
 	if err != nil {
 		s.logger.Error("检查退款资格失败", map[string]interface{}{
 			"error": err.Error(),
@@ -425,7 +416,7 @@ func (s *ConversationService) ProcessRefundRequest(ctx context.Context, orderID,
 	}
 	
 	// 提交退款申请
-	refund, err := s.refundTool.SubmitRefund(ctx, orderID, reason)
+	refund, err := s.refunds.SubmitRefund(ctx, orderID, reason)
 	if err != nil {
 		s.logger.Error("提交退款申请失败", map[string]interface{}{
 			"error": err.Error(),
@@ -434,7 +425,7 @@ func (s *ConversationService) ProcessRefundRequest(ctx context.Context, orderID,
 	}
 	
 	// 格式化退款信息
-	formattedInfo := s.refundTool.FormatRefundInfo(refund)
+	formattedInfo := s.refunds.FormatRefundInfo(refund)
 	
 	s.logger.Info("退款申请处理成功", map[string]interface{}{
 		"refund_id": refund.RequestID,
@@ -456,7 +447,7 @@ func (s *ConversationService) QueryRefundStatus(ctx context.Context, refundID st
 	})
 	
 	// 查询退款状态
-	refund, err := s.refundTool.QueryRefund(ctx, refundID)
+	refund, err := s.refunds.QueryRefund(ctx, refundID)
 	if err != nil {
 		s.logger.Error("查询退款状态失败", map[string]interface{}{
 			"error": err.Error(),
@@ -465,7 +456,7 @@ func (s *ConversationService) QueryRefundStatus(ctx context.Context, refundID st
 	}
 	
 	// 格式化退款信息
-	formattedInfo := s.refundTool.FormatRefundInfo(refund)
+	formattedInfo := s.refunds.FormatRefundInfo(refund)
 	
 	s.logger.Info("退款状态查询成功", map[string]interface{}{
 		"refund_id": refundID,
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/business"
+	"go-smart/pkg/cassette"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+)
+
+// newCassetteConversationService 创建通过磁带访问模型的对话服务
+// 默认从磁带回放；设置 LLM_CASSETTE_MODE=record 并提供 OPENAI_API_KEY 时访问真实服务重新录制
+// 仓库中的磁带是手写的固定数据（见文件中的 note），不是真实录制
+func newCassetteConversationService(t *testing.T, name string) *ConversationService {
+	t.Helper()
+
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			Provider: "openai",
+			OpenAI: config.OpenAIConfig{
+				APIKey:  "sk-replay-placeholder",
+				BaseURL: "https://api.openai.com/v1",
+				Model:   "gpt-3.5-turbo",
+			},
+		},
+	}
+
+	mode := cassette.ModeReplay
+	if os.Getenv("LLM_CASSETTE_MODE") == string(cassette.ModeRecord) {
+		mode = cassette.ModeRecord
+		cfg.AI.OpenAI.APIKey = os.Getenv("OPENAI_API_KEY")
+		if base := os.Getenv("OPENAI_API_BASE"); base != "" {
+			cfg.AI.OpenAI.BaseURL = base
+		}
+	}
+
+	recorder, err := cassette.NewRecorder(cassette.Config{
+		Path:    "testdata/cassettes/" + name + ".json",
+		Mode:    mode,
+		Secrets: []string{cfg.AI.OpenAI.APIKey},
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	modelManager := model.NewModelManager(cfg, log, nil, recorder.Client())
+	service, err := NewConversationService(context.Background(), modelManager, prompt.Builtin(), business.NewMockServices(), log, cfg)
+	if err != nil {
+		t.Fatalf("NewConversationService() error: %v", err)
+	}
+	return service
+}
+
+func TestConversationServiceReplaysCassette(t *testing.T) {
+	service := newCassetteConversationService(t, "conversation_greeting")
+	ctx := context.Background()
+
+	// 提示词包含当前日期，固定日期后请求与录制时一致
+	service.dateParser.SetCurrentTime(time.Date(2024, 1, 15, 10, 0, 0, 0, time.Local))
+
+	result, err := service.ProcessMessage(ctx, "你好，你能帮我做什么？")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if response, _ := result["response"].(string); !strings.Contains(response, "订单") {
+		t.Errorf("ProcessMessage() response = %q, want mention of 订单", response)
+	}
+
+	// 多轮对话的订单查询按流程处理，不调用模型
+	steps := []struct {
+		message string
+		want    string
+	}{
+		{"帮我查一下订单", "订单号"},
+		{"ORD123456", "已发货"},
+	}
+	for _, step := range steps {
+		response, err := service.ProcessMultiTurnMessage(ctx, "session_cassette", step.message)
+		if err != nil {
+			t.Fatalf("ProcessMultiTurnMessage(%q) error: %v", step.message, err)
+		}
+		if !strings.Contains(response, step.want) {
+			t.Errorf("ProcessMultiTurnMessage(%q) = %q, want mention of %s", step.message, response, step.want)
+		}
+	}
+
+	history, err := service.GetConversationHistory("session_cassette")
+	if err != nil || len(history) != 2*len(steps) {
+		t.Errorf("GetConversationHistory() = %d messages, %v, want %d", len(history), err, 2*len(steps))
+	}
+}
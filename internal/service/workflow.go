@@ -5,53 +5,170 @@ import (
 	"fmt"
 	"go-smart/internal/config"
 	"go-smart/internal/logger"
+	"go-smart/pkg/business"
 	"go-smart/pkg/graph"
 	"go-smart/pkg/llm"
 	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
 	"go-smart/pkg/tools"
+	"sync"
+	"time"
 )
 
 // WorkflowService 工作流服务
+// 每个请求使用新的工作流实例，从检查点加载会话状态；同一会话的请求按会话ID串行执行，不同会话并行处理
 type WorkflowService struct {
-	workflow    *graph.Workflow
-	llmClient   llm.LLMClient
-	toolManager *tools.ToolManager
-	logger      *logger.Logger
+	llmClient       llm.LLMClient
+	toolManager     *tools.ToolManager
+	toolMetrics     *tools.ToolMetrics
+	checkpoints     *graph.CheckpointStore
+	prompts         *prompt.Registry
+	limits          graph.Limits
+	requireApproval bool
+	supervisor      *graph.Supervisor   // 为nil时不按专员处理
+	selector        *tools.ToolSelector // 为nil时不选择工具
+	sessions        sessionLocks
+	logger          *logger.Logger
 }
 
 // NewWorkflowService 创建工作流服务
-func NewWorkflowService(cfg *config.Config, log *logger.Logger) (*WorkflowService, error) {
-	// 创建模型管理器
-	modelManager := model.NewModelManager(cfg, log)
+func NewWorkflowService(modelManager *model.ModelManager, prompts *prompt.Registry, services *business.Services, cfg *config.WorkflowConfig, log *logger.Logger) (*WorkflowService, error) {
+	// 解析单条消息的处理限制
+	limits, err := graph.LimitsFromConfig(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 解析工具调用的时限
+	toolTimeout, toolTimeouts, err := parseToolTimeouts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 打开检查点存储，每个会话一个检查点，处理完成的检查点超过保留时长后删除
+	var checkpointTTL time.Duration
+	if cfg.CheckpointTTL != "" {
+		checkpointTTL, err = time.ParseDuration(cfg.CheckpointTTL)
+		if err != nil {
+			return nil, fmt.Errorf("无效的检查点保留时长 %q: %w", cfg.CheckpointTTL, err)
+		}
+	}
+	checkpoints, err := graph.OpenCheckpointStore(cfg.CheckpointDir, max(checkpointTTL, 0))
+	if err != nil {
+		return nil, err
+	}
 	
 	// 创建LLM客户端
 	llmClient := llm.NewEinoLLMClient(modelManager)
 	
-	// 创建工具管理器
-	toolManager := tools.NewToolManager()
+	// 创建工具管理器，每次调用记录执行记录、日志和调用统计，panic转换为错误，超时结束调用
+	toolManager := tools.NewToolManager(services)
+	toolMetrics := tools.NewToolMetrics()
+	toolManager.Use(tools.Tracing(), tools.Logging(log), toolMetrics.Middleware(), tools.Recover(log), tools.Timeout(toolTimeout, toolTimeouts))
 	
-	// 创建工作流
-	workflow := graph.NewWorkflow(llmClient, toolManager)
+	service := &WorkflowService{
+		llmClient:       llmClient,
+		toolManager:     toolManager,
+		toolMetrics:     toolMetrics,
+		checkpoints:     checkpoints,
+		prompts:         prompts,
+		limits:          limits,
+		requireApproval: cfg.RequireApproval,
+		sessions:        sessionLocks{locks: make(map[string]*sessionLock)},
+		logger:          log,
+	}
+	if cfg.Supervisor {
+		service.supervisor = graph.NewSupervisor(nil)
+	}
+	if cfg.ToolSelection.Enabled {
+		service.selector = tools.NewToolSelector(cfg.ToolSelection.MaxTools, cfg.ToolSelection.IntentTools)
+	}
+	return service, nil
+}
+
+// newWorkflow 创建处理一个请求的工作流，共享模型、工具和检查点存储
+func (s *WorkflowService) newWorkflow() *graph.Workflow {
+	workflow := graph.NewWorkflow(s.llmClient, s.toolManager, s.prompts, s.logger)
+	workflow.SetLimits(s.limits)
+	workflow.SetRequireApproval(s.requireApproval)
+	workflow.SetCheckpointStore(s.checkpoints)
+	if s.supervisor != nil {
+		workflow.SetSupervisor(s.supervisor)
+	}
+	if s.selector != nil {
+		workflow.SetToolSelector(s.selector)
+	}
+	return workflow
+}
+
+// sessionLocks 按会话ID加锁，同一会话的请求串行执行，不再使用的锁随之删除
+type sessionLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sessionLock
+}
+
+// sessionLock 单个会话的锁，refs 为持有或等待该锁的请求数
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock 获取会话的锁，返回释放函数
+func (l *sessionLocks) lock(id string) func() {
+	l.mu.Lock()
+	entry, exists := l.locks[id]
+	if !exists {
+		entry = &sessionLock{}
+		l.locks[id] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
 	
-	return &WorkflowService{
-		workflow:    workflow,
-		llmClient:   llmClient,
-		toolManager: toolManager,
-		logger:      log,
-	}, nil
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// parseToolTimeouts 解析工具调用的默认时限和按工具设置的时限，未设置时使用默认值，负数表示不限制
+func parseToolTimeouts(cfg *config.WorkflowConfig) (time.Duration, map[string]time.Duration, error) {
+	fallback := tools.DefaultTimeout
+	if cfg.ToolTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.ToolTimeout)
+		if err != nil {
+			return 0, nil, fmt.Errorf("无效的工具超时时间 %q: %w", cfg.ToolTimeout, err)
+		}
+		fallback = max(timeout, 0)
+	}
+	timeouts := make(map[string]time.Duration, len(cfg.ToolTimeouts))
+	for name, value := range cfg.ToolTimeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("无效的工具 %s 超时时间 %q: %w", name, value, err)
+		}
+		timeouts[name] = max(timeout, 0)
+	}
+	return fallback, timeouts, nil
 }
 
 // ProcessMessage 处理消息
+// 需要确认时结果中的 approval 为确认请求，response 为确认提示
 func (s *WorkflowService) ProcessMessage(ctx context.Context, message string) (map[string]interface{}, error) {
 	s.logger.Info("工作流处理消息", map[string]interface{}{
 		"message": message,
 	})
 	
-	// 重置工作流状态
-	s.workflow.Reset()
+	// 新的工作流使用临时检查点，不与其他请求共享状态
+	workflow := s.newWorkflow()
 	
 	// 处理消息
-	response, err := s.workflow.ProcessMessage(ctx, message)
+	response, err := workflow.ProcessMessage(ctx, message)
 	if err != nil {
 		s.logger.Error("工作流处理消息失败", map[string]interface{}{
 			"error": err.Error(),
@@ -63,29 +180,60 @@ func (s *WorkflowService) ProcessMessage(ctx context.Context, message string) (m
 		"response": response,
 	})
 	
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"response": response,
-	}, nil
+	}
+	if pending := workflow.Pending(); pending != nil {
+		result["approval"] = pending
+	}
+	return result, nil
 }
 
-// ProcessMultiTurnMessage 处理多轮对话消息
-func (s *WorkflowService) ProcessMultiTurnMessage(ctx context.Context, sessionID, message string) (string, error) {
+// ProcessMultiTurnMessage 处理多轮对话消息，需要确认时返回确认请求
+// 对话记录保存在以会话ID命名的检查点中，上一条消息的处理被中断时先从检查点继续，
+// 用户未收到的上一条回复放在本条回复之前一并返回
+// 会话有未处理的确认请求时不处理新消息，重新返回确认提示
+func (s *WorkflowService) ProcessMultiTurnMessage(ctx context.Context, sessionID, message string) (string, *graph.PendingApproval, error) {
 	s.logger.Info("工作流处理多轮对话消息", map[string]interface{}{
 		"session_id": sessionID,
 		"message":    message,
 	})
 	
-	// TODO: 实现会话状态管理
-	// 目前简单处理，每次都重置工作流
-	s.workflow.Reset()
+	unlock := s.sessions.lock(sessionID)
+	defer unlock()
+	
+	// 加载会话的检查点
+	workflow := s.newWorkflow()
+	workflow.Load(sessionID)
+	if pending := workflow.Pending(); pending != nil {
+		return pending.Message, pending, nil
+	}
+	var previous string
+	if workflow.Interrupted() {
+		var err error
+		if previous, err = workflow.Continue(ctx); err != nil {
+			s.logger.Error("工作流继续处理失败", map[string]interface{}{
+				"session_id": sessionID,
+				"error":      err.Error(),
+			})
+			return "", nil, fmt.Errorf("工作流继续处理失败: %w", err)
+		}
+		if pending := workflow.Pending(); pending != nil {
+			return pending.Message, pending, nil
+		}
+	}
 	
 	// 处理消息
-	response, err := s.workflow.ProcessMessage(ctx, message)
+	response, err := workflow.ProcessMessage(ctx, message)
 	if err != nil {
 		s.logger.Error("工作流处理多轮对话消息失败", map[string]interface{}{
 			"error": err.Error(),
 		})
-		return "", fmt.Errorf("工作流处理多轮对话消息失败: %w", err)
+		return "", nil, fmt.Errorf("工作流处理多轮对话消息失败: %w", err)
+	}
+	
+	if previous != "" {
+		response = previous + "\n\n" + response
 	}
 	
 	s.logger.Info("工作流处理多轮对话消息成功", map[string]interface{}{
@@ -93,7 +241,94 @@ func (s *WorkflowService) ProcessMultiTurnMessage(ctx context.Context, sessionID
 		"response":   response,
 	})
 	
-	return response, nil
+	return response, workflow.Pending(), nil
+}
+
+// ResolveApproval 确认或拒绝工具调用，从检查点恢复暂停的工作流继续处理
+// 继续处理时再次需要确认会返回新的确认请求
+func (s *WorkflowService) ResolveApproval(ctx context.Context, id string, approved bool) (string, *graph.PendingApproval, error) {
+	checkpoint, exists := s.checkpoints.FindApproval(id)
+	if !exists {
+		return "", nil, graph.ErrApprovalNotFound
+	}
+	
+	// 加锁后重新查找，确认请求可能已被同一会话的其他请求处理
+	unlock := s.sessions.lock(checkpoint.ID)
+	defer unlock()
+	if _, exists := s.checkpoints.FindApproval(id); !exists {
+		return "", nil, graph.ErrApprovalNotFound
+	}
+	
+	workflow := s.newWorkflow()
+	workflow.Load(checkpoint.ID)
+	response, err := workflow.Resume(ctx, approved)
+	if err != nil {
+		s.logger.Error("工作流恢复处理失败", map[string]interface{}{
+			"approval_id": id,
+			"error":       err.Error(),
+		})
+		return "", nil, fmt.Errorf("工作流恢复处理失败: %w", err)
+	}
+	return response, workflow.Pending(), nil
+}
+
+// ResumeInterrupted 从检查点继续服务重启前被中断的未绑定会话的处理
+// 绑定会话的处理留到该会话的下一条消息时继续，以便把回复返回给用户
+func (s *WorkflowService) ResumeInterrupted(ctx context.Context) {
+	for _, checkpoint := range s.checkpoints.List(graph.StatusRunning) {
+		if !checkpoint.Ephemeral {
+			continue
+		}
+		workflow := s.newWorkflow()
+		workflow.Load(checkpoint.ID)
+		_, err := workflow.Continue(ctx)
+		
+		if err != nil {
+			s.logger.Error("工作流继续处理失败", map[string]interface{}{
+				"checkpoint_id": checkpoint.ID,
+				"error":         err.Error(),
+			})
+		}
+	}
+}
+
+// checkpointPruneInterval 删除过期检查点的间隔
+const checkpointPruneInterval = time.Hour
+
+// PruneCheckpoints 定期删除超过保留时长的已完成检查点，直到上下文结束
+func (s *WorkflowService) PruneCheckpoints(ctx context.Context) {
+	ticker := time.NewTicker(checkpointPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		
+		pruned, err := s.checkpoints.Prune()
+		if err != nil {
+			s.logger.Error("删除过期检查点失败", map[string]interface{}{
+				"error": err.Error(),
+			})
+			continue
+		}
+		if pruned > 0 {
+			s.logger.Info("已删除过期检查点", map[string]interface{}{
+				"count": pruned,
+			})
+		}
+	}
+}
+
+// GetApproval 获取未处理的确认请求所在的检查点
+func (s *WorkflowService) GetApproval(id string) (graph.Checkpoint, bool) {
+	return s.checkpoints.FindApproval(id)
+}
+
+// ListApprovals 获取有未处理确认请求的检查点
+func (s *WorkflowService) ListApprovals() []graph.Checkpoint {
+	return s.checkpoints.List(graph.StatusAwaitingApproval)
 }
 
 // GetModelInfo 获取模型信息
@@ -115,4 +350,9 @@ func (s *WorkflowService) GetTools() map[string]interface{} {
 	}
 	
 	return toolInfos
-}
\ No newline at end of file
+}
+
+// GetToolStats 获取各工具的调用次数、失败次数和耗时
+func (s *WorkflowService) GetToolStats() []tools.ToolStats {
+	return s.toolMetrics.Snapshot()
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/business"
+	"go-smart/pkg/graph"
+	"go-smart/pkg/llm"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+)
+
+// newMockWorkflowService 创建使用模拟模型的工作流服务，模型每次回复前等待 delay
+func newMockWorkflowService(t *testing.T, delay string) *WorkflowService {
+	t.Helper()
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	cfg := &config.Config{AI: config.AIConfig{
+		Provider: "mock",
+		Mock:     config.MockConfig{ResponseDelay: delay, DefaultResponse: "好的"},
+	}}
+	service, err := NewWorkflowService(model.NewModelManager(cfg, log, nil, nil), prompt.Builtin(), business.NewMockServices(), &cfg.Workflow, log)
+	if err != nil {
+		t.Fatalf("NewWorkflowService() error: %v", err)
+	}
+	return service
+}
+
+// toolThenAnswer 先要求调用 blocking_tool，收到工具结果后回答的模型客户端
+type toolThenAnswer struct{}
+
+// Chat 最后一条消息是工具结果时回答，否则要求调用工具
+func (toolThenAnswer) Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}, opts ...einomodel.Option) (*llm.ChatResponse, error) {
+	if messages[len(messages)-1]["role"] == "tool" {
+		return &llm.ChatResponse{Content: "好的"}, nil
+	}
+	call := llm.ToolCall{ID: "call_1", Function: llm.ToolCallFunction{Name: "blocking_tool", Arguments: map[string]interface{}{}}}
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{call}}, nil
+}
+
+// GetModelInfo 获取模型信息
+func (toolThenAnswer) GetModelInfo() map[string]string {
+	return map[string]string{}
+}
+
+// blockingTool 只读工具，每次调用先向 started 发送通知，再等到 release 放行后返回
+type blockingTool struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+// Call 执行工具
+func (b *blockingTool) Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+		return map[string]interface{}{}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *blockingTool) GetDescription() string { return "blocking_tool" }
+func (b *blockingTool) GetName() string        { return "blocking_tool" }
+func (b *blockingTool) GetParameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (b *blockingTool) IsReadOnly(map[string]interface{}) bool { return true }
+
+func TestWorkflowServiceSerializesPerSession(t *testing.T) {
+	service := newMockWorkflowService(t, "")
+	service.llmClient = toolThenAnswer{}
+	tool := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	if err := service.toolManager.RegisterTool(tool); err != nil {
+		t.Fatalf("RegisterTool() error: %v", err)
+	}
+
+	// send 在后台处理会话的一条消息，处理结束时关闭返回的通道
+	send := func(sessionID string) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if _, _, err := service.ProcessMultiTurnMessage(context.Background(), sessionID, "你好"); err != nil {
+				t.Errorf("ProcessMultiTurnMessage(%s) error: %v", sessionID, err)
+			}
+		}()
+		return done
+	}
+	// waitStarted 等待一次工具调用开始
+	waitStarted := func(what string) {
+		t.Helper()
+		select {
+		case <-tool.started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s did not start", what)
+		}
+	}
+
+	// 不同会话并行处理：两个工具调用都在任何一个放行之前开始
+	doneA, doneB := send("session_a"), send("session_b")
+	waitStarted("session_a and session_b tool calls")
+	waitStarted("session_a and session_b tool calls")
+	tool.release <- struct{}{}
+	tool.release <- struct{}{}
+	<-doneA
+	<-doneB
+
+	// 同一会话串行处理：第一条消息的工具调用放行前，第二条消息不开始
+	first := send("session_c")
+	waitStarted("first session_c tool call")
+	second := send("session_c")
+	select {
+	case <-tool.started:
+		t.Fatal("second session_c message started before the first finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	tool.release <- struct{}{}
+	<-first
+	waitStarted("second session_c tool call")
+	tool.release <- struct{}{}
+	<-second
+
+	// 同一会话的两条消息都保存在会话的对话记录中
+	checkpoint, ok := service.checkpoints.Load("session_c")
+	if !ok {
+		t.Fatal("session_c checkpoint not saved")
+	}
+	users := 0
+	for _, message := range checkpoint.State.Messages {
+		if message.Role == "user" {
+			users++
+		}
+	}
+	if users != 2 {
+		t.Errorf("session_c checkpoint has %d user messages, want 2", users)
+	}
+	if len(service.sessions.locks) != 0 {
+		t.Errorf("%d session locks left after requests finished", len(service.sessions.locks))
+	}
+}
+
+func TestWorkflowServiceReturnsContinuedAnswer(t *testing.T) {
+	service := newMockWorkflowService(t, "100ms")
+
+	// 模型回复前请求被取消，模拟处理中途服务关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	defer time.AfterFunc(20*time.Millisecond, cancel).Stop()
+	if _, _, err := service.ProcessMultiTurnMessage(ctx, "session_resume", "你好"); err == nil {
+		t.Fatal("ProcessMultiTurnMessage() error = nil, want interruption")
+	}
+	if checkpoint, ok := service.checkpoints.Load("session_resume"); !ok || checkpoint.Status != graph.StatusRunning {
+		t.Fatalf("checkpoint = %+v, %v, want running", checkpoint, ok)
+	}
+
+	// 下一条消息先继续被中断的处理，用户未收到的回复放在本条回复之前
+	response, _, err := service.ProcessMultiTurnMessage(context.Background(), "session_resume", "还在吗")
+	if err != nil {
+		t.Fatalf("ProcessMultiTurnMessage() error: %v", err)
+	}
+	if response != "好的\n\n好的" {
+		t.Errorf("response = %q, want continued answer before the new one", response)
+	}
+}
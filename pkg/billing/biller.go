@@ -0,0 +1,228 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/usage"
+)
+
+// unassigned 报表中未携带租户、API Key或会话的记录归入该分组
+const unassigned = "unassigned"
+
+// Spend 消费汇总
+type Spend struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// add 累加一条账本记录
+func (s *Spend) add(entry Entry) {
+	s.Requests++
+	s.PromptTokens += entry.PromptTokens
+	s.CompletionTokens += entry.CompletionTokens
+	s.Cost += entry.Cost
+}
+
+// ReportFilter 报表筛选条件，零值表示不限制
+type ReportFilter struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+}
+
+// Report 消费报表
+type Report struct {
+	Currency       string           `json:"currency"`
+	Total          Spend            `json:"total"`
+	Tenants        map[string]Spend `json:"tenants"`
+	APIKeys        map[string]Spend `json:"api_keys"`
+	Sessions       map[string]Spend `json:"sessions"`
+	Models         map[string]Spend `json:"models"`
+	UnpricedModels []string         `json:"unpriced_models,omitempty"` // 未配置价格、费用按0计的模型
+	Quotas         []QuotaStatus    `json:"quotas,omitempty"`
+}
+
+// Biller 计费器，按价格表为每次模型调用计费并执行租户配额
+type Biller struct {
+	prices   *PriceTable
+	quotas   quotaSet
+	ledger   *Ledger
+	currency string
+	logger   *logger.Logger
+	now      func() time.Time
+}
+
+// NewBiller 创建计费器
+func NewBiller(cfg *config.BillingConfig, log *logger.Logger) (*Biller, error) {
+	quotas, err := newQuotaSet(cfg.Quotas)
+	if err != nil {
+		return nil, fmt.Errorf("解析配额配置失败: %w", err)
+	}
+
+	ledger, err := OpenLedger(cfg.LedgerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Biller{
+		prices:   NewPriceTable(cfg.Prices),
+		quotas:   quotas,
+		ledger:   ledger,
+		currency: cfg.Currency,
+		logger:   log,
+		now:      time.Now,
+	}, nil
+}
+
+// Attach 将计费器挂载到用量统计器，调用前检查配额，调用后记账
+func (b *Biller) Attach(tracker *usage.Tracker) {
+	tracker.SetGuard(b)
+	tracker.AddObserver(b.Observe)
+}
+
+// Admit 检查租户配额
+// 超额且处理方式为downgrade时返回降级模型名，为block时返回ErrQuotaExceeded
+func (b *Biller) Admit(ctx context.Context, modelName string) (string, error) {
+	tenantID := usage.ScopeFromContext(ctx).TenantID
+
+	status, exists := b.quotas.status(b.ledger, tenantID, b.now())
+	if !exists || !status.Exceeded {
+		return "", nil
+	}
+
+	quota, _ := b.quotas.lookup(tenantID)
+	if quota.Action == ActionDowngrade {
+		b.logger.Warn("租户消费超额，降级模型", map[string]interface{}{
+			"tenant_id":       tenantID,
+			"model":           modelName,
+			"downgrade_model": quota.DowngradeModel,
+			"daily_spend":     status.DailySpend,
+			"monthly_spend":   status.MonthlySpend,
+		})
+		return quota.DowngradeModel, nil
+	}
+
+	b.logger.Warn("租户消费超额，拒绝请求", map[string]interface{}{
+		"tenant_id":     tenantID,
+		"model":         modelName,
+		"daily_spend":   status.DailySpend,
+		"monthly_spend": status.MonthlySpend,
+	})
+	if quota.DailyLimit > 0 && status.DailySpend >= quota.DailyLimit {
+		return "", fmt.Errorf("%w: 租户今日消费 %.4f %s，已达每日上限 %.4f %s",
+			ErrQuotaExceeded, status.DailySpend, b.currency, quota.DailyLimit, b.currency)
+	}
+	return "", fmt.Errorf("%w: 租户本月消费 %.4f %s，已达每月上限 %.4f %s",
+		ErrQuotaExceeded, status.MonthlySpend, b.currency, quota.MonthlyLimit, b.currency)
+}
+
+// Observe 根据用量记录计费并写入账本
+func (b *Biller) Observe(record usage.Record) {
+	cost, priced := b.prices.Cost(record.Model, record.PromptTokens, record.CompletionTokens)
+	if !priced {
+		b.logger.Warn("模型未配置价格，费用按0记录", map[string]interface{}{
+			"model": record.Model,
+		})
+	}
+
+	entry := Entry{
+		Time:             record.Time,
+		TenantID:         record.Scope.TenantID,
+		KeyID:            MaskAPIKey(record.Scope.APIKey),
+		SessionID:        record.Scope.SessionID,
+		UserID:           record.Scope.UserID,
+		Model:            record.Model,
+		PromptTokens:     record.PromptTokens,
+		CompletionTokens: record.CompletionTokens,
+		Cost:             cost,
+		Priced:           priced,
+	}
+	if err := b.ledger.Append(entry); err != nil {
+		b.logger.Error("记账失败", map[string]interface{}{
+			"error": err.Error(),
+			"model": record.Model,
+		})
+	}
+}
+
+// Report 生成消费报表
+func (b *Biller) Report(filter ReportFilter) Report {
+	entries := b.ledger.Entries(func(entry Entry) bool {
+		if filter.TenantID != "" && entry.TenantID != filter.TenantID {
+			return false
+		}
+		if !filter.From.IsZero() && entry.Time.Before(filter.From) {
+			return false
+		}
+		if !filter.To.IsZero() && !entry.Time.Before(filter.To) {
+			return false
+		}
+		return true
+	})
+
+	report := Report{
+		Currency: b.currency,
+		Tenants:  make(map[string]Spend),
+		APIKeys:  make(map[string]Spend),
+		Sessions: make(map[string]Spend),
+		Models:   make(map[string]Spend),
+	}
+	unpriced := make(map[string]bool)
+	tenants := make(map[string]bool)
+	for _, entry := range entries {
+		report.Total.add(entry)
+		addSpend(report.Tenants, entry.TenantID, entry)
+		addSpend(report.APIKeys, entry.KeyID, entry)
+		addSpend(report.Sessions, entry.SessionID, entry)
+		addSpend(report.Models, entry.Model, entry)
+		if !entry.Priced {
+			unpriced[entry.Model] = true
+		}
+		tenants[entry.TenantID] = true
+	}
+	if filter.TenantID != "" {
+		tenants[filter.TenantID] = true
+	}
+
+	for modelName := range unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, modelName)
+	}
+	sort.Strings(report.UnpricedModels)
+
+	now := b.now()
+	for tenantID := range tenants {
+		if status, exists := b.quotas.status(b.ledger, tenantID, now); exists {
+			if status.TenantID == "" {
+				status.TenantID = unassigned
+			}
+			report.Quotas = append(report.Quotas, status)
+		}
+	}
+	sort.Slice(report.Quotas, func(i, j int) bool {
+		return report.Quotas[i].TenantID < report.Quotas[j].TenantID
+	})
+
+	return report
+}
+
+// Close 关闭账本
+func (b *Biller) Close() error {
+	return b.ledger.Close()
+}
+
+// addSpend 按分组键累加消费
+func addSpend(index map[string]Spend, key string, entry Entry) {
+	if key == "" {
+		key = unassigned
+	}
+	spend := index[key]
+	spend.add(entry)
+	index[key] = spend
+}
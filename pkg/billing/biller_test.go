@@ -0,0 +1,90 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/usage"
+)
+
+func newTestBiller(t *testing.T, path string, quota config.QuotaConfig) *Biller {
+	t.Helper()
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	biller, err := NewBiller(&config.BillingConfig{
+		LedgerPath: path,
+		Currency:   "USD",
+		Prices: []config.ModelPrice{
+			{Model: "gpt-test", PromptPer1K: 1, CompletionPer1K: 2},
+		},
+		Quotas: []config.QuotaConfig{quota},
+	}, log)
+	if err != nil {
+		t.Fatalf("NewBiller() error: %v", err)
+	}
+	t.Cleanup(func() { biller.Close() })
+	return biller
+}
+
+func TestBillerBlocksOverQuota(t *testing.T) {
+	biller := newTestBiller(t, "", config.QuotaConfig{TenantID: "*", DailyLimit: 2, Action: ActionBlock})
+	ctx := usage.WithScope(context.Background(), usage.Scope{TenantID: "t1"})
+
+	if _, err := biller.Admit(ctx, "gpt-test"); err != nil {
+		t.Fatalf("Admit() before spend error: %v", err)
+	}
+
+	// 1000 prompt + 500 completion = 1 + 1 = 2 USD
+	biller.Observe(usage.Record{Time: time.Now(), Scope: usage.Scope{TenantID: "t1"}, Model: "gpt-test", PromptTokens: 1000, CompletionTokens: 500})
+
+	if _, err := biller.Admit(ctx, "gpt-test"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("Admit() error = %v, want ErrQuotaExceeded", err)
+	}
+	other := usage.WithScope(context.Background(), usage.Scope{TenantID: "t2"})
+	if _, err := biller.Admit(other, "gpt-test"); err != nil {
+		t.Errorf("Admit() for other tenant error: %v", err)
+	}
+}
+
+func TestBillerDowngradesOverQuota(t *testing.T) {
+	biller := newTestBiller(t, "", config.QuotaConfig{TenantID: "t1", MonthlyLimit: 1, Action: ActionDowngrade, DowngradeModel: "gpt-mini"})
+	biller.Observe(usage.Record{Time: time.Now(), Scope: usage.Scope{TenantID: "t1"}, Model: "gpt-test", PromptTokens: 1000})
+
+	ctx := usage.WithScope(context.Background(), usage.Scope{TenantID: "t1"})
+	override, err := biller.Admit(ctx, "gpt-test")
+	if err != nil || override != "gpt-mini" {
+		t.Errorf("Admit() = %q, %v, want gpt-mini", override, err)
+	}
+}
+
+func TestLedgerPersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	quota := config.QuotaConfig{TenantID: "*", DailyLimit: 100}
+
+	biller := newTestBiller(t, path, quota)
+	biller.Observe(usage.Record{Time: time.Now(), Scope: usage.Scope{TenantID: "t1", SessionID: "s1", APIKey: "sk-secret"}, Model: "gpt-test", PromptTokens: 2000, CompletionTokens: 1000})
+	biller.Observe(usage.Record{Time: time.Now(), Scope: usage.Scope{TenantID: "t1"}, Model: "unknown-model", PromptTokens: 10})
+	biller.Close()
+
+	report := newTestBiller(t, path, quota).Report(ReportFilter{TenantID: "t1"})
+	if report.Total.Requests != 2 || report.Total.Cost != 4 {
+		t.Errorf("report total = %+v", report.Total)
+	}
+	if spend := report.APIKeys[MaskAPIKey("sk-secret")]; spend.Cost != 4 {
+		t.Errorf("api key spend = %+v", spend)
+	}
+	if len(report.UnpricedModels) != 1 || report.UnpricedModels[0] != "unknown-model" {
+		t.Errorf("unpriced models = %v", report.UnpricedModels)
+	}
+	if len(report.Quotas) != 1 || report.Quotas[0].DailySpend != 4 {
+		t.Errorf("quotas = %+v", report.Quotas)
+	}
+}
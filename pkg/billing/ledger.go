@@ -0,0 +1,159 @@
+package billing
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry 账本记录，对应一次模型调用的费用
+type Entry struct {
+	Time             time.Time `json:"time"`
+	TenantID         string    `json:"tenant_id,omitempty"`
+	KeyID            string    `json:"key_id,omitempty"` // API Key的摘要，账本中不保存明文
+	SessionID        string    `json:"session_id,omitempty"`
+	UserID           string    `json:"user_id,omitempty"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+	Priced           bool      `json:"priced"` // 模型是否有定价，未定价时费用记为0
+}
+
+// Ledger 持久化账本，以每行一条JSON记录的方式追加写入文件
+type Ledger struct {
+	mu      sync.RWMutex
+	file    *os.File
+	entries []Entry
+	daily   map[string]float64 // 租户+日期 -> 消费
+	monthly map[string]float64 // 租户+月份 -> 消费
+}
+
+// OpenLedger 打开账本，加载已有记录
+// path为空时账本仅保存在内存中
+func OpenLedger(path string) (*Ledger, error) {
+	ledger := &Ledger{
+		daily:   make(map[string]float64),
+		monthly: make(map[string]float64),
+	}
+	if path == "" {
+		return ledger, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("创建账本目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开账本文件失败: %w", err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("解析账本第 %d 行失败: %w", line, err)
+		}
+		ledger.index(entry)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("读取账本文件失败: %w", err)
+	}
+
+	ledger.file = file
+	return ledger, nil
+}
+
+// Append 追加一条记录
+func (l *Ledger) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("序列化账本记录失败: %w", err)
+		}
+		if _, err := l.file.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("写入账本失败: %w", err)
+		}
+	}
+
+	l.index(entry)
+	return nil
+}
+
+// TenantSpend 获取租户在指定时间所在日和月的累计消费
+func (l *Ledger) TenantSpend(tenantID string, at time.Time) (daily, monthly float64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.daily[dayKey(tenantID, at)], l.monthly[monthKey(tenantID, at)]
+}
+
+// Entries 获取满足条件的记录
+func (l *Ledger) Entries(match func(Entry) bool) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]Entry, 0)
+	for _, entry := range l.entries {
+		if match == nil || match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Close 关闭账本文件
+func (l *Ledger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// index 将记录加入内存索引，调用方需持有写锁
+func (l *Ledger) index(entry Entry) {
+	l.entries = append(l.entries, entry)
+	l.daily[dayKey(entry.TenantID, entry.Time)] += entry.Cost
+	l.monthly[monthKey(entry.TenantID, entry.Time)] += entry.Cost
+}
+
+// dayKey 按租户和自然日生成索引键
+func dayKey(tenantID string, at time.Time) string {
+	return tenantID + "|" + at.Local().Format("2006-01-02")
+}
+
+// monthKey 按租户和自然月生成索引键
+func monthKey(tenantID string, at time.Time) string {
+	return tenantID + "|" + at.Local().Format("2006-01")
+}
+
+// MaskAPIKey 生成API Key的摘要标识，用于账本和报表
+func MaskAPIKey(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key_" + hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,46 @@
+package billing
+
+import (
+	"go-smart/internal/config"
+)
+
+// defaultPriceKey 默认价格对应的模型名
+const defaultPriceKey = "*"
+
+// PriceTable 模型价格表
+type PriceTable struct {
+	prices map[string]config.ModelPrice
+}
+
+// NewPriceTable 根据配置创建价格表
+func NewPriceTable(prices []config.ModelPrice) *PriceTable {
+	table := &PriceTable{
+		prices: make(map[string]config.ModelPrice, len(prices)),
+	}
+	for _, price := range prices {
+		if price.Model == "" {
+			continue
+		}
+		table.prices[price.Model] = price
+	}
+	return table
+}
+
+// Lookup 查找模型单价，未单独定价时使用默认价格
+func (t *PriceTable) Lookup(modelName string) (config.ModelPrice, bool) {
+	if price, exists := t.prices[modelName]; exists {
+		return price, true
+	}
+	price, exists := t.prices[defaultPriceKey]
+	return price, exists
+}
+
+// Cost 计算一次调用的费用，返回值第二项表示模型是否有定价
+func (t *PriceTable) Cost(modelName string, promptTokens, completionTokens int) (float64, bool) {
+	price, exists := t.Lookup(modelName)
+	if !exists {
+		return 0, false
+	}
+	cost := float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K
+	return cost, true
+}
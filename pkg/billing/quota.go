@@ -0,0 +1,88 @@
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go-smart/internal/config"
+)
+
+// ErrQuotaExceeded 租户消费已达配额上限
+var ErrQuotaExceeded = errors.New("spending quota exceeded")
+
+// 超额处理方式
+const (
+	ActionBlock     = "block"
+	ActionDowngrade = "downgrade"
+)
+
+// defaultQuotaKey 默认配额对应的租户ID
+const defaultQuotaKey = "*"
+
+// QuotaStatus 租户配额使用情况
+type QuotaStatus struct {
+	TenantID     string  `json:"tenant_id"`
+	DailySpend   float64 `json:"daily_spend"`
+	DailyLimit   float64 `json:"daily_limit"`
+	MonthlySpend float64 `json:"monthly_spend"`
+	MonthlyLimit float64 `json:"monthly_limit"`
+	Action       string  `json:"action"`
+	Exceeded     bool    `json:"exceeded"`
+}
+
+// quotaSet 租户配额集合
+type quotaSet map[string]config.QuotaConfig
+
+// newQuotaSet 根据配置创建配额集合
+func newQuotaSet(quotas []config.QuotaConfig) (quotaSet, error) {
+	set := make(quotaSet, len(quotas))
+	for _, quota := range quotas {
+		if quota.TenantID == "" {
+			return nil, fmt.Errorf("配额缺少 tenant_id")
+		}
+		if quota.Action == "" {
+			quota.Action = ActionBlock
+		}
+		switch quota.Action {
+		case ActionBlock:
+		case ActionDowngrade:
+			if quota.DowngradeModel == "" {
+				return nil, fmt.Errorf("租户 %s 的配额为 downgrade 但未配置 downgrade_model", quota.TenantID)
+			}
+		default:
+			return nil, fmt.Errorf("租户 %s 的配额处理方式无效: %s", quota.TenantID, quota.Action)
+		}
+		set[quota.TenantID] = quota
+	}
+	return set, nil
+}
+
+// lookup 查找租户配额，未单独配置时使用默认配额
+func (s quotaSet) lookup(tenantID string) (config.QuotaConfig, bool) {
+	if quota, exists := s[tenantID]; exists {
+		return quota, true
+	}
+	quota, exists := s[defaultQuotaKey]
+	return quota, exists
+}
+
+// status 计算租户的配额使用情况
+func (s quotaSet) status(ledger *Ledger, tenantID string, now time.Time) (QuotaStatus, bool) {
+	quota, exists := s.lookup(tenantID)
+	if !exists {
+		return QuotaStatus{}, false
+	}
+
+	daily, monthly := ledger.TenantSpend(tenantID, now)
+	return QuotaStatus{
+		TenantID:     tenantID,
+		DailySpend:   daily,
+		DailyLimit:   quota.DailyLimit,
+		MonthlySpend: monthly,
+		MonthlyLimit: quota.MonthlyLimit,
+		Action:       quota.Action,
+		Exceeded: (quota.DailyLimit > 0 && daily >= quota.DailyLimit) ||
+			(quota.MonthlyLimit > 0 && monthly >= quota.MonthlyLimit),
+	}, true
+}
@@ -0,0 +1,232 @@
+package business
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smart/internal/config"
+)
+
+func TestServicesShareRepositories(t *testing.T) {
+	ctx := context.Background()
+	services := NewMockServices()
+
+	order, err := services.Orders.Query(ctx, "ORD345678")
+	if err != nil || order.TotalAmount != 3086 {
+		t.Fatalf("Query(ORD345678) = %+v, %v", order, err)
+	}
+	if _, err := services.Orders.Query(ctx, "ORD000000"); err == nil || err.Error() != "订单不存在: ORD000000" {
+		t.Errorf("Query(missing) error = %v", err)
+	}
+
+	// 新申请号在示例申请之后编号，金额取自订单服务
+	refund, err := services.Refunds.SubmitRefund(ctx, "ORD345678", "不想要了")
+	if err != nil {
+		t.Fatalf("SubmitRefund() error: %v", err)
+	}
+	if refund.RequestID != "REF003" || refund.Amount != 3086 || refund.Status != "处理中" {
+		t.Errorf("refund = %+v", refund)
+	}
+	stored, err := services.Refunds.QueryRefund(ctx, refund.RequestID)
+	if err != nil || stored.OrderID != "ORD345678" {
+		t.Errorf("QueryRefund(%s) = %+v, %v", refund.RequestID, stored, err)
+	}
+	processed, err := services.Refunds.ProcessRefund(ctx, refund.RequestID)
+	if err != nil || processed.Status == "处理中" {
+		t.Fatalf("ProcessRefund() = %+v, %v", processed, err)
+	}
+	if stored, _ := services.Refunds.QueryRefund(ctx, refund.RequestID); stored.Status != processed.Status {
+		t.Errorf("processed status not saved: %s", stored.Status)
+	}
+
+	items := []InvoiceItem{{Name: "手机壳", Quantity: 2, UnitPrice: 49.5}}
+	invoice, err := services.Invoices.CreateInvoice(ctx, "张三", "110101199001011234", items, order.CreateTime)
+	if err != nil {
+		t.Fatalf("CreateInvoice() error: %v", err)
+	}
+	if invoice.Subtotal != 99 || invoice.Items[0].Total != 99 || items[0].Total != 0 {
+		t.Errorf("invoice = %+v, items = %+v", invoice, items)
+	}
+	if _, err := services.Invoices.UpdateInvoiceStatus(ctx, invoice.InvoiceID, "已发送"); err != nil {
+		t.Fatalf("UpdateInvoiceStatus() error: %v", err)
+	}
+	queried, err := services.Invoices.QueryInvoice(ctx, invoice.InvoiceID)
+	if err != nil || queried.Status != "已发送" {
+		t.Errorf("QueryInvoice(%s) = %+v, %v", invoice.InvoiceID, queried, err)
+	}
+	if !strings.Contains(services.Invoices.FormatInvoiceInfo(queried), "价税合计: 111.87") {
+		t.Errorf("formatted invoice = %s", services.Invoices.FormatInvoiceInfo(queried))
+	}
+}
+
+func TestSQLiteOrderRepositoryWithFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	csvOrders, err := LoadOrderFixtures("testdata/orders.csv")
+	if err != nil {
+		t.Fatalf("LoadOrderFixtures(csv) error: %v", err)
+	}
+	if len(csvOrders) != 2 || len(csvOrders[0].ProductList) != 2 || csvOrders[0].TotalAmount != 638 {
+		t.Fatalf("csv orders = %+v", csvOrders)
+	}
+	if !csvOrders[1].ShipTime.IsZero() || !csvOrders[1].CreateTime.Equal(time.Date(2025, 3, 5, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("csv times = %v, %v", csvOrders[1].CreateTime, csvOrders[1].ShipTime)
+	}
+	if _, err := LoadOrderFixtures("testdata/orders.txt"); err == nil {
+		t.Error("LoadOrderFixtures(txt) error = nil, want unsupported format")
+	}
+
+	path := filepath.Join(t.TempDir(), "orders.db")
+	cfg := config.DatabaseConfig{Type: StorageSQLite, Connection: path, Fixtures: "testdata/orders.json"}
+	orders, err := OpenOrderRepository(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrderRepository() error: %v", err)
+	}
+	for _, order := range csvOrders {
+		if err := orders.Save(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orders.(io.Closer).Close()
+
+	// 重新打开后数据仍在，未配置数据文件时不再写入示例订单
+	cfg.Fixtures = ""
+	orders, err = OpenOrderRepository(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrderRepository(reopen) error: %v", err)
+	}
+	defer orders.(io.Closer).Close()
+	if count, err := orders.Count(ctx); err != nil || count != 3 {
+		t.Errorf("Count() = %d, %v, want 3", count, err)
+	}
+
+	services := NewServicesWithOrders(orders)
+	order, err := services.Orders.Query(ctx, "ORD900003")
+	if err != nil {
+		t.Fatalf("Query(ORD900003) error: %v", err)
+	}
+	if order.TotalAmount != 299 || order.ProductList[0].Name != "显示器支架" || order.EstDelivery.Format("2006-01-02") != "2025-02-23" {
+		t.Errorf("order = %+v", order)
+	}
+	if _, err := services.Orders.Query(ctx, "ORD123456"); err == nil {
+		t.Error("Query(ORD123456) found a sample order in a seeded database")
+	}
+
+	// 退款和发票通过同一订单存储读取订单
+	refund, err := services.Refunds.SubmitRefund(ctx, "ORD900001", "不想要了")
+	if err != nil || refund.Amount != 638 {
+		t.Errorf("SubmitRefund(ORD900001) = %+v, %v", refund, err)
+	}
+	invoice, err := services.Invoices.CreateOrderInvoice(ctx, "ORD900001", "张三", "110101199001011234")
+	if err != nil || len(invoice.Items) != 2 || invoice.Subtotal != 638 {
+		t.Errorf("CreateOrderInvoice(ORD900001) = %+v, %v", invoice, err)
+	}
+}
+
+func TestListOrdersByUserAndDate(t *testing.T) {
+	ctx := context.Background()
+	fixtures, err := LoadOrderFixtures("testdata/orders.csv")
+	if err != nil {
+		t.Fatalf("LoadOrderFixtures(csv) error: %v", err)
+	}
+
+	// 早期创建的订单表没有 user_id 字段，打开时自动补充
+	path := filepath.Join(t.TempDir(), "orders.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyTable := strings.Replace(createOrdersTable, "user_id       TEXT NOT NULL DEFAULT '',", "", 1)
+	if _, err := db.Exec(legacyTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO orders (order_id, status, create_time) VALUES ('ORD900009', '已发货', '2025-03-01T09:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	sqliteOrders, err := OpenSQLiteOrderRepository(path)
+	if err != nil {
+		t.Fatalf("OpenSQLiteOrderRepository(legacy) error: %v", err)
+	}
+	defer sqliteOrders.Close()
+	for _, order := range fixtures {
+		if err := sqliteOrders.Save(ctx, order); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	march1 := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	repositories := map[string]OrderRepository{
+		StorageMemory: NewMemoryOrderRepository(fixtures...),
+		StorageSQLite: sqliteOrders,
+	}
+	for name, repository := range repositories {
+		orders := NewServicesWithOrders(repository).Orders
+
+		listed, err := orders.List(ctx, OrderFilter{UserID: "U2001", From: march1, To: march1.AddDate(0, 0, 1)})
+		if err != nil || len(listed) != 1 || listed[0].OrderID != "ORD900001" || len(listed[0].ProductList) != 2 {
+			t.Errorf("%s: List(2025-03-01) = %+v, %v", name, listed, err)
+		}
+
+		// 不限日期时从新到旧排列
+		listed, err = orders.List(ctx, OrderFilter{UserID: "U2001"})
+		if err != nil || len(listed) != 2 || listed[0].OrderID != "ORD900002" {
+			t.Errorf("%s: List(U2001) = %+v, %v", name, listed, err)
+		}
+		if formatted := orders.FormatOrderList(listed); !strings.Contains(formatted, "共找到 2 个订单") || !strings.Contains(formatted, "机械键盘、键帽套装") {
+			t.Errorf("%s: formatted list = %s", name, formatted)
+		}
+
+		listed, err = orders.List(ctx, OrderFilter{UserID: "U2001", Status: MatchOrderStatus("待发货的订单")})
+		if err != nil || len(listed) != 1 || listed[0].OrderID != "ORD900002" {
+			t.Errorf("%s: List(待发货) = %+v, %v", name, listed, err)
+		}
+
+		if _, err := orders.List(ctx, OrderFilter{From: march1}); err == nil {
+			t.Errorf("%s: List() without user error = nil", name)
+		}
+	}
+}
+
+func TestSaveOrderOverwritesUser(t *testing.T) {
+	ctx := context.Background()
+	sqliteOrders, err := OpenSQLiteOrderRepository(filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLiteOrderRepository() error: %v", err)
+	}
+	defer sqliteOrders.Close()
+
+	repositories := map[string]OrderRepository{
+		StorageMemory: NewMemoryOrderRepository(),
+		StorageSQLite: sqliteOrders,
+	}
+	for name, repository := range repositories {
+		order := OrderInfo{OrderID: "ORD900100", UserID: "U3001", Status: "待发货", CreateTime: time.Now()}
+		if err := repository.Save(ctx, order); err != nil {
+			t.Fatalf("%s: Save() error: %v", name, err)
+		}
+
+		// 重新保存时订单改到另一个用户名下
+		order.UserID, order.Status = "U3002", "已发货"
+		if err := repository.Save(ctx, order); err != nil {
+			t.Fatalf("%s: Save() again error: %v", name, err)
+		}
+
+		saved, err := repository.Get(ctx, "ORD900100")
+		if err != nil || saved.UserID != "U3002" || saved.Status != "已发货" {
+			t.Errorf("%s: Get() = %+v, %v", name, saved, err)
+		}
+		if listed, err := repository.List(ctx, OrderFilter{UserID: "U3001"}); err != nil || len(listed) != 0 {
+			t.Errorf("%s: List(U3001) = %+v, %v, want no orders", name, listed, err)
+		}
+		if listed, err := repository.List(ctx, OrderFilter{UserID: "U3002"}); err != nil || len(listed) != 1 {
+			t.Errorf("%s: List(U3002) = %+v, %v, want 1 order", name, listed, err)
+		}
+	}
+}
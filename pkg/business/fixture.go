@@ -0,0 +1,263 @@
+package business
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-smart/internal/config"
+)
+
+// 订单存储类型
+const (
+	StorageMemory = "memory"
+	StorageSQLite = "sqlite"
+)
+
+// fixtureTimeLayouts 订单数据中支持的时间格式，不带时区的时间按本地时间解析
+var fixtureTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// OpenOrderRepository 按数据库配置打开订单存储
+// 配置了 fixtures 时导入其中的订单（订单号相同的覆盖），否则存储中没有订单时写入示例订单
+// 返回的存储实现 io.Closer 时，使用完毕后需要关闭
+func OpenOrderRepository(ctx context.Context, cfg config.DatabaseConfig) (OrderRepository, error) {
+	var orders OrderRepository
+	switch cfg.Type {
+	case "", StorageMemory:
+		orders = NewMemoryOrderRepository()
+	case StorageSQLite:
+		repository, err := OpenSQLiteOrderRepository(cfg.Connection)
+		if err != nil {
+			return nil, err
+		}
+		orders = repository
+	default:
+		return nil, fmt.Errorf("不支持的订单存储类型: %s", cfg.Type)
+	}
+
+	if err := seedOrders(ctx, orders, cfg.Fixtures); err != nil {
+		if closer, ok := orders.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return orders, nil
+}
+
+// seedOrders 导入订单数据文件，未指定文件且存储为空时写入示例订单
+func seedOrders(ctx context.Context, orders OrderRepository, fixtures string) error {
+	var seed []OrderInfo
+	if fixtures != "" {
+		loaded, err := LoadOrderFixtures(fixtures)
+		if err != nil {
+			return err
+		}
+		seed = loaded
+	} else {
+		count, err := orders.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		seed = mockOrders(time.Now())
+	}
+
+	for _, order := range seed {
+		if err := orders.Save(ctx, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadOrderFixtures 从JSON或CSV文件读取订单，按扩展名区分格式
+//
+// JSON文件为订单数组，字段与 OrderInfo 的json标签相同。
+// CSV文件首行为列名，每行一个商品，订单号相同的行属于同一订单，订单字段取第一行的值。
+// 必需的列为 order_id、status、create_time，可选的列为 user_id、pay_time、ship_time、total_amount、ship_address、
+// tracking_info、est_delivery、product_id、product_name、price、quantity。
+// 两种格式的时间都可以是RFC3339、"2006-01-02 15:04:05" 或 "2006-01-02"，未填写订单总额时按商品计算
+func LoadOrderFixtures(path string) ([]OrderInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开订单数据文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var orders []OrderInfo
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		orders, err = decodeJSONOrders(file)
+	case ".csv":
+		orders, err = decodeCSVOrders(file)
+	default:
+		return nil, fmt.Errorf("不支持的订单数据格式: %s，应为 .json 或 .csv", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取订单数据文件 %s 失败: %w", path, err)
+	}
+
+	for i := range orders {
+		if orders[i].OrderID == "" {
+			return nil, fmt.Errorf("订单数据文件 %s 中第 %d 个订单缺少订单号", path, i+1)
+		}
+		if orders[i].TotalAmount == 0 {
+			for _, product := range orders[i].ProductList {
+				orders[i].TotalAmount += product.Price * float64(product.Quantity)
+			}
+		}
+	}
+	return orders, nil
+}
+
+// fixtureOrder JSON订单数据，时间字段允许多种格式
+type fixtureOrder struct {
+	OrderInfo
+	CreateTime  string `json:"create_time"`
+	PayTime     string `json:"pay_time"`
+	ShipTime    string `json:"ship_time"`
+	EstDelivery string `json:"est_delivery"`
+}
+
+// decodeJSONOrders 解析JSON订单数组
+func decodeJSONOrders(r io.Reader) ([]OrderInfo, error) {
+	var fixtures []fixtureOrder
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, err
+	}
+	orders := make([]OrderInfo, 0, len(fixtures))
+	for _, fixture := range fixtures {
+		order := fixture.OrderInfo
+		if err := parseTimes(map[string]string{
+			"create_time":  fixture.CreateTime,
+			"pay_time":     fixture.PayTime,
+			"ship_time":    fixture.ShipTime,
+			"est_delivery": fixture.EstDelivery,
+		}, &order); err != nil {
+			return nil, fmt.Errorf("订单 %s: %w", order.OrderID, err)
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// decodeCSVOrders 解析CSV订单数据，每行一个商品
+func decodeCSVOrders(r io.Reader) ([]OrderInfo, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取列名失败: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, required := range []string{"order_id", "status", "create_time"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("缺少必需的列: %s", required)
+		}
+	}
+
+	var orders []OrderInfo
+	index := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(name string) string {
+			if i, exists := columns[name]; exists && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		orderID := value("order_id")
+		position, exists := index[orderID]
+		if !exists {
+			order := OrderInfo{
+				OrderID:      orderID,
+				UserID:       value("user_id"),
+				Status:       value("status"),
+				ShipAddress:  value("ship_address"),
+				TrackingInfo: value("tracking_info"),
+			}
+			if amount := value("total_amount"); amount != "" {
+				if order.TotalAmount, err = strconv.ParseFloat(amount, 64); err != nil {
+					return nil, fmt.Errorf("第 %d 行: 无效的订单总额 %q", line, amount)
+				}
+			}
+			if err := parseTimes(map[string]string{
+				"create_time":  value("create_time"),
+				"pay_time":     value("pay_time"),
+				"ship_time":    value("ship_time"),
+				"est_delivery": value("est_delivery"),
+			}, &order); err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", line, err)
+			}
+			position = len(orders)
+			index[orderID] = position
+			orders = append(orders, order)
+		}
+
+		if name := value("product_name"); name != "" {
+			product := Product{ID: value("product_id"), Name: name, Quantity: 1}
+			if price := value("price"); price != "" {
+				if product.Price, err = strconv.ParseFloat(price, 64); err != nil {
+					return nil, fmt.Errorf("第 %d 行: 无效的单价 %q", line, price)
+				}
+			}
+			if quantity := value("quantity"); quantity != "" {
+				if product.Quantity, err = strconv.Atoi(quantity); err != nil {
+					return nil, fmt.Errorf("第 %d 行: 无效的数量 %q", line, quantity)
+				}
+			}
+			orders[position].ProductList = append(orders[position].ProductList, product)
+		}
+	}
+	return orders, nil
+}
+
+// parseTimes 解析订单的各个时间字段
+func parseTimes(values map[string]string, order *OrderInfo) error {
+	targets := map[string]*time.Time{
+		"create_time":  &order.CreateTime,
+		"pay_time":     &order.PayTime,
+		"ship_time":    &order.ShipTime,
+		"est_delivery": &order.EstDelivery,
+	}
+	for name, value := range values {
+		parsed, err := parseTime(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*targets[name] = parsed
+	}
+	return nil
+}
+
+// parseTime 按支持的格式解析时间，空字符串表示未发生
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range fixtureTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 %q", value)
+}
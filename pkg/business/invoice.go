@@ -0,0 +1,198 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// invoiceTaxRate 开具发票的税率
+const invoiceTaxRate = 0.13
+
+// invoiceStatuses 有效的发票状态
+var invoiceStatuses = map[string]bool{
+	"已开具": true,
+	"已发送": true,
+	"已支付": true,
+	"已逾期": true,
+	"已作废": true,
+}
+
+// InvoiceItem 发票项目
+type InvoiceItem struct {
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+}
+
+// Invoice 发票
+type Invoice struct {
+	InvoiceID     string        `json:"invoice_id"`
+	CustomerName  string        `json:"customer_name"`
+	CustomerTaxID string        `json:"customer_tax_id"`
+	Items         []InvoiceItem `json:"items"`
+	IssueDate     time.Time     `json:"issue_date"`
+	DueDate       time.Time     `json:"due_date"`
+	Subtotal      float64       `json:"subtotal"`
+	TaxRate       float64       `json:"tax_rate"`
+	TaxAmount     float64       `json:"tax_amount"`
+	TotalWithTax  float64       `json:"total_with_tax"`
+	Status        string        `json:"status"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// InvoiceRepository 发票存储
+type InvoiceRepository interface {
+	// NextID 生成新的发票号
+	NextID(ctx context.Context) (string, error)
+	// Save 保存发票，发票号已存在时覆盖
+	Save(ctx context.Context, invoice Invoice) error
+	// Get 获取发票，不存在时返回 ErrNotFound
+	Get(ctx context.Context, invoiceID string) (*Invoice, error)
+}
+
+// InvoiceService 发票服务，按订单开票时商品取自订单服务中的订单
+type InvoiceService struct {
+	orders   *OrderService
+	invoices InvoiceRepository
+}
+
+// NewInvoiceService 创建发票服务
+func NewInvoiceService(orders *OrderService, invoices InvoiceRepository) *InvoiceService {
+	return &InvoiceService{
+		orders:   orders,
+		invoices: invoices,
+	}
+}
+
+// CreateInvoice 创建发票，issueDate 为零值时使用当前日期
+func (s *InvoiceService) CreateInvoice(ctx context.Context, customerName, customerTaxID string, items []InvoiceItem, issueDate time.Time) (*Invoice, error) {
+	// 验证输入参数
+	if customerName == "" || customerTaxID == "" {
+		return nil, fmt.Errorf("客户名称和税号不能为空")
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("商品列表不能为空")
+	}
+
+	// 验证商品信息并计算小计和不含税金额
+	items = append([]InvoiceItem(nil), items...)
+	subtotal := 0.0
+	for i := range items {
+		item := &items[i]
+		if item.Name == "" || item.Quantity <= 0 || item.UnitPrice <= 0 {
+			return nil, fmt.Errorf("商品信息不完整，必须包含名称、数量和单价")
+		}
+		item.Total = float64(item.Quantity) * item.UnitPrice
+		subtotal += item.Total
+	}
+
+	if issueDate.IsZero() {
+		issueDate = time.Now()
+	}
+
+	invoiceID, err := s.invoices.NextID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("生成发票号失败: %w", err)
+	}
+
+	taxAmount := subtotal * invoiceTaxRate
+	now := time.Now()
+	invoice := Invoice{
+		InvoiceID:     invoiceID,
+		CustomerName:  customerName,
+		CustomerTaxID: customerTaxID,
+		Items:         items,
+		IssueDate:     issueDate,
+		DueDate:       issueDate.AddDate(0, 0, 30), // 30天后到期
+		Subtotal:      subtotal,
+		TaxRate:       invoiceTaxRate,
+		TaxAmount:     taxAmount,
+		TotalWithTax:  subtotal + taxAmount,
+		Status:        "已开具",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.invoices.Save(ctx, invoice); err != nil {
+		return nil, fmt.Errorf("保存发票失败: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// CreateOrderInvoice 按订单中的商品开具发票
+func (s *InvoiceService) CreateOrderInvoice(ctx context.Context, orderID, customerName, customerTaxID string) (*Invoice, error) {
+	order, err := s.orders.Query(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]InvoiceItem, 0, len(order.ProductList))
+	for _, product := range order.ProductList {
+		items = append(items, InvoiceItem{
+			Name:      product.Name,
+			Quantity:  product.Quantity,
+			UnitPrice: product.Price,
+		})
+	}
+	return s.CreateInvoice(ctx, customerName, customerTaxID, items, time.Time{})
+}
+
+// QueryInvoice 查询发票
+func (s *InvoiceService) QueryInvoice(ctx context.Context, invoiceID string) (*Invoice, error) {
+	invoice, err := s.invoices.Get(ctx, invoiceID)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("发票不存在: %s", invoiceID)
+		}
+		return nil, fmt.Errorf("查询发票失败: %w", err)
+	}
+	return invoice, nil
+}
+
+// UpdateInvoiceStatus 更新发票状态
+func (s *InvoiceService) UpdateInvoiceStatus(ctx context.Context, invoiceID, status string) (*Invoice, error) {
+	if !invoiceStatuses[status] {
+		return nil, fmt.Errorf("无效的发票状态: %s", status)
+	}
+
+	invoice, err := s.QueryInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Status = status
+	invoice.UpdatedAt = time.Now()
+	if err := s.invoices.Save(ctx, *invoice); err != nil {
+		return nil, fmt.Errorf("保存发票失败: %w", err)
+	}
+
+	return invoice, nil
+}
+
+// FormatInvoiceInfo 格式化发票信息
+func (s *InvoiceService) FormatInvoiceInfo(invoice *Invoice) string {
+	var result string
+
+	result += fmt.Sprintf("发票号: %s\n", invoice.InvoiceID)
+	result += fmt.Sprintf("客户名称: %s\n", invoice.CustomerName)
+	result += fmt.Sprintf("客户税号: %s\n", invoice.CustomerTaxID)
+	result += fmt.Sprintf("开票日期: %s\n", invoice.IssueDate.Format("2006-01-02"))
+	result += fmt.Sprintf("到期日期: %s\n", invoice.DueDate.Format("2006-01-02"))
+	result += fmt.Sprintf("发票状态: %s\n", invoice.Status)
+
+	result += "\n商品明细:\n"
+	for _, item := range invoice.Items {
+		result += fmt.Sprintf("- %s (数量: %d, 单价: %.2f, 小计: %.2f)\n",
+			item.Name, item.Quantity, item.UnitPrice, item.Total)
+	}
+
+	result += fmt.Sprintf("\n不含税金额: %.2f\n", invoice.Subtotal)
+	result += fmt.Sprintf("税率: %.0f%%\n", invoice.TaxRate*100)
+	result += fmt.Sprintf("税额: %.2f\n", invoice.TaxAmount)
+	result += fmt.Sprintf("价税合计: %.2f\n", invoice.TotalWithTax)
+
+	return result
+}
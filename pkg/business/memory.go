@@ -0,0 +1,167 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 存储中没有对应的记录
+var ErrNotFound = errors.New("记录不存在")
+
+// IsNotFound 判断错误是否表示记录不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// MemoryOrderRepository 内存订单存储
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]OrderInfo
+}
+
+// NewMemoryOrderRepository 创建内存订单存储
+func NewMemoryOrderRepository(orders ...OrderInfo) *MemoryOrderRepository {
+	r := &MemoryOrderRepository{orders: make(map[string]OrderInfo, len(orders))}
+	for _, order := range orders {
+		r.orders[order.OrderID] = order
+	}
+	return r
+}
+
+// Get 获取订单
+func (r *MemoryOrderRepository) Get(ctx context.Context, orderID string) (*OrderInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	order, exists := r.orders[orderID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &order, nil
+}
+
+// List 列出满足条件的订单
+func (r *MemoryOrderRepository) List(ctx context.Context, filter OrderFilter) ([]OrderInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var orders []OrderInfo
+	for _, order := range r.orders {
+		if filter.Match(order) {
+			orders = append(orders, order)
+		}
+	}
+	sortOrders(orders)
+	return orders, nil
+}
+
+// Save 保存订单，订单号已存在时覆盖
+func (r *MemoryOrderRepository) Save(ctx context.Context, order OrderInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.OrderID] = order
+	return nil
+}
+
+// Count 订单数量
+func (r *MemoryOrderRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.orders), nil
+}
+
+// MemoryRefundRepository 内存退款申请存储
+type MemoryRefundRepository struct {
+	mu      sync.RWMutex
+	refunds map[string]RefundRequest
+	counter int
+}
+
+// NewMemoryRefundRepository 创建内存退款申请存储，新申请号在已有申请之后依次编号
+func NewMemoryRefundRepository(refunds ...RefundRequest) *MemoryRefundRepository {
+	r := &MemoryRefundRepository{refunds: make(map[string]RefundRequest, len(refunds))}
+	for _, refund := range refunds {
+		r.refunds[refund.RequestID] = refund
+	}
+	r.counter = len(refunds)
+	return r
+}
+
+// NextID 生成新的退款申请号，如 REF003
+func (r *MemoryRefundRepository) NextID(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for {
+		r.counter++
+		id := fmt.Sprintf("REF%03d", r.counter)
+		if _, exists := r.refunds[id]; !exists {
+			return id, nil
+		}
+	}
+}
+
+// Save 保存退款申请
+func (r *MemoryRefundRepository) Save(ctx context.Context, refund RefundRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refunds[refund.RequestID] = refund
+	return nil
+}
+
+// Get 获取退款申请
+func (r *MemoryRefundRepository) Get(ctx context.Context, refundID string) (*RefundRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	refund, exists := r.refunds[refundID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &refund, nil
+}
+
+// MemoryInvoiceRepository 内存发票存储
+type MemoryInvoiceRepository struct {
+	mu       sync.RWMutex
+	invoices map[string]Invoice
+	counter  int
+}
+
+// NewMemoryInvoiceRepository 创建内存发票存储
+func NewMemoryInvoiceRepository(invoices ...Invoice) *MemoryInvoiceRepository {
+	r := &MemoryInvoiceRepository{
+		invoices: make(map[string]Invoice, len(invoices)),
+		counter:  1000,
+	}
+	for _, invoice := range invoices {
+		r.invoices[invoice.InvoiceID] = invoice
+	}
+	return r
+}
+
+// NextID 生成新的发票号，如 INV202501020001
+func (r *MemoryInvoiceRepository) NextID(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counter++
+	return fmt.Sprintf("INV%s%04d", time.Now().Format("20060102"), r.counter), nil
+}
+
+// Save 保存发票
+func (r *MemoryInvoiceRepository) Save(ctx context.Context, invoice Invoice) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invoices[invoice.InvoiceID] = invoice
+	return nil
+}
+
+// Get 获取发票
+func (r *MemoryInvoiceRepository) Get(ctx context.Context, invoiceID string) (*Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	invoice, exists := r.invoices[invoiceID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &invoice, nil
+}
@@ -0,0 +1,182 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OrderStatuses 订单状态
+var OrderStatuses = []string{"待付款", "待发货", "已发货", "已送达", "已取消"}
+
+// OrderInfo 订单信息
+type OrderInfo struct {
+	OrderID      string    `json:"order_id"`
+	UserID       string    `json:"user_id"`
+	Status       string    `json:"status"`
+	CreateTime   time.Time `json:"create_time"`
+	PayTime      time.Time `json:"pay_time"`
+	ShipTime     time.Time `json:"ship_time"`
+	ProductList  []Product `json:"product_list"`
+	TotalAmount  float64   `json:"total_amount"`
+	ShipAddress  string    `json:"ship_address"`
+	TrackingInfo string    `json:"tracking_info"`
+	EstDelivery  time.Time `json:"est_delivery"`
+}
+
+// Product 商品信息
+type Product struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
+
+// OrderFilter 订单列表的筛选条件，为空的条件不限
+type OrderFilter struct {
+	UserID string
+	// From、To 下单时间范围，包含 From，不包含 To
+	From   time.Time
+	To     time.Time
+	Status string
+}
+
+// Match 判断订单是否满足筛选条件
+func (f OrderFilter) Match(order OrderInfo) bool {
+	if f.UserID != "" && order.UserID != f.UserID {
+		return false
+	}
+	if f.Status != "" && order.Status != f.Status {
+		return false
+	}
+	if !f.From.IsZero() && order.CreateTime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !order.CreateTime.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// OrderRepository 订单存储
+type OrderRepository interface {
+	// Get 获取订单，不存在时返回 ErrNotFound
+	Get(ctx context.Context, orderID string) (*OrderInfo, error)
+	// List 列出满足条件的订单，按下单时间从新到旧排列
+	List(ctx context.Context, filter OrderFilter) ([]OrderInfo, error)
+	// Save 保存订单，订单号已存在时覆盖
+	Save(ctx context.Context, order OrderInfo) error
+	// Count 订单数量
+	Count(ctx context.Context) (int, error)
+}
+
+// OrderService 订单服务
+type OrderService struct {
+	orders OrderRepository
+}
+
+// NewOrderService 创建订单服务
+func NewOrderService(orders OrderRepository) *OrderService {
+	return &OrderService{orders: orders}
+}
+
+// Query 查询订单
+func (s *OrderService) Query(ctx context.Context, orderID string) (*OrderInfo, error) {
+	order, err := s.orders.Get(ctx, orderID)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("订单不存在: %s", orderID)
+		}
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	return order, nil
+}
+
+// List 列出用户满足条件的订单，按下单时间从新到旧排列
+// 必须指定用户，避免列出其他用户的订单
+func (s *OrderService) List(ctx context.Context, filter OrderFilter) ([]OrderInfo, error) {
+	if filter.UserID == "" {
+		return nil, fmt.Errorf("缺少用户ID，无法查询订单列表")
+	}
+	orders, err := s.orders.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单列表失败: %w", err)
+	}
+	return orders, nil
+}
+
+// FormatOrderList 格式化订单列表，每个订单一行
+func (s *OrderService) FormatOrderList(orders []OrderInfo) string {
+	if len(orders) == 0 {
+		return "没有找到符合条件的订单\n"
+	}
+
+	result := fmt.Sprintf("共找到 %d 个订单:\n", len(orders))
+	for _, order := range orders {
+		names := make([]string, 0, len(order.ProductList))
+		for _, product := range order.ProductList {
+			names = append(names, product.Name)
+		}
+		result += fmt.Sprintf("- %s | %s | 下单时间: %s | 金额: %.2f | 商品: %s\n",
+			order.OrderID, order.Status, order.CreateTime.Format("2006-01-02 15:04:05"), order.TotalAmount, strings.Join(names, "、"))
+	}
+	return result
+}
+
+// MatchOrderStatus 返回文本中提到的订单状态，没有提到时返回空字符串
+func MatchOrderStatus(text string) string {
+	for _, status := range OrderStatuses {
+		if strings.Contains(text, status) {
+			return status
+		}
+	}
+	return ""
+}
+
+// sortOrders 按下单时间从新到旧排列订单
+func sortOrders(orders []OrderInfo) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreateTime.After(orders[j].CreateTime)
+	})
+}
+
+// FormatOrderInfo 格式化订单信息
+func (s *OrderService) FormatOrderInfo(order *OrderInfo) string {
+	var result string
+
+	result += fmt.Sprintf("订单号: %s\n", order.OrderID)
+	result += fmt.Sprintf("订单状态: %s\n", order.Status)
+	result += fmt.Sprintf("下单时间: %s\n", order.CreateTime.Format("2006-01-02 15:04:05"))
+
+	if !order.PayTime.IsZero() {
+		result += fmt.Sprintf("支付时间: %s\n", order.PayTime.Format("2006-01-02 15:04:05"))
+	}
+
+	if !order.ShipTime.IsZero() {
+		result += fmt.Sprintf("发货时间: %s\n", order.ShipTime.Format("2006-01-02 15:04:05"))
+	}
+
+	result += "\n商品列表:\n"
+	for _, product := range order.ProductList {
+		result += fmt.Sprintf("- %s (数量: %d, 单价: %.2f)\n", product.Name, product.Quantity, product.Price)
+	}
+
+	result += fmt.Sprintf("\n订单总额: %.2f\n", order.TotalAmount)
+	result += fmt.Sprintf("收货地址: %s\n", order.ShipAddress)
+
+	if order.TrackingInfo != "" {
+		result += fmt.Sprintf("物流信息: %s\n", order.TrackingInfo)
+	}
+
+	if !order.EstDelivery.IsZero() {
+		if order.EstDelivery.After(time.Now()) {
+			result += fmt.Sprintf("预计送达: %s\n", order.EstDelivery.Format("2006-01-02"))
+		} else {
+			result += fmt.Sprintf("送达时间: %s\n", order.EstDelivery.Format("2006-01-02"))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,174 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RefundRequest 退款申请
+type RefundRequest struct {
+	OrderID     string    `json:"order_id"`
+	Reason      string    `json:"reason"`
+	Amount      float64   `json:"amount"`
+	RequestTime time.Time `json:"request_time"`
+	Status      string    `json:"status"`
+	RequestID   string    `json:"request_id"`
+	ProcessTime time.Time `json:"process_time"`
+	Response    string    `json:"response"`
+}
+
+// RefundRepository 退款申请存储
+type RefundRepository interface {
+	// NextID 生成新的退款申请号
+	NextID(ctx context.Context) (string, error)
+	// Save 保存退款申请，申请号已存在时覆盖
+	Save(ctx context.Context, refund RefundRequest) error
+	// Get 获取退款申请，不存在时返回 ErrNotFound
+	Get(ctx context.Context, refundID string) (*RefundRequest, error)
+}
+
+// RefundService 退款服务，退款资格和金额以订单服务中的订单为准
+type RefundService struct {
+	orders  *OrderService
+	refunds RefundRepository
+}
+
+// NewRefundService 创建退款服务
+func NewRefundService(orders *OrderService, refunds RefundRepository) *RefundService {
+	return &RefundService{
+		orders:  orders,
+		refunds: refunds,
+	}
+}
+
+// CheckRefundEligibility 检查退款资格
+func (s *RefundService) CheckRefundEligibility(ctx context.Context, orderID string) (bool, string, error) {
+	// 查询订单信息
+	order, err := s.orders.Query(ctx, orderID)
+	if err != nil {
+		return false, "", fmt.Errorf("查询订单失败: %v", err)
+	}
+	return checkEligibility(order)
+}
+
+// checkEligibility 按订单状态判断能否退款
+func checkEligibility(order *OrderInfo) (bool, string, error) {
+	switch order.Status {
+	case "已送达":
+		// 已送达的订单，检查是否在7天内
+		if time.Since(order.EstDelivery) > 7*24*time.Hour {
+			return false, "订单已超过7天退货期", nil
+		}
+		return true, "订单在退货期内，可以申请退款", nil
+	case "已发货":
+		return true, "订单已发货但未送达，可以申请退款", nil
+	case "待发货":
+		return true, "订单未发货，可以直接取消订单退款", nil
+	case "已取消":
+		return false, "订单已取消，无法再次退款", nil
+	default:
+		return false, "当前订单状态不支持退款", nil
+	}
+}
+
+// SubmitRefund 提交退款申请，退款金额为订单总额
+func (s *RefundService) SubmitRefund(ctx context.Context, orderID, reason string) (*RefundRequest, error) {
+	order, err := s.orders.Query(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+
+	// 检查退款资格
+	eligible, message, err := checkEligibility(order)
+	if err != nil {
+		return nil, err
+	}
+	if !eligible {
+		return nil, fmt.Errorf("不符合退款条件: %s", message)
+	}
+
+	refundID, err := s.refunds.NextID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("生成退款申请号失败: %w", err)
+	}
+
+	refund := RefundRequest{
+		OrderID:     orderID,
+		Reason:      reason,
+		Amount:      order.TotalAmount,
+		RequestTime: time.Now(),
+		Status:      "处理中",
+		RequestID:   refundID,
+	}
+	if err := s.refunds.Save(ctx, refund); err != nil {
+		return nil, fmt.Errorf("保存退款申请失败: %w", err)
+	}
+
+	return &refund, nil
+}
+
+// ProcessRefund 处理退款申请
+func (s *RefundService) ProcessRefund(ctx context.Context, refundID string) (*RefundRequest, error) {
+	refund, err := s.QueryRefund(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund.Status != "处理中" {
+		return nil, fmt.Errorf("退款申请已处理，当前状态: %s", refund.Status)
+	}
+
+	// 模拟审核结果
+	approved := rand.Intn(10) > 2 // 80%概率批准
+
+	if approved {
+		refund.Status = "已批准"
+		refund.Response = "退款已批准，将在3-5个工作日内原路退回您的支付账户"
+	} else {
+		refund.Status = "已拒绝"
+		refund.Response = "抱歉，根据退款政策，您的申请不符合退款条件"
+	}
+
+	refund.ProcessTime = time.Now()
+	if err := s.refunds.Save(ctx, *refund); err != nil {
+		return nil, fmt.Errorf("保存退款申请失败: %w", err)
+	}
+
+	return refund, nil
+}
+
+// QueryRefund 查询退款状态
+func (s *RefundService) QueryRefund(ctx context.Context, refundID string) (*RefundRequest, error) {
+	refund, err := s.refunds.Get(ctx, refundID)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, fmt.Errorf("退款申请不存在: %s", refundID)
+		}
+		return nil, fmt.Errorf("查询退款申请失败: %w", err)
+	}
+	return refund, nil
+}
+
+// FormatRefundInfo 格式化退款信息
+func (s *RefundService) FormatRefundInfo(refund *RefundRequest) string {
+	var result string
+
+	result += fmt.Sprintf("退款申请号: %s\n", refund.RequestID)
+	result += fmt.Sprintf("关联订单号: %s\n", refund.OrderID)
+	result += fmt.Sprintf("退款金额: %.2f\n", refund.Amount)
+	result += fmt.Sprintf("申请原因: %s\n", refund.Reason)
+	result += fmt.Sprintf("申请时间: %s\n", refund.RequestTime.Format("2006-01-02 15:04:05"))
+	result += fmt.Sprintf("处理状态: %s\n", refund.Status)
+
+	if !refund.ProcessTime.IsZero() {
+		result += fmt.Sprintf("处理时间: %s\n", refund.ProcessTime.Format("2006-01-02 15:04:05"))
+	}
+
+	if refund.Response != "" {
+		result += fmt.Sprintf("处理结果: %s\n", refund.Response)
+	}
+
+	return result
+}
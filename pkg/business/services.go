@@ -0,0 +1,153 @@
+package business
+
+import "time"
+
+// Services 订单、退款和发票服务
+// 多轮对话和工作流使用同一组服务，在一处提交的退款、开具的发票在另一处可以查到
+type Services struct {
+	Orders   *OrderService
+	Refunds  *RefundService
+	Invoices *InvoiceService
+}
+
+// NewServices 基于给定的存储创建服务
+func NewServices(orders OrderRepository, refunds RefundRepository, invoices InvoiceRepository) *Services {
+	orderService := NewOrderService(orders)
+	return &Services{
+		Orders:   orderService,
+		Refunds:  NewRefundService(orderService, refunds),
+		Invoices: NewInvoiceService(orderService, invoices),
+	}
+}
+
+// NewServicesWithOrders 基于给定的订单存储创建服务，退款和发票使用带示例数据的内存存储
+func NewServicesWithOrders(orders OrderRepository) *Services {
+	now := time.Now()
+	return NewServices(
+		orders,
+		NewMemoryRefundRepository(mockRefunds(now)...),
+		NewMemoryInvoiceRepository(mockInvoices(now)...),
+	)
+}
+
+// NewMockServices 创建使用内存存储和示例数据的服务
+func NewMockServices() *Services {
+	return NewServicesWithOrders(NewMemoryOrderRepository(mockOrders(time.Now())...))
+}
+
+// mockOrders 示例订单，ORD123456 和 ORD345678 属于用户 U1001，ORD789012 属于用户 U1002
+func mockOrders(now time.Time) []OrderInfo {
+	return []OrderInfo{
+		{
+			OrderID:    "ORD123456",
+			UserID:     "U1001",
+			Status:     "已发货",
+			CreateTime: now.Add(-72 * time.Hour),
+			PayTime:    now.Add(-71 * time.Hour),
+			ShipTime:   now.Add(-24 * time.Hour),
+			ProductList: []Product{
+				{ID: "P001", Name: "智能手表", Price: 1299.00, Quantity: 1},
+				{ID: "P002", Name: "手机壳", Price: 49.00, Quantity: 2},
+			},
+			TotalAmount:  1397.00,
+			ShipAddress:  "北京市朝阳区某某街道123号",
+			TrackingInfo: "顺丰快递，单号SF123456789",
+			EstDelivery:  now.Add(24 * time.Hour),
+		},
+		{
+			OrderID:    "ORD789012",
+			UserID:     "U1002",
+			Status:     "已送达",
+			CreateTime: now.Add(-120 * time.Hour),
+			PayTime:    now.Add(-119 * time.Hour),
+			ShipTime:   now.Add(-96 * time.Hour),
+			ProductList: []Product{
+				{ID: "P003", Name: "蓝牙耳机", Price: 399.00, Quantity: 1},
+			},
+			TotalAmount:  399.00,
+			ShipAddress:  "上海市浦东新区某某路456号",
+			TrackingInfo: "顺丰快递，单号SF987654321",
+			EstDelivery:  now.Add(-48 * time.Hour),
+		},
+		{
+			OrderID:    "ORD345678",
+			UserID:     "U1001",
+			Status:     "待发货",
+			CreateTime: now.Add(-12 * time.Hour),
+			PayTime:    now.Add(-11 * time.Hour),
+			ProductList: []Product{
+				{ID: "P004", Name: "平板电脑", Price: 2999.00, Quantity: 1},
+				{ID: "P005", Name: "保护膜", Price: 29.00, Quantity: 3},
+			},
+			TotalAmount:  3086.00,
+			ShipAddress:  "广州市天河区某某大道789号",
+			TrackingInfo: "暂无物流信息",
+			EstDelivery:  now.Add(48 * time.Hour),
+		},
+	}
+}
+
+// mockRefunds 示例退款申请
+func mockRefunds(now time.Time) []RefundRequest {
+	return []RefundRequest{
+		{
+			OrderID:     "ORD123456",
+			Reason:      "商品质量问题",
+			Amount:      1299.00,
+			RequestTime: now.Add(-48 * time.Hour),
+			Status:      "已批准",
+			RequestID:   "REF001",
+			ProcessTime: now.Add(-24 * time.Hour),
+			Response:    "退款已批准，将在3-5个工作日内原路退回您的支付账户",
+		},
+		{
+			OrderID:     "ORD789012",
+			Reason:      "不想要了",
+			Amount:      399.00,
+			RequestTime: now.Add(-12 * time.Hour),
+			Status:      "处理中",
+			RequestID:   "REF002",
+		},
+	}
+}
+
+// mockInvoices 示例发票
+func mockInvoices(now time.Time) []Invoice {
+	return []Invoice{
+		{
+			InvoiceID:     "INV20231101001",
+			CustomerName:  "张三",
+			CustomerTaxID: "110101199001011234",
+			Items: []InvoiceItem{
+				{Name: "智能手表", Quantity: 1, UnitPrice: 1299.00, Total: 1299.00},
+				{Name: "手机壳", Quantity: 2, UnitPrice: 49.00, Total: 98.00},
+			},
+			IssueDate:    now.Add(-72 * time.Hour),
+			DueDate:      now.Add(-42 * time.Hour),
+			Subtotal:     1397.00,
+			TaxRate:      0.13,
+			TaxAmount:    181.61,
+			TotalWithTax: 1578.61,
+			Status:       "已支付",
+			CreatedAt:    now.Add(-72 * time.Hour),
+			UpdatedAt:    now.Add(-42 * time.Hour),
+		},
+		{
+			InvoiceID:     "INV20231102002",
+			CustomerName:  "李四",
+			CustomerTaxID: "110101199002022345",
+			Items: []InvoiceItem{
+				{Name: "蓝牙耳机", Quantity: 1, UnitPrice: 399.00, Total: 399.00},
+			},
+			IssueDate:    now.Add(-48 * time.Hour),
+			DueDate:      now.Add(48 * time.Hour),
+			Subtotal:     399.00,
+			TaxRate:      0.13,
+			TaxAmount:    51.87,
+			TotalWithTax: 450.87,
+			Status:       "已开具",
+			CreatedAt:    now.Add(-48 * time.Hour),
+			UpdatedAt:    now.Add(-48 * time.Hour),
+		},
+	}
+}
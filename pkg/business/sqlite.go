@@ -0,0 +1,221 @@
+package business
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// createOrdersTable 订单表，商品列表以JSON保存，时间以RFC3339格式保存，未发生的时间为空字符串
+const createOrdersTable = `
+CREATE TABLE IF NOT EXISTS orders (
+	order_id      TEXT PRIMARY KEY,
+	user_id       TEXT NOT NULL DEFAULT '',
+	status        TEXT NOT NULL,
+	create_time   TEXT NOT NULL,
+	pay_time      TEXT NOT NULL DEFAULT '',
+	ship_time     TEXT NOT NULL DEFAULT '',
+	products      TEXT NOT NULL DEFAULT '[]',
+	total_amount  REAL NOT NULL DEFAULT 0,
+	ship_address  TEXT NOT NULL DEFAULT '',
+	tracking_info TEXT NOT NULL DEFAULT '',
+	est_delivery  TEXT NOT NULL DEFAULT ''
+)`
+
+// createOrdersUserIndex 按用户查询订单的索引
+const createOrdersUserIndex = `CREATE INDEX IF NOT EXISTS idx_orders_user ON orders (user_id)`
+
+// orderColumns 查询订单的字段
+const orderColumns = `order_id, user_id, status, create_time, pay_time, ship_time, products, total_amount, ship_address, tracking_info, est_delivery`
+
+// SQLiteOrderRepository SQLite订单存储，使用嵌入式数据库，不需要单独部署
+type SQLiteOrderRepository struct {
+	db *sql.DB
+}
+
+// OpenSQLiteOrderRepository 打开数据库文件中的订单存储，文件或订单表不存在时创建
+func OpenSQLiteOrderRepository(path string) (*SQLiteOrderRepository, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建数据库目录失败: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	// SQLite同一时间只允许一个写入，单个连接避免 database is locked
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(createOrdersTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建订单表失败: %w", err)
+	}
+	if err := migrateOrdersTable(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("升级订单表失败: %w", err)
+	}
+	return &SQLiteOrderRepository{db: db}, nil
+}
+
+// migrateOrdersTable 为早期创建的订单表补充 user_id 字段并建立索引
+func migrateOrdersTable(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('orders')`)
+	if err != nil {
+		return err
+	}
+	hasUserID := false
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		hasUserID = hasUserID || name == "user_id"
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !hasUserID {
+		if _, err := db.Exec(`ALTER TABLE orders ADD COLUMN user_id TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(createOrdersUserIndex)
+	return err
+}
+
+// Get 获取订单
+func (r *SQLiteOrderRepository) Get(ctx context.Context, orderID string) (*OrderInfo, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE order_id = ?`, orderID)
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return order, err
+}
+
+// List 列出满足条件的订单
+// 时间以文本保存，带不同时区时无法按文本比较，用户和状态在查询中筛选，下单时间范围读取后筛选
+func (r *SQLiteOrderRepository) List(ctx context.Context, filter OrderFilter) ([]OrderInfo, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE 1 = 1`
+	var args []any
+	if filter.UserID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []OrderInfo
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Match(*order) {
+			orders = append(orders, *order)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	sortOrders(orders)
+	return orders, nil
+}
+
+// Save 保存订单，订单号已存在时覆盖
+func (r *SQLiteOrderRepository) Save(ctx context.Context, order OrderInfo) error {
+	products, err := json.Marshal(order.ProductList)
+	if err != nil {
+		return fmt.Errorf("序列化订单 %s 的商品列表失败: %w", order.OrderID, err)
+	}
+	if order.ProductList == nil {
+		products = []byte("[]")
+	}
+	_, err = r.db.ExecContext(ctx, `INSERT INTO orders (`+orderColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(order_id) DO UPDATE SET
+			user_id = excluded.user_id,
+			status = excluded.status,
+			create_time = excluded.create_time,
+			pay_time = excluded.pay_time,
+			ship_time = excluded.ship_time,
+			products = excluded.products,
+			total_amount = excluded.total_amount,
+			ship_address = excluded.ship_address,
+			tracking_info = excluded.tracking_info,
+			est_delivery = excluded.est_delivery`,
+		order.OrderID, order.UserID, order.Status, formatTime(order.CreateTime), formatTime(order.PayTime), formatTime(order.ShipTime),
+		string(products), order.TotalAmount, order.ShipAddress, order.TrackingInfo, formatTime(order.EstDelivery),
+	)
+	if err != nil {
+		return fmt.Errorf("保存订单 %s 失败: %w", order.OrderID, err)
+	}
+	return nil
+}
+
+// Count 订单数量
+func (r *SQLiteOrderRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计订单失败: %w", err)
+	}
+	return count, nil
+}
+
+// Close 关闭数据库
+func (r *SQLiteOrderRepository) Close() error {
+	return r.db.Close()
+}
+
+// scanOrder 读取一行订单
+func scanOrder(row interface{ Scan(dest ...any) error }) (*OrderInfo, error) {
+	var order OrderInfo
+	var createTime, payTime, shipTime, products, estDelivery string
+	err := row.Scan(&order.OrderID, &order.UserID, &order.Status, &createTime, &payTime, &shipTime, &products,
+		&order.TotalAmount, &order.ShipAddress, &order.TrackingInfo, &estDelivery)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(products), &order.ProductList); err != nil {
+		return nil, fmt.Errorf("解析订单 %s 的商品列表失败: %w", order.OrderID, err)
+	}
+	for _, field := range []struct {
+		value  string
+		target *time.Time
+	}{
+		{createTime, &order.CreateTime},
+		{payTime, &order.PayTime},
+		{shipTime, &order.ShipTime},
+		{estDelivery, &order.EstDelivery},
+	} {
+		if *field.target, err = parseTime(field.value); err != nil {
+			return nil, fmt.Errorf("解析订单 %s 的时间失败: %w", order.OrderID, err)
+		}
+	}
+	return &order, nil
+}
+
+// formatTime 以RFC3339格式保存时间，零值保存为空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
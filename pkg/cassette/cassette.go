@@ -0,0 +1,80 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cassetteVersion 磁带文件格式版本
+const cassetteVersion = 1
+
+// Cassette 磁带文件，保存一组按顺序录制的HTTP交互
+type Cassette struct {
+	Version      int            `json:"version"`
+	Note         string         `json:"note,omitempty"` // 说明，如标注为手写的固定数据，录制时不写入
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction 一次HTTP请求及其响应
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request 录制的请求，敏感信息已脱敏
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	Key     string      `json:"key"` // 回放时用于匹配请求的键，由方法、路径以及请求体中的 model、messages、tools 生成
+}
+
+// Response 录制的响应
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Load 从文件加载磁带
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取磁带文件失败: %w", err)
+	}
+
+	cassette := &Cassette{}
+	if err := json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("解析磁带文件失败: %w", err)
+	}
+	if cassette.Version != cassetteVersion {
+		return nil, fmt.Errorf("不支持的磁带版本: %d", cassette.Version)
+	}
+	return cassette, nil
+}
+
+// Save 将磁带写入文件
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化磁带失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建磁带目录失败: %w", err)
+	}
+
+	// 先写临时文件再替换，避免录制中断时留下残缺的磁带
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入磁带文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("写入磁带文件失败: %w", err)
+	}
+	return nil
+}
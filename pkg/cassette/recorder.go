@@ -0,0 +1,230 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Mode 录制器工作模式
+type Mode string
+
+const (
+	ModeRecord Mode = "record" // 请求真实服务并录制到磁带，已有磁带会被覆盖
+	ModeReplay Mode = "replay" // 只从磁带回放，不访问网络
+)
+
+// ErrInteractionNotFound 回放时磁带中没有匹配的请求
+var ErrInteractionNotFound = errors.New("cassette interaction not found")
+
+// matchFields 请求体中参与匹配的字段
+var matchFields = []string{"model", "messages", "tools"}
+
+// Config 录制器配置
+type Config struct {
+	Path      string            // 磁带文件路径
+	Mode      Mode              // 工作模式
+	Secrets   []string          // 需要额外脱敏的明文密钥，如配置中的API Key
+	Transport http.RoundTripper // 录制模式下实际发送请求的Transport，为空时使用http.DefaultTransport
+}
+
+// Recorder 录制/回放HTTP交互的RoundTripper
+// 适用于基于HTTP的模型提供商，WebSocket连接（如讯飞星火）不经过该Transport
+type Recorder struct {
+	mu        sync.Mutex
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	redactor  *redactor
+	cassette  *Cassette
+	cursors   map[string]int // 回放时每个匹配键已使用的交互数
+}
+
+// NewRecorder 创建录制器
+func NewRecorder(config Config) (*Recorder, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("未配置磁带文件路径")
+	}
+
+	r := &Recorder{
+		path:      config.Path,
+		mode:      config.Mode,
+		transport: config.Transport,
+		redactor:  newRedactor(config.Secrets),
+		cursors:   make(map[string]int),
+	}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+
+	switch config.Mode {
+	case ModeRecord:
+		r.cassette = &Cassette{Version: cassetteVersion}
+	case ModeReplay:
+		cassette, err := Load(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = cassette
+	default:
+		return nil, fmt.Errorf("无效的磁带模式: %s", config.Mode)
+	}
+
+	return r, nil
+}
+
+// Client 获取使用该录制器的HTTP客户端
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Mode 获取工作模式
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// RoundTrip 实现http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	redactedBody := r.redactor.text(string(body))
+	key := matchKey(req.Method, req.URL.Path, redactedBody)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, key, redactedBody)
+	}
+	return r.record(req, key, redactedBody)
+}
+
+// record 发送真实请求并录制交互
+func (r *Recorder) record(req *http.Request, key, redactedBody string) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     r.redactor.url(req.URL),
+			Headers: r.redactor.headers(req.Header),
+			Body:    redactedBody,
+			Key:     key,
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    r.redactor.headers(resp.Header),
+			Body:       r.redactor.text(string(respBody)),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.cassette.Save(r.path); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay 从磁带中查找匹配的交互并构造响应
+// 相同匹配键的交互按录制顺序依次回放，用完后重复最后一条
+func (r *Recorder) replay(req *http.Request, key, redactedBody string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := make([]*Interaction, 0)
+	for _, interaction := range r.cassette.Interactions {
+		if interaction.Request.Key == key {
+			matched = append(matched, interaction)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("%w: %s %s %s", ErrInteractionNotFound, req.Method, req.URL.Path, describeBody(redactedBody))
+	}
+
+	index := r.cursors[key]
+	if index >= len(matched) {
+		index = len(matched) - 1
+	}
+	r.cursors[key]++
+
+	recorded := matched[index].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// readBody 读取请求体并还原，以便后续继续发送
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// matchKey 生成请求的匹配键
+// JSON请求体只取 model、messages、tools 字段，忽略温度、请求ID等不影响语义的字段
+func matchKey(method, path, body string) string {
+	canonical := body
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &payload); err == nil {
+		fields := make(map[string]interface{}, len(matchFields))
+		for _, field := range matchFields {
+			if value, exists := payload[field]; exists {
+				fields[field] = value
+			}
+		}
+		// map按键排序序列化，保证相同内容得到相同的键
+		data, _ := json.Marshal(fields)
+		canonical = string(data)
+	}
+
+	sum := sha256.Sum256([]byte(method + " " + path + "\n" + canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeBody 生成请求体摘要，用于回放未命中时的错误信息
+func describeBody(body string) string {
+	var payload struct {
+		Model    string            `json:"model"`
+		Messages []json.RawMessage `json:"messages"`
+		Tools    []json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return fmt.Sprintf("(%d bytes)", len(body))
+	}
+	return fmt.Sprintf("(model=%s, messages=%d, tools=%d)", payload.Model, len(payload.Messages), len(payload.Tools))
+}
@@ -0,0 +1,91 @@
+package cassette
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redacted 脱敏后的占位值
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders 需要脱敏的请求头和响应头
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"Cookie",
+	"Set-Cookie",
+	"Openai-Organization",
+	"Openai-Project",
+}
+
+// sensitiveQueryParams 需要脱敏的URL查询参数（如讯飞星火的签名鉴权参数）
+var sensitiveQueryParams = []string{
+	"authorization",
+	"signature",
+	"key",
+	"api_key",
+	"apikey",
+	"token",
+	"access_token",
+}
+
+// secretPattern 请求体中常见的密钥格式
+var secretPattern = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`)
+
+// redactor 脱敏器
+type redactor struct {
+	secrets []string
+}
+
+// newRedactor 创建脱敏器，secrets 为需要额外替换的明文密钥
+func newRedactor(secrets []string) *redactor {
+	r := &redactor{}
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// headers 复制并脱敏请求头或响应头
+func (r *redactor) headers(header http.Header) http.Header {
+	cloned := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if cloned.Get(name) != "" {
+			cloned.Set(name, redacted)
+		}
+	}
+	for name, values := range cloned {
+		for i, value := range values {
+			cloned[name][i] = r.text(value)
+		}
+	}
+	return cloned
+}
+
+// url 脱敏URL中的查询参数
+func (r *redactor) url(u *url.URL) string {
+	cloned := *u
+	cloned.User = nil
+	query := cloned.Query()
+	for _, name := range sensitiveQueryParams {
+		if query.Has(name) {
+			query.Set(name, redacted)
+		}
+	}
+	cloned.RawQuery = query.Encode()
+	return r.text(cloned.String())
+}
+
+// text 脱敏文本中的密钥
+func (r *redactor) text(value string) string {
+	for _, secret := range r.secrets {
+		value = strings.ReplaceAll(value, secret, redacted)
+	}
+	return secretPattern.ReplaceAllString(value, redacted)
+}
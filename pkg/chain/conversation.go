@@ -8,10 +8,10 @@ import (
 	"time"
 
 	"github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"go-smart/pkg/date"
+	"go-smart/pkg/prompt"
 )
 
 // ConversationChain 对话链结构
@@ -20,17 +20,13 @@ type ConversationChain struct {
 	dateParser *date.DateProcessor
 }
 
-// NewConversationChain 创建新的对话链
-func NewConversationChain(ctx context.Context, chatModel model.BaseChatModel) (*ConversationChain, error) {
+// NewConversationChain 创建新的对话链，系统提示使用注册表中的 order_assistant
+func NewConversationChain(ctx context.Context, chatModel model.BaseChatModel, prompts *prompt.Registry) (*ConversationChain, error) {
 	// 创建日期处理器
 	dateParser := date.NewDateProcessor()
 	
 	// 创建对话模板
-	chatTemplate := prompt.FromMessages(
-		schema.FString,
-		schema.SystemMessage("你是一个智能客服助手，专门帮助用户处理订单相关的问题。当前时间是 {current_date}。"),
-		schema.UserMessage("{query}"),
-	)
+	chatTemplate := prompts.ChatTemplate(prompt.OrderAssistant)
 	
 	// 创建输出解析器
 	outputParser := compose.InvokableLambda(func(ctx context.Context, msg *schema.Message) (map[string]any, error) {
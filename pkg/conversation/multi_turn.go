@@ -7,35 +7,58 @@ import (
 	"strings"
 
 	"github.com/cloudwego/eino/components/model"
-	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
+	"go-smart/pkg/business"
+	"go-smart/pkg/date"
+	"go-smart/pkg/experiment"
+	"go-smart/pkg/prompt"
+	"go-smart/pkg/trace"
+	"go-smart/pkg/usage"
 )
 
 // MultiTurnConversation 多轮对话处理器
 type MultiTurnConversation struct {
 	manager       *Manager
 	chatModel     model.BaseChatModel
+	prompts       *prompt.Registry
+	orders        *business.OrderService
+	refunds       *business.RefundService
+	dates         *date.DateProcessor
 }
 
-// NewMultiTurnConversation 创建多轮对话处理器
+// NewMultiTurnConversation 创建多轮对话处理器，订单查询使用 orders 中的订单，退款申请提交到 refunds
 func NewMultiTurnConversation(
 	manager *Manager,
 	chatModel model.BaseChatModel,
+	prompts *prompt.Registry,
+	orders *business.OrderService,
+	refunds *business.RefundService,
 ) *MultiTurnConversation {
 	return &MultiTurnConversation{
 		manager:    manager,
 		chatModel:  chatModel,
+		prompts:    prompts,
+		orders:     orders,
+		refunds:    refunds,
+		dates:      date.NewDateProcessor(),
 	}
 }
 
 // ProcessMessage 处理用户消息
 func (m *MultiTurnConversation) ProcessMessage(ctx context.Context, sessionID, userMessage string) (string, error) {
-	// 获取或创建会话状态
-	_ = m.manager.GetOrCreateState(sessionID, "default_user")
+	// 获取或创建会话状态，请求中标识了用户时记录在状态中
+	userID := usage.ScopeFromContext(ctx).UserID
+	if userID == "" {
+		userID = "default_user"
+	}
+	_ = m.manager.GetOrCreateState(sessionID, userID)
+	
+	// 每轮对话都记录所在的实验分组
+	metadata := experiment.Metadata(ctx)
 	
 	// 添加用户消息到历史
-	err := m.manager.stateManager.AddMessage(sessionID, "user", userMessage, nil)
+	err := m.manager.stateManager.AddMessage(sessionID, "user", userMessage, metadata)
 	if err != nil {
 		return "", fmt.Errorf("添加用户消息失败: %w", err)
 	}
@@ -48,8 +71,17 @@ func (m *MultiTurnConversation) ProcessMessage(ctx context.Context, sessionID, u
 	
 	// 根据当前步骤处理消息
 	var response string
+	previousStep := currentStep
+	intent := m.detectIntent(userMessage)
+	
+	// 用户要求转人工时优先处理，不论当前处于哪个步骤
+	if intent == "escalate" {
+		currentStep = "escalate"
+	}
 	
 	switch currentStep {
+	case "escalate":
+		response, err = m.escalate(ctx, sessionID)
 	case "greeting":
 		response, err = m.handleGreetingStep(ctx, sessionID, userMessage)
 	case "order_query":
@@ -57,12 +89,9 @@ func (m *MultiTurnConversation) ProcessMessage(ctx context.Context, sessionID, u
 	case "refund_request":
 		response, err = m.handleRefundRequestStep(ctx, sessionID, userMessage)
 	default:
-		// 检测用户意图
-		intent := m.detectIntent(userMessage)
-		
 		switch intent {
 		case "order_query":
-			response, err = m.startOrderQuery(ctx, sessionID)
+			response, err = m.startOrderQuery(ctx, sessionID, userMessage)
 		case "refund_request":
 			response, err = m.startRefundRequest(ctx, sessionID)
 		default:
@@ -71,12 +100,16 @@ func (m *MultiTurnConversation) ProcessMessage(ctx context.Context, sessionID, u
 		}
 	}
 	
+	// 记录本轮识别的意图和步骤变化
+	nextStep, _ := m.manager.GetCurrentStep(sessionID)
+	trace.FromContext(ctx).Transition(intent, previousStep, nextStep)
+	
 	if err != nil {
 		return "", err
 	}
 	
 	// 添加助手响应到历史
-	err = m.manager.stateManager.AddMessage(sessionID, "assistant", response, nil)
+	err = m.manager.stateManager.AddMessage(sessionID, "assistant", response, metadata)
 	if err != nil {
 		return "", fmt.Errorf("添加助手消息失败: %w", err)
 	}
@@ -89,6 +122,14 @@ func (m *MultiTurnConversation) detectIntent(message string) string {
 	// 转换为小写以便匹配
 	lowerMessage := strings.ToLower(message)
 	
+	// 转人工关键词
+	escalateKeywords := []string{"转人工", "人工客服", "找人工", "投诉"}
+	for _, keyword := range escalateKeywords {
+		if strings.Contains(lowerMessage, keyword) {
+			return "escalate"
+		}
+	}
+	
 	// 订单查询关键词
 	orderKeywords := []string{"查订单", "查询订单", "订单状态", "我的订单", "查一下订单", "订单信息"}
 	for _, keyword := range orderKeywords {
@@ -105,6 +146,13 @@ func (m *MultiTurnConversation) detectIntent(message string) string {
 		}
 	}
 	
+	// 说了下单时间的订单描述，如"我昨天下的单"、"上周的订单"
+	if strings.Contains(lowerMessage, "订单") || strings.Contains(lowerMessage, "下的单") {
+		if _, err := m.dates.ExtractRangeFromText(message); err == nil {
+			return "order_query"
+		}
+	}
+	
 	return "general"
 }
 
@@ -115,7 +163,7 @@ func (m *MultiTurnConversation) handleGreetingStep(ctx context.Context, sessionI
 	
 	switch intent {
 	case "order_query":
-		return m.startOrderQuery(ctx, sessionID)
+		return m.startOrderQuery(ctx, sessionID, message)
 	case "refund_request":
 		return m.startRefundRequest(ctx, sessionID)
 	default:
@@ -125,8 +173,21 @@ func (m *MultiTurnConversation) handleGreetingStep(ctx context.Context, sessionI
 	}
 }
 
+// escalate 转人工处理，结束当前流程
+func (m *MultiTurnConversation) escalate(ctx context.Context, sessionID string) (string, error) {
+	err := m.manager.stateManager.SetCurrentStep(sessionID, "greeting")
+	if err != nil {
+		return "", fmt.Errorf("重置对话步骤失败: %w", err)
+	}
+	
+	experiment.RecordOutcome(ctx, experiment.OutcomeEscalated)
+	
+	response := "好的，正在为您转接人工客服，请稍候。"
+	return response, nil
+}
+
 // startOrderQuery 开始订单查询流程
-func (m *MultiTurnConversation) startOrderQuery(ctx context.Context, sessionID string) (string, error) {
+func (m *MultiTurnConversation) startOrderQuery(ctx context.Context, sessionID, message string) (string, error) {
 	// 设置当前步骤为订单查询
 	err := m.manager.stateManager.SetCurrentStep(sessionID, "order_query")
 	if err != nil {
@@ -139,13 +200,18 @@ func (m *MultiTurnConversation) startOrderQuery(ctx context.Context, sessionID s
 		return "", fmt.Errorf("获取状态失败: %w", ErrStateNotFound)
 	}
 	
+	// 说了下单时间时按日期列出订单，不使用之前记住的订单号
+	if dateRange, err := m.dates.ExtractRangeFromText(message); err == nil {
+		return m.listOrders(ctx, sessionID, dateRange, business.MatchOrderStatus(message))
+	}
+	
 	if orderID, exists := state.Context["order_id"]; exists {
 		// 如果已有订单号，直接查询
 		return m.processOrderQuery(ctx, sessionID, orderID.(string))
 	}
 	
 	// 否则询问订单号
-	response := "好的，我可以帮您查询订单信息。请提供您的订单号，通常以'ORD'开头。"
+	response := "好的，我可以帮您查询订单信息。请提供您的订单号，通常以'ORD'开头，也可以告诉我大概的下单时间，如昨天、上周。"
 	return response, nil
 }
 
@@ -155,8 +221,13 @@ func (m *MultiTurnConversation) handleOrderQueryStep(ctx context.Context, sessio
 	orderID := m.extractOrderID(message)
 	
 	if orderID == "" {
+		// 没有订单号但说了下单时间，按日期列出订单
+		if dateRange, err := m.dates.ExtractRangeFromText(message); err == nil {
+			return m.listOrders(ctx, sessionID, dateRange, business.MatchOrderStatus(message))
+		}
+		
 		// 没有找到订单号，继续询问
-		response := "抱歉，我没有找到有效的订单号。请提供您的订单号，通常以'ORD'开头。"
+		response := "抱歉，我没有找到有效的订单号。请提供您的订单号，通常以'ORD'开头，也可以告诉我大概的下单时间，如昨天、上周。"
 		return response, nil
 	}
 	
@@ -172,24 +243,71 @@ func (m *MultiTurnConversation) handleOrderQueryStep(ctx context.Context, sessio
 
 // processOrderQuery 处理订单查询
 func (m *MultiTurnConversation) processOrderQuery(ctx context.Context, sessionID, orderID string) (string, error) {
-	// 模拟订单查询结果
-	orderInfo := map[string]interface{}{
-		"order_id":      orderID,
-		"status":        "已发货",
-		"create_time":   "2023-11-01 10:30:00",
-		"total_amount":  "299.00",
-		"items":         []string{"智能手表", "手机壳"},
+	order, err := m.orders.Query(ctx, orderID)
+	if err != nil {
+		// 查询失败时停留在订单查询步骤，用户可以重新提供订单号
+		response := fmt.Sprintf("抱歉，%s。请核对订单号后重新提供。", err.Error())
+		return response, nil
 	}
 	
 	// 重置对话步骤
-	err := m.manager.stateManager.SetCurrentStep(sessionID, "greeting")
+	err = m.manager.stateManager.SetCurrentStep(sessionID, "greeting")
 	if err != nil {
 		return "", fmt.Errorf("重置对话步骤失败: %w", err)
 	}
 	
 	// 返回订单信息
-	response := fmt.Sprintf("查询成功！以下是您的订单信息：\n\n订单号：%s\n状态：%s\n创建时间：%s\n总金额：%s\n商品：%v\n\n还有其他可以帮助您的吗？", 
-		orderInfo["order_id"], orderInfo["status"], orderInfo["create_time"], orderInfo["total_amount"], orderInfo["items"])
+	response := fmt.Sprintf("查询成功！以下是您的订单信息：\n\n%s\n还有其他可以帮助您的吗？", m.orders.FormatOrderInfo(order))
+	return response, nil
+}
+
+// listOrders 列出当前用户在日期范围内的订单
+// 只有一个订单时记住订单号，之后可以直接为该订单申请退款；有多个订单时停留在订单查询步骤，等待用户选择订单号
+func (m *MultiTurnConversation) listOrders(ctx context.Context, sessionID string, dateRange date.DateRange, status string) (string, error) {
+	userID := usage.ScopeFromContext(ctx).UserID
+	if userID == "" {
+		// 无法确认用户时只能按订单号查询
+		response := "抱歉，暂时无法确认您的账户，不能按下单时间查找订单。请提供您的订单号，通常以'ORD'开头。"
+		return response, nil
+	}
+	
+	// 之前记住的订单号不再是用户当前所指的订单
+	err := m.manager.stateManager.UpdateState(sessionID, func(state *ConversationState) {
+		delete(state.Context, "order_id")
+	})
+	if err != nil {
+		return "", fmt.Errorf("清除订单号失败: %w", err)
+	}
+	
+	orders, err := m.orders.List(ctx, business.OrderFilter{
+		UserID: userID,
+		From:   dateRange.Start,
+		To:     dateRange.End,
+		Status: status,
+	})
+	if err != nil {
+		return "", err
+	}
+	
+	if len(orders) == 0 {
+		response := fmt.Sprintf("没有找到您在 %s 下的%s订单。请确认下单时间，或直接提供订单号。", dateRange, status)
+		return response, nil
+	}
+	
+	if len(orders) == 1 {
+		err = m.manager.stateManager.SetContext(sessionID, "order_id", orders[0].OrderID)
+		if err != nil {
+			return "", fmt.Errorf("保存订单号失败: %w", err)
+		}
+		err = m.manager.stateManager.SetCurrentStep(sessionID, "greeting")
+		if err != nil {
+			return "", fmt.Errorf("重置对话步骤失败: %w", err)
+		}
+		response := fmt.Sprintf("查询成功！您在 %s 下了 1 个订单：\n\n%s\n还有其他可以帮助您的吗？", dateRange, m.orders.FormatOrderInfo(&orders[0]))
+		return response, nil
+	}
+	
+	response := fmt.Sprintf("查询成功！以下是您在 %s 下的订单：\n\n%s\n如需查看某个订单的详情，请告诉我订单号。", dateRange, m.orders.FormatOrderList(orders))
 	return response, nil
 }
 
@@ -291,38 +409,41 @@ func (m *MultiTurnConversation) handleRefundRequestStep(ctx context.Context, ses
 	return response, nil
 }
 
-// processRefundRequest 处理退款申请
+// processRefundRequest 向退款服务提交退款申请
+// 无论是否提交成功都清除退款原因，避免再次进入退款流程时重复提交；提交失败时同时清除订单号，用户可以换一个订单
 func (m *MultiTurnConversation) processRefundRequest(ctx context.Context, sessionID, orderID, reason string) (string, error) {
-	// 模拟退款申请结果
-	refundInfo := map[string]interface{}{
-		"refund_id":   "REF" + orderID[3:], // 使用订单号生成退款ID
-		"order_id":    orderID,
-		"status":      "已受理",
-		"reason":      reason,
-		"refund_amount": "299.00",
-		"process_time": "3-5个工作日",
-	}
+	refund, submitErr := m.refunds.SubmitRefund(ctx, orderID, reason)
 	
 	// 重置对话步骤
-	err := m.manager.stateManager.SetCurrentStep(sessionID, "greeting")
+	err := m.manager.stateManager.UpdateState(sessionID, func(state *ConversationState) {
+		state.CurrentStep = "greeting"
+		delete(state.Context, "refund_reason")
+		if submitErr != nil {
+			delete(state.Context, "order_id")
+		}
+	})
 	if err != nil {
 		return "", fmt.Errorf("重置对话步骤失败: %w", err)
 	}
 	
+	if submitErr != nil {
+		response := fmt.Sprintf("抱歉，订单 %s 的退款申请未能提交：%s。如需为其他订单申请退款，请提供订单号。", orderID, submitErr.Error())
+		return response, nil
+	}
+	
+	// 退款流程完成
+	experiment.RecordOutcome(ctx, experiment.OutcomeCompleted)
+	
 	// 返回退款申请信息
-	response := fmt.Sprintf("退款申请已提交！以下是您的申请信息：\n\n退款单号：%s\n订单号：%s\n状态：%s\n退款原因：%s\n退款金额：%s\n预计处理时间：%s\n\n还有其他可以帮助您的吗？", 
-		refundInfo["refund_id"], refundInfo["order_id"], refundInfo["status"], refundInfo["reason"], refundInfo["refund_amount"], refundInfo["process_time"])
+	response := fmt.Sprintf("退款申请已提交！以下是您的申请信息：\n\n退款单号：%s\n订单号：%s\n状态：%s\n退款原因：%s\n退款金额：%.2f\n预计处理时间：3-5个工作日\n\n还有其他可以帮助您的吗？",
+		refund.RequestID, refund.OrderID, refund.Status, refund.Reason, refund.Amount)
 	return response, nil
 }
 
 // handleGeneralChat 处理通用聊天
 func (m *MultiTurnConversation) handleGeneralChat(ctx context.Context, sessionID, message string) (string, error) {
 	// 创建对话模板
-	chatTemplate := prompt.FromMessages(
-		schema.FString,
-		schema.SystemMessage("你是一个智能客服助手，可以帮助用户查询订单信息、处理退款申请等。请友好、专业地回答用户的问题。"),
-		schema.UserMessage("{query}"),
-	)
+	chatTemplate := m.prompts.ChatTemplate(prompt.GeneralChat)
 	
 	// 构建对话链
 	chain := compose.NewChain[map[string]any, map[string]any]()
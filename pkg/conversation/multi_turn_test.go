@@ -0,0 +1,47 @@
+package conversation
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/prompt"
+)
+
+func TestMultiTurnRefundSubmitsToRefundService(t *testing.T) {
+	services := business.NewMockServices()
+	conv := NewMultiTurnConversation(NewManager(), nil, prompt.Builtin(), services.Orders, services.Refunds)
+	ctx := context.Background()
+
+	var response string
+	for _, message := range []string{"我要退款", "订单号是ORD123456", "商品质量问题"} {
+		var err error
+		if response, err = conv.ProcessMessage(ctx, "session_refund", message); err != nil {
+			t.Fatalf("ProcessMessage(%q) error: %v", message, err)
+		}
+	}
+
+	match := regexp.MustCompile(`退款单号：(\S+)`).FindStringSubmatch(response)
+	if match == nil {
+		t.Fatalf("response = %q, want refund ID", response)
+	}
+	refund, err := services.Refunds.QueryRefund(ctx, match[1])
+	if err != nil {
+		t.Fatalf("QueryRefund(%s) error: %v", match[1], err)
+	}
+	order, _ := services.Orders.Query(ctx, "ORD123456")
+	if refund.OrderID != "ORD123456" || refund.Reason != "商品质量问题" || refund.Amount != order.TotalAmount {
+		t.Errorf("stored refund = %+v", refund)
+	}
+
+	// 再次申请退款时重新询问原因，不重复提交上一次的申请
+	response, err = conv.ProcessMessage(ctx, "session_refund", "我要退款")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if !strings.Contains(response, "请告诉我退款原因") {
+		t.Errorf("response = %q, want question about the reason", response)
+	}
+}
@@ -12,11 +12,9 @@ type DateProcessor struct {
 	currentTime time.Time
 }
 
-// NewDateProcessor 创建新的日期处理器
+// NewDateProcessor 创建新的日期处理器，相对日期按解析时的系统时间计算
 func NewDateProcessor() *DateProcessor {
-	return &DateProcessor{
-		currentTime: time.Now(),
-	}
+	return &DateProcessor{}
 }
 
 // SetCurrentTime 设置当前时间（主要用于测试）
@@ -24,6 +22,14 @@ func (dp *DateProcessor) SetCurrentTime(t time.Time) {
 	dp.currentTime = t
 }
 
+// Now 当前时间，未设置时使用系统时间，长期运行的服务中“昨天”不会停留在启动那一天
+func (dp *DateProcessor) Now() time.Time {
+	if dp.currentTime.IsZero() {
+		return time.Now()
+	}
+	return dp.currentTime
+}
+
 // ParseRelativeDate 解析相对日期表达式，返回具体日期
 // 支持的表达式：
 // - "昨天" -> 昨天的日期
@@ -33,24 +39,26 @@ func (dp *DateProcessor) SetCurrentTime(t time.Time) {
 // - "N天前" -> N天前的日期
 // - "N天后" -> N天后的日期
 func (dp *DateProcessor) ParseRelativeDate(expr string) (time.Time, error) {
+	now := dp.Now()
+	
 	// 处理"昨天"
 	if expr == "昨天" {
-		return dp.currentTime.AddDate(0, 0, -1), nil
+		return now.AddDate(0, 0, -1), nil
 	}
 	
 	// 处理"前天"
 	if expr == "前天" {
-		return dp.currentTime.AddDate(0, 0, -2), nil
+		return now.AddDate(0, 0, -2), nil
 	}
 	
 	// 处理"今天"
 	if expr == "今天" {
-		return dp.currentTime, nil
+		return now, nil
 	}
 	
 	// 处理"明天"
 	if expr == "明天" {
-		return dp.currentTime.AddDate(0, 0, 1), nil
+		return now.AddDate(0, 0, 1), nil
 	}
 	
 	// 处理"N天前"模式
@@ -61,7 +69,7 @@ func (dp *DateProcessor) ParseRelativeDate(expr string) (time.Time, error) {
 		if err != nil {
 			return time.Time{}, errors.New("无效的天数")
 		}
-		return dp.currentTime.AddDate(0, 0, -days), nil
+		return now.AddDate(0, 0, -days), nil
 	}
 	
 	// 处理"N天后"模式
@@ -72,7 +80,7 @@ func (dp *DateProcessor) ParseRelativeDate(expr string) (time.Time, error) {
 		if err != nil {
 			return time.Time{}, errors.New("无效的天数")
 		}
-		return dp.currentTime.AddDate(0, 0, days), nil
+		return now.AddDate(0, 0, days), nil
 	}
 	
 	return time.Time{}, errors.New("不支持的日期表达式")
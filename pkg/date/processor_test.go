@@ -91,4 +91,48 @@ func TestExtractDateFromText(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestExtractRangeFromText(t *testing.T) {
+	// 2024-01-17 是周三，时间部分不影响日期范围
+	dp := NewDateProcessor()
+	dp.SetCurrentTime(time.Date(2024, 1, 17, 10, 30, 0, 0, time.UTC))
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		text     string
+		expected DateRange
+		str      string
+	}{
+		{"我昨天下的单", DateRange{day(1, 16), day(1, 17)}, "2024-01-16"},
+		{"3天前的订单", DateRange{day(1, 14), day(1, 15)}, "2024-01-14"},
+		{"最近7天的订单", DateRange{day(1, 11), day(1, 18)}, "2024-01-11 至 2024-01-17"},
+		{"上周买的东西", DateRange{day(1, 8), day(1, 15)}, "2024-01-08 至 2024-01-14"},
+		{"这周的订单", DateRange{day(1, 15), day(1, 22)}, "2024-01-15 至 2024-01-21"},
+		{"上个月的订单", DateRange{day(12, 1).AddDate(-1, 0, 0), day(1, 1)}, "2023-12-01 至 2023-12-31"},
+		{"2024-1-5 的订单", DateRange{day(1, 5), day(1, 6)}, "2024-01-05"},
+	}
+	for _, tt := range tests {
+		r, err := dp.ExtractRangeFromText(tt.text)
+		if err != nil {
+			t.Errorf("ExtractRangeFromText(%s) unexpected error: %v", tt.text, err)
+			continue
+		}
+		if !r.Start.Equal(tt.expected.Start) || !r.End.Equal(tt.expected.End) || r.String() != tt.str {
+			t.Errorf("ExtractRangeFromText(%s) = %v (%s), expected %v (%s)", tt.text, r, r, tt.expected, tt.str)
+		}
+	}
+	if _, err := dp.ExtractRangeFromText("我的订单"); err == nil {
+		t.Error("ExtractRangeFromText(我的订单) expected error but got none")
+	}
+
+	r, err := dp.ParseRange("2024-01-05", "2024-01-07")
+	if err != nil || !r.Start.Equal(day(1, 5)) || !r.End.Equal(day(1, 8)) {
+		t.Errorf("ParseRange() = %v, %v", r, err)
+	}
+	if _, err := dp.ParseRange("2024-01-07", "2024-01-05"); err == nil {
+		t.Error("ParseRange(reversed) expected error but got none")
+	}
+}
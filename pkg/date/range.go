@@ -0,0 +1,119 @@
+package date
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange 日期范围，包含 Start，不包含 End，两者都是当天零点，零值表示该端不限
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String 格式化日期范围，单日为 "2006-01-02"，多日为 "2006-01-02 至 2006-01-08"
+func (r DateRange) String() string {
+	switch {
+	case r.Start.IsZero() && r.End.IsZero():
+		return "全部日期"
+	case r.Start.IsZero():
+		return "截至 " + r.End.AddDate(0, 0, -1).Format("2006-01-02")
+	case r.End.IsZero():
+		return r.Start.Format("2006-01-02") + " 起"
+	}
+	last := r.End.AddDate(0, 0, -1)
+	if !last.After(r.Start) {
+		return r.Start.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%s 至 %s", r.Start.Format("2006-01-02"), last.Format("2006-01-02"))
+}
+
+var (
+	// recentDaysPattern "最近N天"、"近N天"
+	recentDaysPattern = regexp.MustCompile(`(?:最近|近)(\d+)天`)
+	// datePattern 具体日期，如 2025-03-04
+	datePattern = regexp.MustCompile(`\d{4}-\d{1,2}-\d{1,2}`)
+)
+
+// ExtractRangeFromText 从文本中提取日期或时间段
+// 支持的表达式：
+// - ExtractDateFromText 支持的单日表达式，如 "昨天"、"3天前"，按整天计算
+// - "最近N天"、"近N天"、"最近一周"，包含今天
+// - "本周"、"这周"、"上周"，每周从周一开始
+// - "本月"、"这个月"、"上个月"、"上月"
+// - "2006-01-02" 格式的日期
+func (dp *DateProcessor) ExtractRangeFromText(text string) (DateRange, error) {
+	today := startOfDay(dp.Now())
+
+	if matches := recentDaysPattern.FindStringSubmatch(text); len(matches) == 2 {
+		days, err := strconv.Atoi(matches[1])
+		if err != nil || days <= 0 {
+			return DateRange{}, errors.New("无效的天数")
+		}
+		return DateRange{Start: today.AddDate(0, 0, 1-days), End: today.AddDate(0, 0, 1)}, nil
+	}
+
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	firstDay := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	switch {
+	case strings.Contains(text, "最近一周") || strings.Contains(text, "近一周"):
+		return DateRange{Start: today.AddDate(0, 0, -6), End: today.AddDate(0, 0, 1)}, nil
+	case strings.Contains(text, "上周"):
+		return DateRange{Start: monday.AddDate(0, 0, -7), End: monday}, nil
+	case strings.Contains(text, "本周") || strings.Contains(text, "这周"):
+		return DateRange{Start: monday, End: monday.AddDate(0, 0, 7)}, nil
+	case strings.Contains(text, "上个月") || strings.Contains(text, "上月"):
+		return DateRange{Start: firstDay.AddDate(0, -1, 0), End: firstDay}, nil
+	case strings.Contains(text, "本月") || strings.Contains(text, "这个月"):
+		return DateRange{Start: firstDay, End: firstDay.AddDate(0, 1, 0)}, nil
+	}
+
+	if day, _, err := dp.ExtractDateFromText(text); err == nil {
+		return singleDay(startOfDay(day)), nil
+	}
+	if match := datePattern.FindString(text); match != "" {
+		if day, err := time.ParseInLocation("2006-1-2", match, today.Location()); err == nil {
+			return singleDay(day), nil
+		}
+	}
+
+	return DateRange{}, errors.New("文本中未找到日期或时间段")
+}
+
+// ParseRange 解析起止日期（都包含在内），格式为 "2006-01-02"，为空表示该端不限
+func (dp *DateProcessor) ParseRange(start, end string) (DateRange, error) {
+	var r DateRange
+	location := dp.Now().Location()
+	if start != "" {
+		day, err := time.ParseInLocation("2006-01-02", start, location)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("无效的开始日期: %s", start)
+		}
+		r.Start = day
+	}
+	if end != "" {
+		day, err := time.ParseInLocation("2006-01-02", end, location)
+		if err != nil {
+			return DateRange{}, fmt.Errorf("无效的结束日期: %s", end)
+		}
+		r.End = day.AddDate(0, 0, 1)
+	}
+	if !r.Start.IsZero() && !r.End.IsZero() && !r.End.After(r.Start) {
+		return DateRange{}, fmt.Errorf("结束日期 %s 早于开始日期 %s", end, start)
+	}
+	return r, nil
+}
+
+// singleDay 某一天的日期范围
+func singleDay(day time.Time) DateRange {
+	return DateRange{Start: day, End: day.AddDate(0, 0, 1)}
+}
+
+// startOfDay 当天零点
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,264 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+)
+
+// ErrExperimentNotFound 实验不存在
+var ErrExperimentNotFound = errors.New("experiment not found")
+
+// 分桶方式
+const (
+	BucketBySession = "session" // 同一会话始终进入同一分组
+	BucketByUser    = "user"    // 同一用户的所有会话进入同一分组，没有用户ID时按会话分桶
+)
+
+// Subject 参与分桶的对象
+type Subject struct {
+	SessionID string
+	UserID    string
+}
+
+// Assignment 对象在某个实验中的分组
+type Assignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// Manager 实验管理器，负责分桶、应用分组配置和汇总结果
+type Manager struct {
+	experiments []config.ExperimentConfig
+	logger      *logger.Logger
+	mu          sync.Mutex
+	sessions    map[string]*sessionRecord // 会话ID -> 分组和结果
+}
+
+// NewManager 创建实验管理器并校验实验配置
+func NewManager(experiments []config.ExperimentConfig, log *logger.Logger) (*Manager, error) {
+	names := make(map[string]bool, len(experiments))
+	for _, exp := range experiments {
+		if exp.Name == "" {
+			return nil, fmt.Errorf("实验名称不能为空")
+		}
+		if names[exp.Name] {
+			return nil, fmt.Errorf("实验名称重复: %s", exp.Name)
+		}
+		names[exp.Name] = true
+
+		if exp.BucketBy != "" && exp.BucketBy != BucketBySession && exp.BucketBy != BucketByUser {
+			return nil, fmt.Errorf("实验 %s 的分桶方式无效: %s", exp.Name, exp.BucketBy)
+		}
+		if exp.Traffic != nil && (*exp.Traffic < 0 || *exp.Traffic > 100) {
+			return nil, fmt.Errorf("实验 %s 的流量百分比应在0到100之间", exp.Name)
+		}
+		if len(exp.Variants) == 0 {
+			return nil, fmt.Errorf("实验 %s 至少需要一个分组", exp.Name)
+		}
+		variants := make(map[string]bool, len(exp.Variants))
+		for _, variant := range exp.Variants {
+			if variant.Name == "" || variants[variant.Name] {
+				return nil, fmt.Errorf("实验 %s 的分组名称为空或重复", exp.Name)
+			}
+			variants[variant.Name] = true
+			if variant.Weight < 0 {
+				return nil, fmt.Errorf("实验 %s 分组 %s 的权重不能为负数", exp.Name, variant.Name)
+			}
+			if variant.Temperature != nil {
+				options := model.GenerationOptions{Temperature: variant.Temperature}
+				if err := options.Validate(); err != nil {
+					return nil, fmt.Errorf("实验 %s 分组 %s 的参数无效: %w", exp.Name, variant.Name, err)
+				}
+			}
+		}
+	}
+
+	return &Manager{
+		experiments: experiments,
+		logger:      log,
+		sessions:    make(map[string]*sessionRecord),
+	}, nil
+}
+
+// CheckReferences 检查分组引用的模型档位和提示词版本是否存在
+func (m *Manager) CheckReferences(hasProfile func(name string) bool, hasPrompt func(name, version string) bool) error {
+	for _, exp := range m.experiments {
+		for _, variant := range exp.Variants {
+			if variant.Profile != "" && !hasProfile(variant.Profile) {
+				return fmt.Errorf("实验 %s 分组 %s 引用的模型档位不存在: %s", exp.Name, variant.Name, variant.Profile)
+			}
+			for name, version := range variant.Prompts {
+				if !hasPrompt(name, version) {
+					return fmt.Errorf("实验 %s 分组 %s 引用的提示词不存在: %s@%s", exp.Name, variant.Name, name, version)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// trafficPercent 进入实验的流量百分比，未设置时为100
+func trafficPercent(exp config.ExperimentConfig) int {
+	if exp.Traffic == nil {
+		return 100
+	}
+	return *exp.Traffic
+}
+
+// Assign 计算对象在各个已启用实验中的分组
+// 同一对象在同一实验中总是得到相同的分组，未进入实验流量的对象不返回该实验
+func (m *Manager) Assign(subject Subject) []Assignment {
+	assignments := make([]Assignment, 0)
+	for _, exp := range m.experiments {
+		if !exp.Enabled {
+			continue
+		}
+		key := bucketKey(exp, subject)
+		if key == "" {
+			continue
+		}
+
+		if bucket(exp.Name, "traffic", key, 100) >= trafficPercent(exp) {
+			continue
+		}
+
+		assignments = append(assignments, Assignment{
+			Experiment: exp.Name,
+			Variant:    pickVariant(exp, key).Name,
+		})
+	}
+	return assignments
+}
+
+// Enroll 为本次请求分组并应用分组配置，返回携带分组的上下文
+// 请求显式指定的模型档位和生成参数优先于分组配置
+// 有会话ID时记录会话的分组，用于汇总结果
+func (m *Manager) Enroll(ctx context.Context, subject Subject) (context.Context, []Assignment) {
+	assignments := m.Assign(subject)
+	if len(assignments) == 0 {
+		return ctx, assignments
+	}
+
+	for _, assignment := range assignments {
+		variant, _ := m.variant(assignment)
+		ctx = prompt.WithVersions(ctx, variant.Prompts)
+		if variant.Profile != "" && model.ProfileFromContext(ctx) == "" {
+			ctx = model.WithProfile(ctx, variant.Profile)
+		}
+		if variant.Temperature != nil {
+			options := model.GenerationOptions{Temperature: variant.Temperature}
+			ctx = model.WithGenerationOptions(ctx, options.Merge(model.GenerationOptionsFromContext(ctx)))
+		}
+	}
+
+	if subject.SessionID != "" {
+		m.recordTurn(subject.SessionID, assignments)
+	}
+
+	return context.WithValue(ctx, enrollmentKey{}, &enrollment{
+		manager:     m,
+		sessionID:   subject.SessionID,
+		assignments: assignments,
+	}), assignments
+}
+
+// variant 获取分组配置
+func (m *Manager) variant(assignment Assignment) (config.ExperimentVariant, bool) {
+	for _, exp := range m.experiments {
+		if exp.Name != assignment.Experiment {
+			continue
+		}
+		for _, variant := range exp.Variants {
+			if variant.Name == assignment.Variant {
+				return variant, true
+			}
+		}
+	}
+	return config.ExperimentVariant{}, false
+}
+
+// bucketKey 获取分桶使用的键
+func bucketKey(exp config.ExperimentConfig, subject Subject) string {
+	if exp.BucketBy == BucketByUser && subject.UserID != "" {
+		return "user:" + subject.UserID
+	}
+	if subject.SessionID != "" {
+		return "session:" + subject.SessionID
+	}
+	return ""
+}
+
+// pickVariant 按权重为键选择分组
+func pickVariant(exp config.ExperimentConfig, key string) config.ExperimentVariant {
+	total := 0
+	for _, variant := range exp.Variants {
+		total += variantWeight(variant)
+	}
+
+	slot := bucket(exp.Name, "variant", key, total)
+	for _, variant := range exp.Variants {
+		slot -= variantWeight(variant)
+		if slot < 0 {
+			return variant
+		}
+	}
+	return exp.Variants[len(exp.Variants)-1]
+}
+
+// variantWeight 获取分组权重，未设置时为1
+func variantWeight(variant config.ExperimentVariant) int {
+	if variant.Weight == 0 {
+		return 1
+	}
+	return variant.Weight
+}
+
+// bucket 将键稳定地映射到 [0, size) 区间
+// 实验名和用途作为盐，使不同实验、流量和分组的分桶相互独立
+func bucket(experiment, purpose, key string, size int) int {
+	if size <= 0 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment + "/" + purpose + "/" + key))
+	return int(hash.Sum32() % uint32(size))
+}
+
+// enrollmentKey 上下文中本次请求的实验分组
+type enrollmentKey struct{}
+
+// enrollment 本次请求的实验分组
+type enrollment struct {
+	manager     *Manager
+	sessionID   string
+	assignments []Assignment
+}
+
+// AssignmentsFromContext 获取本次请求的实验分组
+func AssignmentsFromContext(ctx context.Context) []Assignment {
+	if e, ok := ctx.Value(enrollmentKey{}).(*enrollment); ok {
+		return e.assignments
+	}
+	return nil
+}
+
+// Metadata 将本次请求的实验分组转换为消息元数据，未参与实验时返回nil
+func Metadata(ctx context.Context) map[string]interface{} {
+	assignments := AssignmentsFromContext(ctx)
+	if len(assignments) == 0 {
+		return nil
+	}
+	variants := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		variants[assignment.Experiment] = assignment.Variant
+	}
+	return map[string]interface{}{"experiments": variants}
+}
@@ -0,0 +1,141 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go-smart/internal/config"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	hot := 1.0
+	manager, err := NewManager([]config.ExperimentConfig{{
+		Name:    "refund_prompt",
+		Enabled: true,
+		Variants: []config.ExperimentVariant{
+			{Name: "control", Weight: 1},
+			{Name: "treatment", Weight: 1, Prompts: map[string]string{prompt.GeneralChat: "v2"}, Profile: "fast", Temperature: &hot},
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func TestAssignIsDeterministicAndBalanced(t *testing.T) {
+	manager := newTestManager(t)
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		subject := Subject{SessionID: fmt.Sprintf("session-%d", i)}
+		first := manager.Assign(subject)
+		second := manager.Assign(subject)
+		if len(first) != 1 || first[0] != second[0] {
+			t.Fatalf("assignment for %s not stable: %v vs %v", subject.SessionID, first, second)
+		}
+		counts[first[0].Variant]++
+	}
+	for _, variant := range []string{"control", "treatment"} {
+		if counts[variant] < 800 || counts[variant] > 1200 {
+			t.Fatalf("variant split too uneven: %v", counts)
+		}
+	}
+
+	if assignments := manager.Assign(Subject{}); len(assignments) != 0 {
+		t.Fatalf("subject without session or user should not be enrolled: %v", assignments)
+	}
+}
+
+func TestTrafficZeroEnrollsNobody(t *testing.T) {
+	zero, half := 0, 50
+	variants := []config.ExperimentVariant{{Name: "control"}}
+	manager, err := NewManager([]config.ExperimentConfig{
+		{Name: "paused", Enabled: true, Traffic: &zero, Variants: variants},
+		{Name: "half", Enabled: true, Traffic: &half, Variants: variants},
+		{Name: "full", Enabled: true, Variants: variants},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0表示不分配流量，未设置表示全部流量
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		for _, assignment := range manager.Assign(Subject{SessionID: fmt.Sprintf("session-%d", i)}) {
+			counts[assignment.Experiment]++
+		}
+	}
+	if counts["paused"] != 0 || counts["full"] != 1000 || counts["half"] < 400 || counts["half"] > 600 {
+		t.Errorf("enrollment counts = %v", counts)
+	}
+	if result, _ := manager.Result("paused"); result.Traffic != 0 {
+		t.Errorf("paused traffic = %d, want 0", result.Traffic)
+	}
+}
+
+func TestEnrollAppliesVariantAndAggregatesOutcomes(t *testing.T) {
+	manager := newTestManager(t)
+
+	// 找到分别落入两个分组的会话
+	sessions := map[string]string{}
+	for i := 0; len(sessions) < 2; i++ {
+		id := fmt.Sprintf("s%d", i)
+		variant := manager.Assign(Subject{SessionID: id})[0].Variant
+		if _, exists := sessions[variant]; !exists {
+			sessions[variant] = id
+		}
+	}
+
+	ctx, assignments := manager.Enroll(context.Background(), Subject{SessionID: sessions["treatment"]})
+	if len(assignments) != 1 || assignments[0].Variant != "treatment" {
+		t.Fatalf("unexpected assignments: %v", assignments)
+	}
+	if got := prompt.VersionFromContext(ctx, prompt.GeneralChat); got != "v2" {
+		t.Fatalf("prompt version override = %q, want v2", got)
+	}
+	if got := model.ProfileFromContext(ctx); got != "fast" {
+		t.Fatalf("profile override = %q, want fast", got)
+	}
+	if temp := model.GenerationOptionsFromContext(ctx).Temperature; temp == nil || *temp != 1.0 {
+		t.Fatalf("temperature override not applied: %v", temp)
+	}
+	RecordOutcome(ctx, OutcomeCompleted)
+	RecordOutcome(ctx, OutcomeCompleted)
+
+	// 请求显式指定的档位优先于分组配置
+	explicit := model.WithProfile(context.Background(), "smart")
+	ctx, _ = manager.Enroll(explicit, Subject{SessionID: sessions["treatment"]})
+	if got := model.ProfileFromContext(ctx); got != "smart" {
+		t.Fatalf("explicit profile overridden by variant: %q", got)
+	}
+
+	ctx, _ = manager.Enroll(context.Background(), Subject{SessionID: sessions["control"]})
+	RecordOutcome(ctx, OutcomeEscalated)
+	if err := manager.Rate(sessions["control"], 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Rate("unknown", 5); err != ErrSessionNotEnrolled {
+		t.Fatalf("rating unknown session: %v", err)
+	}
+
+	result, err := manager.Result("refund_prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := map[string]VariantResult{}
+	for _, variant := range result.Variants {
+		variants[variant.Variant] = variant
+	}
+	treatment, control := variants["treatment"], variants["control"]
+	if treatment.Sessions != 1 || treatment.Turns != 2 || treatment.Completions != 1 || treatment.CompletionRate != 1 {
+		t.Fatalf("unexpected treatment result: %+v", treatment)
+	}
+	if control.Sessions != 1 || control.Escalations != 1 || control.Ratings != 1 || control.AverageRating != 2 {
+		t.Fatalf("unexpected control result: %+v", control)
+	}
+}
@@ -0,0 +1,196 @@
+package experiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrSessionNotEnrolled 会话未参与任何实验
+var ErrSessionNotEnrolled = errors.New("session not enrolled in any experiment")
+
+// Outcome 会话结果
+type Outcome string
+
+const (
+	OutcomeCompleted Outcome = "completed" // 完成业务流程，如退款申请已提交
+	OutcomeEscalated Outcome = "escalated" // 转人工处理
+)
+
+// sessionRecord 会话的分组和结果，同一会话的结果只计一次
+type sessionRecord struct {
+	assignments []Assignment
+	turns       int
+	completed   bool
+	escalated   bool
+	rating      int // 用户评分，0表示未评分
+}
+
+// VariantResult 分组的结果汇总
+type VariantResult struct {
+	Variant        string  `json:"variant"`
+	Sessions       int     `json:"sessions"`
+	Turns          int     `json:"turns"`
+	Completions    int     `json:"completions"`
+	CompletionRate float64 `json:"completion_rate"`
+	Escalations    int     `json:"escalations"`
+	EscalationRate float64 `json:"escalation_rate"`
+	Ratings        int     `json:"ratings"`
+	AverageRating  float64 `json:"average_rating"`
+}
+
+// Result 实验的结果汇总
+type Result struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Enabled     bool            `json:"enabled"`
+	BucketBy    string          `json:"bucket_by"`
+	Traffic     int             `json:"traffic"`
+	Variants    []VariantResult `json:"variants"`
+}
+
+// recordTurn 记录会话的一轮对话，会话首次参与实验时记录分组
+func (m *Manager) recordTurn(sessionID string, assignments []Assignment) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, exists := m.sessions[sessionID]
+	if !exists {
+		record = &sessionRecord{}
+		m.sessions[sessionID] = record
+	}
+	record.assignments = assignments
+	record.turns++
+}
+
+// RecordOutcome 记录本次请求所在会话的结果，未参与实验或没有会话ID时忽略
+func RecordOutcome(ctx context.Context, outcome Outcome) {
+	e, ok := ctx.Value(enrollmentKey{}).(*enrollment)
+	if !ok || e.sessionID == "" {
+		return
+	}
+	e.manager.record(e.sessionID, func(record *sessionRecord) {
+		switch outcome {
+		case OutcomeCompleted:
+			record.completed = true
+		case OutcomeEscalated:
+			record.escalated = true
+		}
+	})
+}
+
+// Rate 记录用户对会话的评分（1-5），重复评分时以最后一次为准
+func (m *Manager) Rate(sessionID string, rating int) error {
+	if rating < 1 || rating > 5 {
+		return fmt.Errorf("评分应在1到5之间")
+	}
+	if !m.record(sessionID, func(record *sessionRecord) {
+		record.rating = rating
+	}) {
+		return ErrSessionNotEnrolled
+	}
+	return nil
+}
+
+// record 更新会话记录，会话未参与实验时返回false
+func (m *Manager) record(sessionID string, update func(*sessionRecord)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, exists := m.sessions[sessionID]
+	if !exists {
+		return false
+	}
+	update(record)
+	return true
+}
+
+// Results 汇总所有实验各分组的结果
+func (m *Manager) Results() []Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := make([]Result, 0, len(m.experiments))
+	for _, exp := range m.experiments {
+		results = append(results, m.result(exp.Name))
+	}
+	return results
+}
+
+// Result 汇总指定实验各分组的结果
+func (m *Manager) Result(name string) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, exp := range m.experiments {
+		if exp.Name == name {
+			return m.result(name), nil
+		}
+	}
+	return Result{}, fmt.Errorf("%w: %s", ErrExperimentNotFound, name)
+}
+
+// result 汇总实验结果，调用方需持有锁
+func (m *Manager) result(name string) Result {
+	var result Result
+	index := make(map[string]int)
+	for _, exp := range m.experiments {
+		if exp.Name != name {
+			continue
+		}
+		bucketBy := exp.BucketBy
+		if bucketBy == "" {
+			bucketBy = BucketBySession
+		}
+		result = Result{
+			Name:        exp.Name,
+			Description: exp.Description,
+			Enabled:     exp.Enabled,
+			BucketBy:    bucketBy,
+			Traffic:     trafficPercent(exp),
+			Variants:    make([]VariantResult, 0, len(exp.Variants)),
+		}
+		for _, variant := range exp.Variants {
+			index[variant.Name] = len(result.Variants)
+			result.Variants = append(result.Variants, VariantResult{Variant: variant.Name})
+		}
+	}
+
+	ratingSums := make([]int, len(result.Variants))
+	for _, record := range m.sessions {
+		for _, assignment := range record.assignments {
+			if assignment.Experiment != name {
+				continue
+			}
+			i, exists := index[assignment.Variant]
+			if !exists {
+				continue
+			}
+			variant := &result.Variants[i]
+			variant.Sessions++
+			variant.Turns += record.turns
+			if record.completed {
+				variant.Completions++
+			}
+			if record.escalated {
+				variant.Escalations++
+			}
+			if record.rating > 0 {
+				variant.Ratings++
+				ratingSums[i] += record.rating
+			}
+		}
+	}
+
+	for i := range result.Variants {
+		variant := &result.Variants[i]
+		if variant.Sessions > 0 {
+			variant.CompletionRate = float64(variant.Completions) / float64(variant.Sessions)
+			variant.EscalationRate = float64(variant.Escalations) / float64(variant.Sessions)
+		}
+		if variant.Ratings > 0 {
+			variant.AverageRating = float64(ratingSums[i]) / float64(variant.Ratings)
+		}
+	}
+	return result
+}
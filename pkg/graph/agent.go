@@ -0,0 +1,586 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-smart/pkg/llm"
+	"go-smart/pkg/tools"
+	"go-smart/pkg/trace"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 图的节点
+const (
+	nodeInput   = "input"   // 添加用户消息
+	nodeRoute   = "route"   // 选择处理消息的专员和提供给模型的工具
+	nodeGuard   = "guard"   // 检查处理限制并构建发给模型的消息
+	nodeModel   = "model"   // 调用模型规划
+	nodeApprove = "approve" // 检查工具调用限制，有会修改数据的调用时等待确认
+	nodeTools   = "tools"   // 执行工具调用
+	nodeStop    = "stop"    // 触发处理限制时返回兜底回复
+	nodeReject  = "reject"  // 拒绝执行时取消工具调用
+)
+
+// maxGraphSteps 单次执行图的最大步数，每个节点执行后都会中断保存检查点，单次执行只经过少数节点
+const maxGraphSteps = 32
+
+func init() {
+	schema.RegisterName[*graphState]("go_smart_workflow_state")
+}
+
+// graphState 图的状态，随检查点一起保存，字段需要导出才能序列化
+type graphState struct {
+	Messages   []*schema.Message
+	Progress   Progress
+	StopReason string
+	StopTool   string
+	Pending    string // 等待确认的请求ID
+	PendingAt  time.Time
+	Mutating   []string
+	Decision   string   // 确认结果，恢复执行时写入
+	Agent      string   // 处理本条消息的专员
+	Tools      []string // 本条消息提供给模型的工具
+	Selected   bool     // 是否选择了工具，未选择时提供专员可用的全部工具
+}
+
+// graphRunKey 上下文中本次执行的信息
+type graphRunKey struct{}
+
+// graphRun 本次执行的信息
+type graphRun struct {
+	seed     []*schema.Message // 新的处理开始时已有的对话记录
+	agent    string            // 处理上一条消息的专员
+	decision string            // 恢复执行时写入状态的确认结果
+	state    *graphState       // 图的状态，执行结束后同步到工作流状态
+}
+
+// newState 新的处理开始时创建图的状态
+func (r *graphRun) newState(ctx context.Context) *graphState {
+	r.state = &graphState{
+		Messages: append([]*schema.Message{}, r.seed...),
+		Progress: Progress{Repeated: make(map[string]int)},
+		Agent:    r.agent,
+	}
+	return r.state
+}
+
+// restore 从检查点恢复时记录图的状态，并写入确认结果
+func (r *graphRun) restore(ctx context.Context, path compose.NodePath, state any) error {
+	s, ok := state.(*graphState)
+	if !ok {
+		return fmt.Errorf("检查点中的状态类型不正确: %T", state)
+	}
+	if s.Progress.Repeated == nil {
+		s.Progress.Repeated = make(map[string]int)
+	}
+	if r.decision != "" {
+		s.Decision = r.decision
+		r.decision = ""
+	}
+	r.state = s
+	return nil
+}
+
+// progress 获取本条消息的处理进度
+func (r *graphRun) progress() Progress {
+	if r.state == nil {
+		return Progress{}
+	}
+	return r.state.Progress
+}
+
+// compile 编译工作流的图，编译结果在设置检查点存储前一直复用
+func (w *Workflow) compile(ctx context.Context) (compose.Runnable[[]*schema.Message, *schema.Message], error) {
+	if w.runnable != nil {
+		return w.runnable, nil
+	}
+
+	g := compose.NewGraph[[]*schema.Message, *schema.Message](compose.WithGenLocalState(func(ctx context.Context) *graphState {
+		run, _ := ctx.Value(graphRunKey{}).(*graphRun)
+		if run == nil {
+			run = &graphRun{}
+		}
+		return run.newState(ctx)
+	}))
+
+	toolsNode, err := compose.NewToolNode(ctx, &compose.ToolsNodeConfig{
+		UnknownToolsHandler: func(ctx context.Context, name, input string) (string, error) {
+			return toolResultContent(ToolResult{Error: fmt.Sprintf("工具不存在: %s", name)}), nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建工具节点失败: %w", err)
+	}
+
+	nodes := []error{
+		g.AddLambdaNode(nodeInput, compose.InvokableLambda(w.input)),
+		g.AddLambdaNode(nodeRoute, compose.InvokableLambda(w.route)),
+		g.AddLambdaNode(nodeGuard, compose.InvokableLambda(w.guard)),
+		g.AddChatModelNode(nodeModel, &plannerModel{workflow: w}, compose.WithStatePostHandler(w.afterModel)),
+		g.AddLambdaNode(nodeApprove, compose.InvokableLambda(w.approve)),
+		g.AddToolsNode(nodeTools, toolsNode, compose.WithStatePostHandler(afterTools)),
+		g.AddLambdaNode(nodeStop, compose.InvokableLambda(w.stopNode)),
+		g.AddLambdaNode(nodeReject, compose.InvokableLambda(w.reject)),
+
+		g.AddEdge(compose.START, nodeInput),
+		g.AddEdge(nodeInput, nodeRoute),
+		g.AddEdge(nodeRoute, nodeGuard),
+		g.AddBranch(nodeGuard, compose.NewGraphBranch(afterGuard, map[string]bool{nodeModel: true, nodeStop: true})),
+		g.AddBranch(nodeModel, compose.NewGraphBranch(afterPlan, map[string]bool{nodeApprove: true, compose.END: true})),
+		g.AddBranch(nodeApprove, compose.NewGraphBranch(afterApprove, map[string]bool{nodeTools: true, nodeStop: true, nodeReject: true})),
+		g.AddEdge(nodeTools, nodeGuard),
+		g.AddEdge(nodeStop, compose.END),
+		g.AddEdge(nodeReject, compose.END),
+	}
+	for _, err := range nodes {
+		if err != nil {
+			return nil, fmt.Errorf("构建工作流图失败: %w", err)
+		}
+	}
+
+	runnable, err := g.Compile(ctx,
+		compose.WithGraphName("workflow"),
+		compose.WithCheckPointStore(w.checkpoints),
+		compose.WithInterruptAfterNodes([]string{nodeModel, nodeTools}),
+		compose.WithMaxRunSteps(maxGraphSteps),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("编译工作流图失败: %w", err)
+	}
+	w.runnable = runnable
+	return runnable, nil
+}
+
+// input 添加用户消息
+func (w *Workflow) input(ctx context.Context, messages []*schema.Message) ([]*schema.Message, error) {
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		s.Messages = append(s.Messages, messages...)
+		return nil
+	})
+	return messages, err
+}
+
+// route 使用主管智能体时根据用户消息选择专员，对话记录在专员之间共享，再选择提供给模型的工具
+func (w *Workflow) route(ctx context.Context, messages []*schema.Message) ([]*schema.Message, error) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+	query := messages[len(messages)-1].Content
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		if w.supervisor != nil {
+			w.routeAgent(ctx, s, query)
+		}
+		s.Tools = w.selectTools(ctx, query, s.Agent)
+		s.Selected = s.Tools != nil
+		return nil
+	})
+	return messages, err
+}
+
+// routeAgent 选择处理消息的专员
+func (w *Workflow) routeAgent(ctx context.Context, s *graphState, query string) {
+	previous := s.Agent
+	s.Agent = w.supervisor.Route(query, previous)
+	if s.Agent != previous {
+		w.logger.Info("转交专员处理", map[string]interface{}{
+			"checkpoint_id": w.checkpointID,
+			"from":          previous,
+			"to":            s.Agent,
+		})
+	}
+	trace.FromContext(ctx).Transition("route", previous, s.Agent)
+}
+
+// guard 检查模型调用次数和处理时限，构建发给模型的消息：专员的系统提示加完整的对话记录
+func (w *Workflow) guard(ctx context.Context, _ []*schema.Message) ([]*schema.Message, error) {
+	var messages []*schema.Message
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		if w.limits.MaxModelTurns > 0 && s.Progress.ModelTurns >= w.limits.MaxModelTurns {
+			s.StopReason = LimitModelTurns
+			return nil
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			s.StopReason = LimitTimeout
+			return nil
+		}
+
+		systemPrompt, err := w.buildSystemPrompt(ctx, s.Agent, s.selected())
+		if err != nil {
+			return err
+		}
+		messages = append([]*schema.Message{schema.SystemMessage(systemPrompt)}, s.Messages...)
+		return nil
+	})
+	return messages, err
+}
+
+// selected 获取选择的工具，未选择时返回nil
+func (s *graphState) selected() []string {
+	if !s.Selected {
+		return nil
+	}
+	if s.Tools == nil {
+		return []string{}
+	}
+	return s.Tools
+}
+
+// afterGuard 触发处理限制时结束，否则调用模型
+func afterGuard(ctx context.Context, _ []*schema.Message) (string, error) {
+	next := nodeModel
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		if s.StopReason != "" {
+			next = nodeStop
+		}
+		return nil
+	})
+	return next, err
+}
+
+// afterModel 模型未返回调用ID时补全，保证工具结果能关联到对应的调用，并记录助手消息
+func (w *Workflow) afterModel(ctx context.Context, message *schema.Message, s *graphState) (*schema.Message, error) {
+	s.Progress.ModelTurns++
+	for i := range message.ToolCalls {
+		if message.ToolCalls[i].ID == "" {
+			message.ToolCalls[i].ID = fmt.Sprintf("call_%d_%d", s.Progress.ModelTurns, i)
+		}
+	}
+	s.Messages = append(s.Messages, message)
+	return message, nil
+}
+
+// afterPlan 有工具调用时继续执行，否则模型的回复即为最终回复
+func afterPlan(ctx context.Context, message *schema.Message) (string, error) {
+	if len(message.ToolCalls) > 0 {
+		return nodeApprove, nil
+	}
+	return compose.END, nil
+}
+
+// approve 执行前检查工具调用次数和重复调用，有会修改数据的调用时中断等待确认
+// 确认后从此节点重新执行，根据写入状态的确认结果继续或取消
+// 重新执行时节点没有输入，从对话记录中取最后一条带工具调用的助手消息
+func (w *Workflow) approve(ctx context.Context, message *schema.Message) (*schema.Message, error) {
+	wait := false
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		if s.Pending != "" {
+			message = lastToolCallMessage(s.Messages)
+			switch s.Decision {
+			case decisionApproved:
+				s.Pending, s.Mutating, s.Decision = "", nil, ""
+			case decisionRejected:
+			default:
+				wait = true
+			}
+			return nil
+		}
+
+		toolCalls := fromSchemaToolCalls(message.ToolCalls)
+		if w.limits.MaxToolCalls > 0 && s.Progress.ToolCalls+len(toolCalls) > w.limits.MaxToolCalls {
+			s.StopReason = LimitToolCalls
+			return nil
+		}
+		for _, toolCall := range toolCalls {
+			key := toolCallKey(toolCall)
+			s.Progress.Repeated[key]++
+			if w.limits.MaxRepeatedToolCalls > 0 && s.Progress.Repeated[key] > w.limits.MaxRepeatedToolCalls {
+				s.StopReason = LimitRepeatedToolCall
+				s.StopTool = toolCall.Name
+				return nil
+			}
+		}
+		s.Progress.ToolCalls += len(toolCalls)
+
+		if mutating := w.mutatingCalls(toolCalls); len(mutating) > 0 {
+			s.Pending = newApprovalID()
+			s.PendingAt = time.Now()
+			s.Mutating = mutating
+			wait = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wait {
+		return nil, compose.NewInterruptAndRerunErr(ActionAwaitApproval)
+	}
+	return message, nil
+}
+
+// lastToolCallMessage 获取最后一条带工具调用的助手消息
+func lastToolCallMessage(messages []*schema.Message) *schema.Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.Assistant && len(messages[i].ToolCalls) > 0 {
+			return messages[i]
+		}
+	}
+	return &schema.Message{Role: schema.Assistant}
+}
+
+// afterApprove 触发处理限制时结束，拒绝时取消工具调用，否则执行工具调用
+func afterApprove(ctx context.Context, _ *schema.Message) (string, error) {
+	next := nodeTools
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		switch {
+		case s.StopReason != "":
+			next = nodeStop
+		case s.Decision == decisionRejected:
+			next = nodeReject
+		}
+		return nil
+	})
+	return next, err
+}
+
+// afterTools 按调用顺序记录工具结果
+func afterTools(ctx context.Context, messages []*schema.Message, s *graphState) ([]*schema.Message, error) {
+	s.Messages = append(s.Messages, messages...)
+	return messages, nil
+}
+
+// stopNode 触发处理限制时结束本条消息，记录日志并返回兜底回复
+func (w *Workflow) stopNode(ctx context.Context, _ any) (*schema.Message, error) {
+	var answer *schema.Message
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		answer = schema.AssistantMessage(w.fallbackAnswer(ctx, s.StopReason, s.Progress, s.StopTool), nil)
+		s.Messages = append(s.Messages, answer)
+		return nil
+	})
+	return answer, err
+}
+
+// reject 拒绝执行时不执行任何调用，为每个调用记录被拒绝的结果并结束本条消息
+func (w *Workflow) reject(ctx context.Context, message *schema.Message) (*schema.Message, error) {
+	answer := schema.AssistantMessage(defaultRejectedAnswer, nil)
+	err := compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		for _, toolCall := range message.ToolCalls {
+			content := toolResultContent(ToolResult{ToolCallID: toolCall.ID, Error: rejectedToolError})
+			s.Messages = append(s.Messages, schema.ToolMessage(content, toolCall.ID))
+		}
+		s.Messages = append(s.Messages, answer)
+		s.Pending, s.Mutating, s.Decision = "", nil, ""
+		return nil
+	})
+	return answer, err
+}
+
+// mutatingCalls 获取需要确认的调用ID，不需要确认时返回空
+func (w *Workflow) mutatingCalls(toolCalls []ToolCall) []string {
+	if !w.requireApproval {
+		return nil
+	}
+	var mutating []string
+	for _, toolCall := range toolCalls {
+		// 不存在的工具不会执行，不需要确认
+		tool, exists := w.toolManager.GetTool(toolCall.Name)
+		if exists && tools.SideEffectOf(tool, toolCall.Args) == tools.Mutating {
+			mutating = append(mutating, toolCall.ID)
+		}
+	}
+	return mutating
+}
+
+// graphTools 获取工具节点使用的工具，每次执行时从工具管理器获取，保证使用最新注册的工具
+func (w *Workflow) graphTools() []tool.BaseTool {
+	available := w.toolManager.GetAllTools()
+	graphTools := make([]tool.BaseTool, 0, len(available))
+	for _, name := range sortedToolNames(available) {
+		graphTools = append(graphTools, &graphTool{workflow: w, tool: available[name]})
+	}
+	return graphTools
+}
+
+// graphTool 将工具管理器中的工具适配为Eino工具
+// 执行出错、超时或panic时返回带错误信息的结果而不是错误，避免一个调用失败导致整轮调用失败
+type graphTool struct {
+	workflow *Workflow
+	tool     tools.ToolFunction
+}
+
+// Info 获取工具信息
+func (t *graphTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.tool.GetName(), Desc: t.tool.GetDescription()}, nil
+}
+
+// InvokableRun 执行工具
+func (t *graphTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	toolCall := ToolCall{
+		ID:   compose.GetToolCallID(ctx),
+		Name: t.tool.GetName(),
+		Args: parseArguments(argumentsInJSON),
+	}
+	// 执行的调用由工具注册表的中间件记录执行记录，未执行的调用在这里记录
+	if !t.workflow.allowed(ctx, toolCall.Name) {
+		result := ToolResult{ToolCallID: toolCall.ID, Error: fmt.Sprintf("当前专员不能使用工具: %s", toolCall.Name)}
+		trace.FromContext(ctx).Tool(toolCall.Name, toolCall.ID, toolCall.Args, nil, time.Now(), result.Error)
+		return toolResultContent(result), nil
+	}
+	return toolResultContent(t.workflow.runToolCall(ctx, toolCall)), nil
+}
+
+// allowed 判断当前专员能否使用工具
+func (w *Workflow) allowed(ctx context.Context, name string) bool {
+	if w.supervisor == nil {
+		return true
+	}
+	agent, _ := currentTools(ctx)
+	_, exists := w.availableTools(agent)[name]
+	return exists
+}
+
+// currentTools 获取处理本条消息的专员和选择的工具
+func currentTools(ctx context.Context) (agent string, selected []string) {
+	_ = compose.ProcessState(ctx, func(ctx context.Context, s *graphState) error {
+		agent, selected = s.Agent, s.selected()
+		return nil
+	})
+	return agent, selected
+}
+
+// plannerModel 将LLM客户端适配为Eino对话模型，使用规划时的生成参数和工具定义
+type plannerModel struct {
+	workflow *Workflow
+}
+
+// Generate 调用模型，只提供本条消息选择的工具，规划参数覆盖请求级和配置中的生成参数
+func (m *plannerModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	w := m.workflow
+	options := append(w.plannerOptions.ModelOptions(), opts...)
+	agent, selected := currentTools(ctx)
+	response, err := w.llmClient.Chat(ctx, clientMessages(input), w.toolDefinitions(agent, selected), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	toolCalls := make([]ToolCall, 0, len(response.ToolCalls))
+	for _, toolCall := range response.ToolCalls {
+		toolCalls = append(toolCalls, ToolCall{
+			ID:   toolCall.ID,
+			Name: toolCall.Function.Name,
+			Args: toolCall.Function.Arguments,
+		})
+	}
+	return schema.AssistantMessage(response.Content, toSchemaToolCalls(toolCalls)), nil
+}
+
+// Stream 以流的形式返回完整的模型回复
+func (m *plannerModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	message, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{message}), nil
+}
+
+// clientMessages 将消息转换为LLM客户端的格式，工具调用和工具结果保持关联
+func clientMessages(input []*schema.Message) []map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(input))
+	for _, msg := range input {
+		message := map[string]interface{}{
+			"role":    string(msg.Role),
+			"content": msg.Content,
+		}
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]llm.ToolCall, 0, len(msg.ToolCalls))
+			for _, toolCall := range fromSchemaToolCalls(msg.ToolCalls) {
+				calls = append(calls, llm.ToolCall{
+					ID:       toolCall.ID,
+					Function: llm.ToolCallFunction{Name: toolCall.Name, Arguments: toolCall.Args},
+				})
+			}
+			message["tool_calls"] = calls
+		}
+		if msg.ToolCallID != "" {
+			message["tool_call_id"] = msg.ToolCallID
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// toSchemaMessages 将对话记录转换为Eino消息
+func toSchemaMessages(messages []Message) []*schema.Message {
+	converted := make([]*schema.Message, 0, len(messages))
+	for _, message := range messages {
+		converted = append(converted, &schema.Message{
+			Role:       schema.RoleType(message.Role),
+			Content:    message.Content,
+			ToolCalls:  toSchemaToolCalls(message.ToolCalls),
+			ToolCallID: message.ToolCallID,
+		})
+	}
+	return converted
+}
+
+// fromSchemaMessages 将Eino消息转换为对话记录
+func fromSchemaMessages(messages []*schema.Message) []Message {
+	converted := make([]Message, 0, len(messages))
+	for _, message := range messages {
+		converted = append(converted, Message{
+			Role:       string(message.Role),
+			Content:    message.Content,
+			ToolCalls:  fromSchemaToolCalls(message.ToolCalls),
+			ToolCallID: message.ToolCallID,
+		})
+	}
+	return converted
+}
+
+// toSchemaToolCalls 将工具调用转换为Eino工具调用，参数序列化为JSON
+func toSchemaToolCalls(toolCalls []ToolCall) []schema.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	converted := make([]schema.ToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		arguments := []byte("{}")
+		if toolCall.Args != nil {
+			if data, err := json.Marshal(toolCall.Args); err == nil {
+				arguments = data
+			}
+		}
+		converted = append(converted, schema.ToolCall{
+			ID:       toolCall.ID,
+			Type:     "function",
+			Function: schema.FunctionCall{Name: toolCall.Name, Arguments: string(arguments)},
+		})
+	}
+	return converted
+}
+
+// fromSchemaToolCalls 将Eino工具调用转换为工具调用
+func fromSchemaToolCalls(toolCalls []schema.ToolCall) []ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	converted := make([]ToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		converted = append(converted, ToolCall{
+			ID:   toolCall.ID,
+			Name: toolCall.Function.Name,
+			Args: parseArguments(toolCall.Function.Arguments),
+		})
+	}
+	return converted
+}
+
+// parseArguments 解析JSON格式的工具参数，无法解析时原样保存在 raw 中
+func parseArguments(arguments string) map[string]interface{} {
+	args := make(map[string]interface{})
+	if arguments == "" {
+		return args
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return map[string]interface{}{"raw": arguments}
+	}
+	return args
+}
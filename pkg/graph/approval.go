@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrApprovalPending 工作流正在等待确认，确认或拒绝前不能处理新消息
+	ErrApprovalPending = errors.New("workflow is waiting for approval")
+	// ErrNoPendingApproval 工作流没有等待确认的工具调用
+	ErrNoPendingApproval = errors.New("workflow has no pending approval")
+	// ErrApprovalNotFound 确认请求不存在或已处理
+	ErrApprovalNotFound = errors.New("approval not found")
+	// ErrNotInterrupted 工作流没有被中断的处理
+	ErrNotInterrupted = errors.New("workflow has no interrupted run")
+)
+
+// ActionAwaitApproval 等待确认时的 NextAction
+const ActionAwaitApproval = "await_approval"
+
+// defaultRejectedAnswer 拒绝执行时的回复
+const defaultRejectedAnswer = "好的，操作已取消，没有执行任何修改。"
+
+// rejectedToolError 拒绝执行时工具结果中的错误信息
+const rejectedToolError = "用户拒绝执行，操作已取消"
+
+// 确认结果
+const (
+	decisionApproved = "approved"
+	decisionRejected = "rejected"
+)
+
+// Progress 单条消息的处理进度，暂停后恢复时继续计入处理限制
+type Progress struct {
+	ModelTurns int            `json:"model_turns"`
+	ToolCalls  int            `json:"tool_calls"`
+	Repeated   map[string]int `json:"repeated,omitempty"` // 工具调用键 -> 次数
+}
+
+// PendingApproval 等待确认的工具调用
+// 同一轮的工具调用确认后一起执行，拒绝时都不执行
+type PendingApproval struct {
+	ID          string     `json:"id"`
+	ToolCalls   []ToolCall `json:"tool_calls"`
+	Mutating    []string   `json:"mutating"` // 会修改数据、需要确认的调用ID
+	Message     string     `json:"message"`  // 发给用户的确认提示
+	RequestedAt time.Time  `json:"requested_at"`
+}
+
+// newApprovalID 生成确认请求ID
+func newApprovalID() string {
+	return "apr_" + randomHex()
+}
+
+// randomHex 生成随机的十六进制字符串
+func randomHex() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// approvalMessage 生成确认提示，列出会修改数据的调用及参数
+func approvalMessage(toolCalls []ToolCall, mutating []string) string {
+	needed := make(map[string]bool, len(mutating))
+	for _, id := range mutating {
+		needed[id] = true
+	}
+
+	var builder strings.Builder
+	builder.WriteString("以下操作需要您确认后才会执行：\n")
+	index := 0
+	for _, toolCall := range toolCalls {
+		if !needed[toolCall.ID] {
+			continue
+		}
+		index++
+		args, _ := json.Marshal(toolCall.Args)
+		fmt.Fprintf(&builder, "%d. %s %s\n", index, toolCall.Name, args)
+	}
+	builder.WriteString("请确认是否继续。")
+	return builder.String()
+}
@@ -0,0 +1,236 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 检查点状态
+const (
+	StatusRunning          = "running"           // 正在处理，服务重启后可从最后一个节点继续
+	StatusAwaitingApproval = "awaiting_approval" // 等待确认工具调用
+	StatusCompleted        = "completed"         // 本条消息已处理完成
+)
+
+// Checkpoint 工作流检查点，以会话ID（未绑定会话时为随机ID）保存
+type Checkpoint struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Ephemeral bool      `json:"ephemeral,omitempty"` // 未绑定会话，处理完成后删除
+	State     State     `json:"state"`               // 对话记录和等待确认的工具调用
+	Graph     []byte    `json:"graph,omitempty"`     // 图的检查点数据，用于从中断的节点继续
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckpointStore 保存工作流检查点，每个检查点一个JSON文件
+// 实现 compose.CheckPointStore，图在每个节点执行后和等待确认时写入
+type CheckpointStore struct {
+	mu          sync.Mutex
+	dir         string
+	ttl         time.Duration // 处理完成的检查点保留的时长，0表示一直保留
+	checkpoints map[string]*Checkpoint
+}
+
+// OpenCheckpointStore 打开检查点存储，加载目录中已有的检查点
+// dir为空时仅保存在内存中；ttl 为处理完成的检查点在最后一次更新后保留的时长，
+// 超过的检查点不再加载并删除文件，为0表示一直保留
+func OpenCheckpointStore(dir string, ttl time.Duration) (*CheckpointStore, error) {
+	store := &CheckpointStore{
+		dir:         dir,
+		ttl:         ttl,
+		checkpoints: make(map[string]*Checkpoint),
+	}
+	if dir == "" {
+		return store, nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建检查点目录失败: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取检查点目录失败: %w", err)
+	}
+	now := time.Now()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取检查点失败: %w", err)
+		}
+		checkpoint := &Checkpoint{}
+		if err := json.Unmarshal(data, checkpoint); err != nil {
+			return nil, fmt.Errorf("解析检查点 %s 失败: %w", filepath.Base(path), err)
+		}
+		if store.expired(checkpoint, now) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("删除过期检查点失败: %w", err)
+			}
+			continue
+		}
+		store.checkpoints[checkpoint.ID] = checkpoint
+	}
+	return store, nil
+}
+
+// Get 获取图的检查点数据
+func (s *CheckpointStore) Get(ctx context.Context, id string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, exists := s.checkpoints[id]
+	if !exists || len(checkpoint.Graph) == 0 {
+		return nil, false, nil
+	}
+	return checkpoint.Graph, true, nil
+}
+
+// Set 保存图的检查点数据
+func (s *CheckpointStore) Set(ctx context.Context, id string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, exists := s.checkpoints[id]
+	if !exists {
+		checkpoint = &Checkpoint{ID: id, Status: StatusRunning}
+	}
+	updated := *checkpoint
+	updated.Graph = data
+	return s.write(&updated)
+}
+
+// Load 获取检查点
+func (s *CheckpointStore) Load(id string) (Checkpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoint, exists := s.checkpoints[id]
+	if !exists {
+		return Checkpoint{}, false
+	}
+	return *checkpoint, true
+}
+
+// Save 保存检查点的状态，保留图的检查点数据
+// 处理完成后图的检查点数据不再需要，会被清除
+func (s *CheckpointStore) Save(id, status string, ephemeral bool, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := Checkpoint{ID: id}
+	if checkpoint, exists := s.checkpoints[id]; exists {
+		updated = *checkpoint
+	}
+	updated.Status = status
+	updated.Ephemeral = ephemeral
+	updated.State = state
+	if status == StatusCompleted {
+		updated.Graph = nil
+	}
+	return s.write(&updated)
+}
+
+// Delete 删除检查点
+func (s *CheckpointStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir != "" {
+		if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("删除检查点失败: %w", err)
+		}
+	}
+	delete(s.checkpoints, id)
+	return nil
+}
+
+// Prune 删除超过保留时长的已完成检查点，返回删除的数量
+// 正在处理和等待确认的检查点不会被删除
+func (s *CheckpointStore) Prune() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pruned := 0
+	now := time.Now()
+	for id, checkpoint := range s.checkpoints {
+		if !s.expired(checkpoint, now) {
+			continue
+		}
+		if s.dir != "" {
+			if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+				return pruned, fmt.Errorf("删除检查点失败: %w", err)
+			}
+		}
+		delete(s.checkpoints, id)
+		pruned++
+	}
+	return pruned, nil
+}
+
+// expired 检查点是否已处理完成且超过保留时长
+func (s *CheckpointStore) expired(checkpoint *Checkpoint, now time.Time) bool {
+	return s.ttl > 0 && checkpoint.Status == StatusCompleted && now.Sub(checkpoint.UpdatedAt) > s.ttl
+}
+
+// List 获取指定状态的检查点，按更新时间排序
+func (s *CheckpointStore) List(status string) []Checkpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints := make([]Checkpoint, 0)
+	for _, checkpoint := range s.checkpoints {
+		if checkpoint.Status == status {
+			checkpoints = append(checkpoints, *checkpoint)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].UpdatedAt.Before(checkpoints[j].UpdatedAt)
+	})
+	return checkpoints
+}
+
+// FindApproval 根据确认请求ID查找等待确认的检查点
+func (s *CheckpointStore) FindApproval(approvalID string) (Checkpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, checkpoint := range s.checkpoints {
+		pending := checkpoint.State.Pending
+		if checkpoint.Status == StatusAwaitingApproval && pending != nil && pending.ID == approvalID {
+			return *checkpoint, true
+		}
+	}
+	return Checkpoint{}, false
+}
+
+// write 写入检查点，调用方需持有锁
+func (s *CheckpointStore) write(checkpoint *Checkpoint) error {
+	checkpoint.UpdatedAt = time.Now()
+	if s.dir != "" {
+		data, err := json.MarshalIndent(checkpoint, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化检查点失败: %w", err)
+		}
+		path := s.path(checkpoint.ID)
+		tmpPath := path + ".tmp"
+		if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+			return fmt.Errorf("写入检查点失败: %w", err)
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			return fmt.Errorf("写入检查点失败: %w", err)
+		}
+	}
+	s.checkpoints[checkpoint.ID] = checkpoint
+	return nil
+}
+
+// path 检查点文件路径，ID经过转义，包含路径分隔符等字符的不同ID不会对应同一文件
+func (s *CheckpointStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
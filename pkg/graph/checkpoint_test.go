@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointStoreKeepsIDsApart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenCheckpointStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+
+	// 只按文件名取ID最后一段时这些ID会写入同一文件，或写到目录之外
+	ids := []string{"b", "a/b", "../b", "a%2Fb"}
+	for _, id := range ids {
+		state := State{Messages: []Message{{Role: "user", Content: id}}}
+		if err := store.Save(id, StatusCompleted, false, state); err != nil {
+			t.Fatalf("Save(%q) error: %v", id, err)
+		}
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(paths) != len(ids) {
+		t.Fatalf("got %d checkpoint files, want %d: %v", len(paths), len(ids), paths)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "b.json")); !os.IsNotExist(err) {
+		t.Errorf("checkpoint written outside the store directory")
+	}
+
+	reopened, err := OpenCheckpointStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+	for _, id := range ids {
+		checkpoint, ok := reopened.Load(id)
+		if !ok || checkpoint.State.Messages[0].Content != id {
+			t.Errorf("Load(%q) = %+v, %v", id, checkpoint.State, ok)
+		}
+	}
+}
+
+func TestCheckpointStorePrunesCompleted(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenCheckpointStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+	for id, status := range map[string]string{"old_done": StatusCompleted, "old_running": StatusRunning, "new_done": StatusCompleted} {
+		if err := store.Save(id, status, false, State{}); err != nil {
+			t.Fatalf("Save(%q) error: %v", id, err)
+		}
+	}
+	// 将两个检查点的更新时间改为两小时前
+	for _, id := range []string{"old_done", "old_running"} {
+		store.checkpoints[id].UpdatedAt = time.Now().Add(-2 * time.Hour)
+	}
+
+	pruned, err := store.Prune()
+	if err != nil || pruned != 1 {
+		t.Fatalf("Prune() = %d, %v, want 1", pruned, err)
+	}
+	if _, ok := store.Load("old_done"); ok {
+		t.Error("expired completed checkpoint still loaded")
+	}
+	if _, err := os.Stat(store.path("old_done")); !os.IsNotExist(err) {
+		t.Error("expired completed checkpoint file still on disk")
+	}
+	for _, id := range []string{"old_running", "new_done"} {
+		if _, ok := store.Load(id); !ok {
+			t.Errorf("checkpoint %s pruned", id)
+		}
+	}
+
+	// 重新打开时不再加载过期的已完成检查点
+	if err := store.Save("stale", StatusCompleted, false, State{}); err != nil {
+		t.Fatal(err)
+	}
+	stale, _ := store.Load("stale")
+	stale.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	data := []byte(`{"id":"stale","status":"completed","state":{},"updated_at":"` + stale.UpdatedAt.Format(time.RFC3339Nano) + `"}`)
+	if err := os.WriteFile(store.path("stale"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenCheckpointStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+	if _, ok := reopened.Load("stale"); ok {
+		t.Error("expired checkpoint loaded on open")
+	}
+	if _, err := os.Stat(store.path("stale")); !os.IsNotExist(err) {
+		t.Error("expired checkpoint file kept on open")
+	}
+}
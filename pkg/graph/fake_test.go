@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"sync/atomic"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+
+	"go-smart/pkg/llm"
+)
+
+// replyFunc 根据第几次调用（从1开始）和收到的消息决定模型的回复
+type replyFunc func(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error)
+
+// fakeClient 由 reply 决定回复的模型客户端，记录每次调用收到的消息和工具名称
+type fakeClient struct {
+	reply    replyFunc
+	calls    int
+	received [][]map[string]interface{}
+	tools    [][]string
+}
+
+// Chat 记录调用后按 reply 回复
+func (c *fakeClient) Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}, opts ...einomodel.Option) (*llm.ChatResponse, error) {
+	c.calls++
+	names := []string{}
+	for _, tool := range tools {
+		names = append(names, tool["function"].(map[string]interface{})["name"].(string))
+	}
+	c.received = append(c.received, messages)
+	c.tools = append(c.tools, names)
+	return c.reply(ctx, c.calls, messages)
+}
+
+// GetModelInfo 获取模型信息
+func (c *fakeClient) GetModelInfo() map[string]string {
+	return map[string]string{}
+}
+
+// last 最近一次调用收到的消息
+func (c *fakeClient) last() []map[string]interface{} {
+	if len(c.received) == 0 {
+		return nil
+	}
+	return c.received[len(c.received)-1]
+}
+
+// answer 直接回答的回复
+func answer(content string) replyFunc {
+	return func(context.Context, int, []map[string]interface{}) (*llm.ChatResponse, error) {
+		return &llm.ChatResponse{Content: content}, nil
+	}
+}
+
+// callToolsOnce 第一次调用要求执行给定的工具调用，之后按 then 回复
+func callToolsOnce(calls []llm.ToolCall, then replyFunc) replyFunc {
+	return func(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error) {
+		if call == 1 {
+			return &llm.ChatResponse{ToolCalls: calls}, nil
+		}
+		return then(ctx, call, messages)
+	}
+}
+
+// toolCall 创建工具调用
+func toolCall(id, name string, args map[string]interface{}) llm.ToolCall {
+	return llm.ToolCall{ID: id, Function: llm.ToolCallFunction{Name: name, Arguments: args}}
+}
+
+// stubTool 可配置的测试工具，run 为空时原样返回参数，记录实际执行次数
+// 未设置 readOnly 时按会修改数据的工具处理
+type stubTool struct {
+	name     string
+	readOnly bool
+	run      func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+	calls    atomic.Int32
+}
+
+// Call 执行工具
+func (s *stubTool) Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	s.calls.Add(1)
+	if s.run == nil {
+		return args, nil
+	}
+	return s.run(ctx, args)
+}
+
+func (s *stubTool) GetDescription() string { return s.name }
+func (s *stubTool) GetName() string        { return s.name }
+func (s *stubTool) GetParameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (s *stubTool) IsReadOnly(map[string]interface{}) bool { return s.readOnly }
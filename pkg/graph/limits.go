@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-smart/internal/config"
+)
+
+// 触发的限制名称，记录在 State.StopReason 中
+const (
+	LimitModelTurns       = "max_model_turns"    // 模型调用次数超限
+	LimitToolCalls        = "max_tool_calls"     // 工具调用次数超限
+	LimitRepeatedToolCall = "repeated_tool_call" // 反复以相同参数调用同一工具
+	LimitTimeout          = "timeout"            // 处理超时
+)
+
+// defaultFallbackAnswer 触发限制时的默认回复
+const defaultFallbackAnswer = "抱歉，您的问题处理步骤较多，暂时无法完成。请换个方式描述您的问题，或联系人工客服。"
+
+// Limits 单条消息的处理限制，为0的字段表示不限制
+type Limits struct {
+	MaxModelTurns        int           // 最多调用模型的次数
+	MaxToolCalls         int           // 最多执行的工具调用数
+	MaxRepeatedToolCalls int           // 同一工具以相同参数最多调用的次数
+	Timeout              time.Duration // 处理时限
+	FallbackAnswer       string        // 触发限制时的回复
+	MaxParallelTools     int           // 同一轮工具调用的最大并发数
+}
+
+// DefaultLimits 默认处理限制
+func DefaultLimits() Limits {
+	return Limits{
+		MaxModelTurns:        8,
+		MaxToolCalls:         16,
+		MaxRepeatedToolCalls: 2,
+		Timeout:              60 * time.Second,
+		FallbackAnswer:       defaultFallbackAnswer,
+		MaxParallelTools:     4,
+	}
+}
+
+// LimitsFromConfig 从配置创建处理限制，未设置的字段使用默认值，负数表示不限制
+func LimitsFromConfig(cfg config.WorkflowConfig) (Limits, error) {
+	limits := DefaultLimits()
+	if cfg.MaxModelTurns != 0 {
+		limits.MaxModelTurns = max(cfg.MaxModelTurns, 0)
+	}
+	if cfg.MaxToolCalls != 0 {
+		limits.MaxToolCalls = max(cfg.MaxToolCalls, 0)
+	}
+	if cfg.MaxRepeatedToolCalls != 0 {
+		limits.MaxRepeatedToolCalls = max(cfg.MaxRepeatedToolCalls, 0)
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return Limits{}, fmt.Errorf("无效的工作流超时时间 %q: %w", cfg.Timeout, err)
+		}
+		limits.Timeout = max(timeout, 0)
+	}
+	if cfg.MaxParallelTools != 0 {
+		limits.MaxParallelTools = max(cfg.MaxParallelTools, 0)
+	}
+	if cfg.FallbackAnswer != "" {
+		limits.FallbackAnswer = cfg.FallbackAnswer
+	}
+	return limits, nil
+}
+
+// toolCallKey 工具调用的唯一标识，用于识别相同参数的重复调用
+// encoding/json 按键排序输出map，参数相同时得到相同的标识
+func toolCallKey(toolCall ToolCall) string {
+	args, _ := json.Marshal(toolCall.Args)
+	return toolCall.Name + ":" + string(args)
+}
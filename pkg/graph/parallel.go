@@ -0,0 +1,34 @@
+package graph
+
+import "context"
+
+// toolSlotsKey 上下文中工具调用的并发槽位
+type toolSlotsKey struct{}
+
+// withToolSlots 为本次处理设置工具调用的并发槽位，同一轮的调用最多同时执行 n 个，n不大于0时不限制
+func withToolSlots(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, toolSlotsKey{}, make(chan struct{}, n))
+}
+
+// runToolCall 执行单个工具调用，出错时返回带错误信息的结果
+// 调用时限和panic由工具注册表的中间件处理（见 tools.Timeout、tools.Recover）
+// 工具节点并发执行同一轮的调用，每个调用执行前需要获取并发槽位
+func (w *Workflow) runToolCall(ctx context.Context, toolCall ToolCall) ToolResult {
+	if slots, ok := ctx.Value(toolSlotsKey{}).(chan struct{}); ok {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return ToolResult{ToolCallID: toolCall.ID, Error: "工具未执行: " + ctx.Err().Error()}
+		}
+	}
+
+	result, err := w.executeTool(ctx, toolCall)
+	if err != nil {
+		return ToolResult{ToolCallID: toolCall.ID, Error: err.Error()}
+	}
+	return ToolResult{ToolCallID: toolCall.ID, Result: result}
+}
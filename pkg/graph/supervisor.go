@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"regexp"
+	"strings"
+
+	"go-smart/pkg/prompt"
+)
+
+// 内置专员名称
+const (
+	SpecialistOrder   = "order"
+	SpecialistRefund  = "refund"
+	SpecialistInvoice = "invoice"
+	SpecialistGeneral = "general"
+)
+
+// Specialist 专员子智能体，负责一类问题，使用自己的系统提示和工具子集
+type Specialist struct {
+	Name     string
+	Prompt   string         // 系统提示名称，模板变量 tools 为可用的工具列表
+	Tools    []string       // 可用的工具名称，为空时不使用工具
+	Keywords []string       // 消息包含任一关键词时交给该专员
+	Pattern  *regexp.Regexp // 消息匹配时交给该专员，如订单号
+}
+
+// orderIDPattern 订单号，如 ORD123456
+var orderIDPattern = regexp.MustCompile(`(?i)ord\d{6,}`)
+
+// DefaultSpecialists 内置的订单、退款、发票和通用专员
+// 按顺序匹配，“订单退款”等同时包含多类关键词的消息交给排在前面的专员
+func DefaultSpecialists() []Specialist {
+	return []Specialist{
+		{
+			Name:     SpecialistRefund,
+			Prompt:   prompt.RefundSpecialist,
+			Tools:    []string{"order_query", "refund_request"},
+			Keywords: []string{"退款", "退货", "退钱", "refund"},
+		},
+		{
+			Name:     SpecialistInvoice,
+			Prompt:   prompt.InvoiceSpecialist,
+			Tools:    []string{"invoice_tool", "order_query"},
+			Keywords: []string{"发票", "开票", "抬头", "税号", "invoice"},
+		},
+		{
+			Name:     SpecialistOrder,
+			Prompt:   prompt.OrderSpecialist,
+			Tools:    []string{"order_query", "order_list"},
+			Keywords: []string{"查订单", "查询订单", "订单状态", "订单信息", "我的订单", "的订单", "下的单", "物流", "快递", "发货", "到货", "送达"},
+			Pattern:  orderIDPattern,
+		},
+		{
+			Name:   SpecialistGeneral,
+			Prompt: prompt.GeneralChat,
+		},
+	}
+}
+
+// Supervisor 主管智能体，每条用户消息先交给负责该类问题的专员处理
+// 消息没有明确的类别时（如补充订单号或退款原因）继续由上一位专员处理，对话记录在专员之间共享
+type Supervisor struct {
+	specialists []Specialist
+}
+
+// NewSupervisor 创建主管智能体，specialists 为空时使用 DefaultSpecialists
+func NewSupervisor(specialists []Specialist) *Supervisor {
+	if len(specialists) == 0 {
+		specialists = DefaultSpecialists()
+	}
+	return &Supervisor{specialists: specialists}
+}
+
+// Route 选择处理消息的专员，current 为上一条消息的专员
+// 没有匹配的专员时沿用 current，新会话交给 general
+func (s *Supervisor) Route(message, current string) string {
+	lowerMessage := strings.ToLower(message)
+	for _, specialist := range s.specialists {
+		for _, keyword := range specialist.Keywords {
+			if strings.Contains(lowerMessage, keyword) {
+				return specialist.Name
+			}
+		}
+	}
+
+	// 只有订单号等补充信息时，正在处理业务的专员继续处理
+	if _, exists := s.Specialist(current); exists && current != SpecialistGeneral {
+		return current
+	}
+	for _, specialist := range s.specialists {
+		if specialist.Pattern != nil && specialist.Pattern.MatchString(message) {
+			return specialist.Name
+		}
+	}
+	return SpecialistGeneral
+}
+
+// Specialist 根据名称获取专员
+func (s *Supervisor) Specialist(name string) (Specialist, bool) {
+	for _, specialist := range s.specialists {
+		if specialist.Name == name {
+			return specialist, true
+		}
+	}
+	return Specialist{}, false
+}
+
+// Specialists 获取全部专员
+func (s *Supervisor) Specialists() []Specialist {
+	return append([]Specialist{}, s.specialists...)
+}
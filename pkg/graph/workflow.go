@@ -3,32 +3,51 @@ package graph
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-smart/internal/logger"
 	"go-smart/pkg/llm"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
 	"go-smart/pkg/tools"
+	"go-smart/pkg/trace"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
 )
 
+// ErrRunInterrupted 上一条消息的处理被中断（如服务重启），需要先调用 Continue 继续处理
+var ErrRunInterrupted = errors.New("workflow run was interrupted")
+
 // State 状态图状态
 type State struct {
-	Messages     []Message `json:"messages"`
-	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
-	ToolResults  []ToolResult `json:"tool_results,omitempty"`
-	NextAction   string `json:"next_action"`
-	IsComplete   bool `json:"is_complete"`
+	Messages    []Message        `json:"messages"`
+	ToolCalls   []ToolCall       `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult     `json:"tool_results,omitempty"`
+	NextAction  string           `json:"next_action"`
+	IsComplete  bool             `json:"is_complete"`
+	StopReason  string           `json:"stop_reason,omitempty"` // 触发的处理限制，正常完成时为空
+	Agent       string           `json:"agent,omitempty"`       // 处理最近一条消息的专员，未使用主管智能体时为空
+	Pending     *PendingApproval `json:"pending,omitempty"`     // 等待确认的工具调用
 }
 
-// Message 消息
+// Message 消息，按对话顺序保存完整记录
+// 请求工具的助手消息带有 ToolCalls，随后每个工具结果一条 tool 消息，通过 ToolCallID 关联
 type Message struct {
-	Role    string `json:"role"` // user, assistant, system
-	Content string `json:"content"`
+	Role       string     `json:"role"` // user, assistant, system, tool
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
 }
 
 // ToolCall 工具调用
 type ToolCall struct {
-	ID       string                 `json:"id"`
-	Name     string                 `json:"name"`
-	Args     map[string]interface{} `json:"args"`
+	ID   string                 `json:"id"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
 }
 
 // ToolResult 工具结果
@@ -38,87 +57,250 @@ type ToolResult struct {
 	Error      string                 `json:"error,omitempty"`
 }
 
-// Workflow 工作流
+// Workflow 工作流，基于Eino编译的图执行：模型节点规划，工具节点执行，分支判断是否继续
+// 每个节点执行后和等待确认时以 checkpointID 保存检查点，中断后可从中断的节点继续
 type Workflow struct {
-	llmClient     llm.LLMClient
-	toolManager   *tools.ToolManager
-	state         State
+	llmClient       llm.LLMClient
+	toolManager     *tools.ToolManager
+	state           State
+	prompts         *prompt.Registry
+	plannerOptions  model.GenerationOptions
+	limits          Limits
+	requireApproval bool
+	supervisor      *Supervisor
+	selector        *tools.ToolSelector
+	logger          *logger.Logger
+
+	checkpoints  *CheckpointStore
+	checkpointID string
+	ephemeral    bool // 未绑定会话，处理完成后删除检查点
+	interrupted  bool // 上一条消息的处理被中断
+	runnable     compose.Runnable[[]*schema.Message, *schema.Message]
 }
 
-// NewWorkflow 创建工作流
-func NewWorkflow(llmClient llm.LLMClient, toolManager *tools.ToolManager) *Workflow {
-	return &Workflow{
-		llmClient:   llmClient,
-		toolManager: toolManager,
-		state: State{
-			Messages:   []Message{},
-			IsComplete: false,
-		},
+// defaultPlannerTemperature 规划工具调用时默认使用的温度，保证相同输入选择相同的工具
+var defaultPlannerTemperature = 0.0
+
+// NewWorkflow 创建工作流，系统提示使用注册表中的 workflow_planner
+// 单条消息的处理限制默认为 DefaultLimits，可通过 SetLimits 修改
+// 默认执行会修改数据的工具前需要确认，可通过 SetRequireApproval 关闭
+// 检查点默认仅保存在内存中，可通过 SetCheckpointStore 设置持久化的存储
+func NewWorkflow(llmClient llm.LLMClient, toolManager *tools.ToolManager, prompts *prompt.Registry, log *logger.Logger) *Workflow {
+	checkpoints, _ := OpenCheckpointStore("", 0)
+	w := &Workflow{
+		llmClient:       llmClient,
+		toolManager:     toolManager,
+		prompts:         prompts,
+		plannerOptions:  model.GenerationOptions{Temperature: &defaultPlannerTemperature},
+		limits:          DefaultLimits(),
+		requireApproval: true,
+		logger:          log,
+		checkpoints:     checkpoints,
 	}
+	w.Reset()
+	return w
 }
 
 // ProcessMessage 处理消息
+// 模型调用次数、工具调用次数、重复调用和处理时长受 Limits 限制，触发限制时返回兜底回复
+// 模型要求执行会修改数据的工具时暂停并返回确认提示，通过 Pending 获取确认请求，确认或拒绝后调用 Resume 继续
 func (w *Workflow) ProcessMessage(ctx context.Context, userMessage string) (string, error) {
-	// 添加用户消息到状态
-	w.state.Messages = append(w.state.Messages, Message{
-		Role:    "user",
-		Content: userMessage,
+	if w.state.Pending != nil {
+		return "", ErrApprovalPending
+	}
+	if w.interrupted {
+		return "", ErrRunInterrupted
+	}
+	w.state.IsComplete = false
+	w.state.StopReason = ""
+
+	// 整条消息的处理时限，等待确认的时间不计入
+	ctx, cancel := w.withTimeout(ctx)
+	defer cancel()
+
+	run := &graphRun{seed: toSchemaMessages(w.state.Messages), agent: w.state.Agent}
+	return w.invoke(ctx, run, []*schema.Message{schema.UserMessage(userMessage)}, compose.WithForceNewRun())
+}
+
+// Resume 处理确认结果，确认时执行暂停的工具调用并继续处理，拒绝时不执行任何调用并结束本条消息
+func (w *Workflow) Resume(ctx context.Context, approved bool) (string, error) {
+	pending := w.state.Pending
+	if pending == nil {
+		return "", ErrNoPendingApproval
+	}
+
+	decision := decisionApproved
+	message := "工具调用已确认"
+	if !approved {
+		decision = decisionRejected
+		message = "工具调用被拒绝"
+	}
+	w.logger.Info(message, map[string]interface{}{
+		"approval_id": pending.ID,
+		"mutating":    pending.Mutating,
+	})
+
+	ctx, cancel := w.withTimeout(ctx)
+	defer cancel()
+
+	return w.invoke(ctx, &graphRun{decision: decision}, nil)
+}
+
+// Continue 从检查点继续被中断的处理，如服务重启时正在处理的消息
+func (w *Workflow) Continue(ctx context.Context) (string, error) {
+	if !w.interrupted {
+		return "", ErrNotInterrupted
+	}
+	w.logger.Info("从检查点继续处理", map[string]interface{}{
+		"checkpoint_id": w.checkpointID,
 	})
-	
-	// 循环处理直到完成
-	for !w.state.IsComplete {
-		// 调用大模型
-		response, err := w.callModel(ctx)
-		if err != nil {
-			return "", fmt.Errorf("调用模型失败: %v", err)
+
+	ctx, cancel := w.withTimeout(ctx)
+	defer cancel()
+
+	return w.invoke(ctx, &graphRun{}, nil)
+}
+
+// invoke 执行图直到得到回复或需要确认
+// 每个节点执行后图会中断并保存检查点，随后从检查点继续执行
+func (w *Workflow) invoke(ctx context.Context, run *graphRun, input []*schema.Message, opts ...compose.Option) (string, error) {
+	runnable, err := w.compile(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = context.WithValue(ctx, graphRunKey{}, run)
+	ctx = withToolSlots(ctx, w.limits.MaxParallelTools)
+	base := []compose.Option{
+		compose.WithCheckPointID(w.checkpointID),
+		compose.WithStateModifier(run.restore),
+		compose.WithToolsNodeOption(compose.WithToolList(w.graphTools()...)).DesignateNode(nodeTools),
+	}
+
+	for {
+		output, err := runnable.Invoke(ctx, input, append(base, opts...)...)
+		if err == nil {
+			return w.complete(run, output.Content)
 		}
-		
-		// 添加助手回复到状态
-		w.state.Messages = append(w.state.Messages, Message{
-			Role:    "assistant",
-			Content: response.Content,
-		})
-		
-		// 检查是否有工具调用
-		if len(response.ToolCalls) > 0 {
-			// 保存工具调用
-			w.state.ToolCalls = append(w.state.ToolCalls, response.ToolCalls...)
-			
-			// 执行工具调用
-			for _, toolCall := range response.ToolCalls {
-				result, err := w.executeTool(ctx, toolCall)
-				if err != nil {
-					toolResult := ToolResult{
-						ToolCallID: toolCall.ID,
-						Error:      err.Error(),
-					}
-					w.state.ToolResults = append(w.state.ToolResults, toolResult)
-				} else {
-					toolResult := ToolResult{
-						ToolCallID: toolCall.ID,
-						Result:     result,
-					}
-					w.state.ToolResults = append(w.state.ToolResults, toolResult)
-				}
+
+		if info, ok := compose.ExtractInterruptInfo(err); ok {
+			w.sync(run)
+			if slices.Contains(info.RerunNodes, nodeApprove) {
+				return w.awaitApproval()
+			}
+			// 节点执行完成，检查点已保存，继续执行后续节点
+			w.interrupted = true
+			if err := w.save(StatusRunning); err != nil {
+				return "", err
 			}
-			
-			// 继续循环，让模型处理工具结果
+			input, opts = nil, nil
 			continue
-		} else {
-			// 没有工具调用，完成处理
-			w.state.IsComplete = true
+		}
+
+		w.sync(run)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			answer := w.stop(ctx, LimitTimeout, run.progress(), "")
+			return w.complete(nil, answer)
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// 请求被取消（如服务关闭），保留检查点，之后可调用 Continue 继续
+			w.interrupted = true
+			if saveErr := w.save(StatusRunning); saveErr != nil {
+				return "", saveErr
+			}
+			return "", fmt.Errorf("工作流处理被中断: %w", err)
+		}
+		w.interrupted = false
+		if saveErr := w.save(StatusCompleted); saveErr != nil {
+			w.logger.Error("保存检查点失败", map[string]interface{}{
+				"checkpoint_id": w.checkpointID,
+				"error":         saveErr.Error(),
+			})
+		}
+		return "", fmt.Errorf("调用模型失败: %w", err)
+	}
+}
+
+// complete 本条消息处理完成，保存最终状态
+func (w *Workflow) complete(run *graphRun, answer string) (string, error) {
+	if run != nil {
+		w.sync(run)
+	}
+	w.state.IsComplete = true
+	w.state.NextAction = ""
+	w.state.Pending = nil
+	w.interrupted = false
+	if err := w.save(StatusCompleted); err != nil {
+		return "", err
+	}
+	return answer, nil
+}
+
+// awaitApproval 暂停处理等待确认，返回确认提示
+func (w *Workflow) awaitApproval() (string, error) {
+	w.interrupted = false
+	pending := w.state.Pending
+	if pending == nil {
+		return "", fmt.Errorf("等待确认的工具调用缺失")
+	}
+	w.state.NextAction = ActionAwaitApproval
+	if err := w.save(StatusAwaitingApproval); err != nil {
+		return "", err
+	}
+
+	w.logger.Info("工具调用等待确认", map[string]interface{}{
+		"approval_id": pending.ID,
+		"mutating":    pending.Mutating,
+	})
+	return pending.Message, nil
+}
+
+// sync 将图的状态同步到工作流状态
+func (w *Workflow) sync(run *graphRun) {
+	s := run.state
+	if s == nil {
+		return
+	}
+
+	w.state.Messages = fromSchemaMessages(s.Messages)
+	w.state.ToolCalls = nil
+	w.state.ToolResults = nil
+	for _, message := range w.state.Messages {
+		w.state.ToolCalls = append(w.state.ToolCalls, message.ToolCalls...)
+		if message.Role == "tool" {
+			w.state.ToolResults = append(w.state.ToolResults, parseToolResult(message))
 		}
 	}
-	
-	// 获取最后的助手回复
-	if len(w.state.Messages) > 0 {
-		lastMessage := w.state.Messages[len(w.state.Messages)-1]
-		if lastMessage.Role == "assistant" {
-			return lastMessage.Content, nil
+	w.state.StopReason = s.StopReason
+	w.state.Agent = s.Agent
+
+	w.state.Pending = nil
+	if s.Pending != "" && s.Decision == "" {
+		toolCalls := lastToolCalls(w.state.Messages)
+		w.state.Pending = &PendingApproval{
+			ID:          s.Pending,
+			ToolCalls:   toolCalls,
+			Mutating:    s.Mutating,
+			Message:     approvalMessage(toolCalls, s.Mutating),
+			RequestedAt: s.PendingAt,
 		}
 	}
-	
-	return "", fmt.Errorf("未找到有效的回复")
+}
+
+// save 保存工作流状态到检查点，未绑定会话的检查点处理完成后删除
+func (w *Workflow) save(status string) error {
+	if status == StatusCompleted && w.ephemeral {
+		return w.checkpoints.Delete(w.checkpointID)
+	}
+	return w.checkpoints.Save(w.checkpointID, status, w.ephemeral, w.state)
+}
+
+// withTimeout 应用整条消息的处理时限
+func (w *Workflow) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.limits.Timeout > 0 {
+		return context.WithTimeout(ctx, w.limits.Timeout)
+	}
+	return ctx, func() {}
 }
 
 // ModelResponse 模型响应
@@ -127,47 +309,229 @@ type ModelResponse struct {
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
-// callModel 调用模型
-func (w *Workflow) callModel(ctx context.Context) (*ModelResponse, error) {
-	// 构建消息
-	messages := make([]map[string]interface{}, 0, len(w.state.Messages)+len(w.state.ToolResults))
-	
-	// 添加系统提示
-	systemPrompt := w.buildSystemPrompt()
-	messages = append(messages, map[string]interface{}{
-		"role":    "system",
-		"content": systemPrompt,
+// stop 图之外触发处理限制（如超时）时结束本条消息，返回兜底回复
+func (w *Workflow) stop(ctx context.Context, limit string, progress Progress, tool string) string {
+	answer := w.fallbackAnswer(ctx, limit, progress, tool)
+	w.state.Messages = append(w.state.Messages, Message{
+		Role:    "assistant",
+		Content: answer,
 	})
-	
-	// 添加历史消息
-	for _, msg := range w.state.Messages {
-		messages = append(messages, map[string]interface{}{
-			"role":    msg.Role,
-			"content": msg.Content,
-		})
+	w.state.StopReason = limit
+	return answer
+}
+
+// fallbackAnswer 记录触发的处理限制并返回兜底回复
+func (w *Workflow) fallbackAnswer(ctx context.Context, limit string, progress Progress, tool string) string {
+	fields := map[string]interface{}{
+		"limit":       limit,
+		"model_turns": progress.ModelTurns,
+		"tool_calls":  progress.ToolCalls,
+	}
+	if tool != "" {
+		fields["tool"] = tool
+	}
+	w.logger.Warn("工作流触发处理限制，返回兜底回复", fields)
+	trace.FromContext(ctx).Error(limit, "工作流触发处理限制")
+
+	if w.limits.FallbackAnswer == "" {
+		return defaultFallbackAnswer
+	}
+	return w.limits.FallbackAnswer
+}
+
+// toolErrorPrefix 工具执行出错时 tool 消息内容的前缀
+const toolErrorPrefix = "工具执行出错: "
+
+// toolResultContent 将工具结果转换为 tool 消息的内容
+func toolResultContent(result ToolResult) string {
+	if result.Error != "" {
+		return toolErrorPrefix + result.Error
 	}
-	
-	// 添加工具结果
-	for _, result := range w.state.ToolResults {
-		content := ""
-		if result.Error != "" {
-			content = fmt.Sprintf("工具执行出错: %s", result.Error)
-		} else {
-			resultJSON, _ := json.Marshal(result.Result)
-			content = string(resultJSON)
+	resultJSON, _ := json.Marshal(result.Result)
+	return string(resultJSON)
+}
+
+// parseToolResult 从 tool 消息还原工具结果
+func parseToolResult(message Message) ToolResult {
+	result := ToolResult{ToolCallID: message.ToolCallID}
+	if errMessage, failed := strings.CutPrefix(message.Content, toolErrorPrefix); failed {
+		result.Error = errMessage
+		return result
+	}
+	_ = json.Unmarshal([]byte(message.Content), &result.Result)
+	return result
+}
+
+// lastToolCalls 获取最后一条带工具调用的助手消息中的调用
+func lastToolCalls(messages []Message) []ToolCall {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" && len(messages[i].ToolCalls) > 0 {
+			return messages[i].ToolCalls
 		}
-		
-		messages = append(messages, map[string]interface{}{
-			"role":    "tool",
-			"content": content,
+	}
+	return nil
+}
+
+// SetLimits 设置单条消息的处理限制
+func (w *Workflow) SetLimits(limits Limits) {
+	w.limits = limits
+}
+
+// SetRequireApproval 设置执行会修改数据的工具前是否需要确认
+func (w *Workflow) SetRequireApproval(require bool) {
+	w.requireApproval = require
+}
+
+// SetSupervisor 设置主管智能体，每条消息交给对应的专员处理，为nil时使用 workflow_planner 和全部工具
+func (w *Workflow) SetSupervisor(supervisor *Supervisor) {
+	w.supervisor = supervisor
+}
+
+// SetToolSelector 设置工具选择器，每条消息只向模型提供相关的工具，为nil时提供全部可用的工具
+func (w *Workflow) SetToolSelector(selector *tools.ToolSelector) {
+	w.selector = selector
+}
+
+// SetCheckpointStore 设置检查点存储
+func (w *Workflow) SetCheckpointStore(store *CheckpointStore) {
+	w.checkpoints = store
+	w.runnable = nil
+}
+
+// Load 将工作流绑定到检查点ID（通常为会话ID），有保存的检查点时恢复对话记录和等待确认的调用
+func (w *Workflow) Load(checkpointID string) {
+	w.Reset()
+	w.checkpointID = checkpointID
+	w.ephemeral = false
+
+	checkpoint, exists := w.checkpoints.Load(checkpointID)
+	if !exists {
+		return
+	}
+	w.state = checkpoint.State
+	w.ephemeral = checkpoint.Ephemeral
+	w.interrupted = checkpoint.Status == StatusRunning
+}
+
+// CheckpointID 获取当前的检查点ID
+func (w *Workflow) CheckpointID() string {
+	return w.checkpointID
+}
+
+// Interrupted 上一条消息的处理是否被中断，需要调用 Continue 继续
+func (w *Workflow) Interrupted() bool {
+	return w.interrupted
+}
+
+// Pending 获取等待确认的工具调用，没有时返回nil
+func (w *Workflow) Pending() *PendingApproval {
+	return w.state.Pending
+}
+
+// State 获取工作流状态
+func (w *Workflow) State() State {
+	return w.state
+}
+
+// SetPlannerOptions 设置规划时的生成参数，如需要可复现的输出时固定温度和种子
+func (w *Workflow) SetPlannerOptions(options model.GenerationOptions) {
+	w.plannerOptions = options
+}
+
+// buildSystemPrompt 构建专员的系统提示，本条消息提供的工具列表作为模板变量 tools 传入
+func (w *Workflow) buildSystemPrompt(ctx context.Context, agent string, selected []string) (string, error) {
+	tools := w.offeredTools(agent, selected)
+	toolList := make([]map[string]interface{}, 0, len(tools))
+
+	// 按名称排序，保证相同输入得到相同的提示词
+	for _, name := range sortedToolNames(tools) {
+		tool := tools[name]
+		toolList = append(toolList, map[string]interface{}{
+			"name":        tool.GetName(),
+			"description": tool.GetDescription(),
 		})
 	}
-	
-	// 获取工具定义
-	availableTools := w.toolManager.GetAllTools()
+
+	name := prompt.WorkflowPlanner
+	if specialist, exists := w.specialist(agent); exists {
+		name = specialist.Prompt
+	}
+	return w.prompts.RenderContext(ctx, name, map[string]interface{}{
+		"tools": toolList,
+	})
+}
+
+// specialist 获取专员，未使用主管智能体时返回false
+func (w *Workflow) specialist(agent string) (Specialist, bool) {
+	if w.supervisor == nil {
+		return Specialist{}, false
+	}
+	return w.supervisor.Specialist(agent)
+}
+
+// availableTools 获取专员可用的工具，未使用主管智能体时为全部工具
+func (w *Workflow) availableTools(agent string) map[string]tools.ToolFunction {
+	specialist, exists := w.specialist(agent)
+	if !exists {
+		return w.toolManager.GetAllTools()
+	}
+	available := make(map[string]tools.ToolFunction, len(specialist.Tools))
+	for _, name := range specialist.Tools {
+		if tool, exists := w.toolManager.GetTool(name); exists {
+			available[name] = tool
+		}
+	}
+	return available
+}
+
+// selectTools 选择本条消息提供给模型的工具并记录选择结果，未设置工具选择器时返回nil
+func (w *Workflow) selectTools(ctx context.Context, query, agent string) []string {
+	if w.selector == nil {
+		return nil
+	}
+	specialist, exists := w.specialist(agent)
+	if exists && len(specialist.Tools) == 0 {
+		return []string{}
+	}
+
+	selection := w.selector.Select(w.toolManager.GetAllTools(), tools.SelectionRequest{
+		Query:   query,
+		Intent:  agent,
+		Allowed: specialist.Tools,
+	})
+	w.logger.Info("选择提供给模型的工具", map[string]interface{}{
+		"checkpoint_id": w.checkpointID,
+		"intent":        agent,
+		"tools":         selection.Tools,
+		"reasons":       selection.Reasons,
+		"fallback":      selection.Fallback,
+	})
+	trace.FromContext(ctx).Tools(agent, selection.Tools, selection.Reasons)
+	return selection.Tools
+}
+
+// offeredTools 获取提供给模型的工具：选择的工具，未选择时为专员可用的全部工具
+func (w *Workflow) offeredTools(agent string, selected []string) map[string]tools.ToolFunction {
+	available := w.availableTools(agent)
+	if selected == nil {
+		return available
+	}
+	offered := make(map[string]tools.ToolFunction, len(selected))
+	for _, name := range selected {
+		if tool, exists := available[name]; exists {
+			offered[name] = tool
+		}
+	}
+	return offered
+}
+
+// toolDefinitions 获取发送给模型的工具定义
+func (w *Workflow) toolDefinitions(agent string, selected []string) []map[string]interface{} {
+	availableTools := w.offeredTools(agent, selected)
 	toolDefinitions := make([]map[string]interface{}, 0, len(availableTools))
-	
-	for _, tool := range availableTools {
+
+	for _, name := range sortedToolNames(availableTools) {
+		tool := availableTools[name]
 		toolDefinitions = append(toolDefinitions, map[string]interface{}{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -177,68 +541,35 @@ func (w *Workflow) callModel(ctx context.Context) (*ModelResponse, error) {
 			},
 		})
 	}
-	
-	// 调用模型
-	response, err := w.llmClient.Chat(ctx, messages, toolDefinitions)
-	if err != nil {
-		return nil, err
-	}
-	
-	// 解析响应
-	modelResponse := &ModelResponse{
-		Content: response.Content,
-	}
-	
-	// 解析工具调用
-	if response.ToolCalls != nil {
-		for _, toolCall := range response.ToolCalls {
-			modelResponse.ToolCalls = append(modelResponse.ToolCalls, ToolCall{
-				ID:   toolCall.ID,
-				Name: toolCall.Function.Name,
-				Args: toolCall.Function.Arguments,
-			})
-		}
-	}
-	
-	return modelResponse, nil
+	return toolDefinitions
 }
 
-// buildSystemPrompt 构建系统提示
-func (w *Workflow) buildSystemPrompt() string {
-	tools := w.toolManager.GetAllTools()
-	var toolDescriptions []string
-	
-	for _, tool := range tools {
-		toolDescriptions = append(toolDescriptions, fmt.Sprintf("- %s: %s", tool.GetName(), tool.GetDescription()))
+// sortedToolNames 获取排序后的工具名称
+func sortedToolNames(available map[string]tools.ToolFunction) []string {
+	names := make([]string, 0, len(available))
+	for name := range available {
+		names = append(names, name)
 	}
-	
-	return fmt.Sprintf(`你是一个智能助手，可以帮助用户处理订单查询、退款申请和发票相关的问题。
-
-可用工具:
-%s
-
-使用工具的规则:
-1. 当用户需要查询订单信息时，使用order_query_tool
-2. 当用户需要申请退款时，使用refund_request_tool
-3. 当用户需要创建或查询发票时，使用invoice_tool
-
-请根据用户的问题，选择合适的工具来帮助用户。如果不需要使用工具，可以直接回答用户的问题。`, strings.Join(toolDescriptions, "\n"))
+	sort.Strings(names)
+	return names
 }
 
-// executeTool 执行工具
+// executeTool 执行工具，参数按工具的参数Schema转换和校验，不符合要求时返回模型可据此修正的错误
 func (w *Workflow) executeTool(ctx context.Context, toolCall ToolCall) (map[string]interface{}, error) {
-	tool, exists := w.toolManager.GetTool(toolCall.Name)
-	if !exists {
+	if _, exists := w.toolManager.GetTool(toolCall.Name); !exists {
 		return nil, fmt.Errorf("工具不存在: %s", toolCall.Name)
 	}
-	
-	return tool.Call(toolCall.Args)
+
+	return w.toolManager.CallTool(tools.WithCallID(ctx, toolCall.ID), toolCall.Name, toolCall.Args)
 }
 
-// Reset 重置工作流状态
+// Reset 重置工作流状态，使用新的临时检查点ID
 func (w *Workflow) Reset() {
 	w.state = State{
 		Messages:   []Message{},
 		IsComplete: false,
 	}
-}
\ No newline at end of file
+	w.checkpointID = "run_" + randomHex()
+	w.ephemeral = true
+	w.interrupted = false
+}
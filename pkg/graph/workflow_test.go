@@ -0,0 +1,495 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/business"
+	"go-smart/pkg/cassette"
+	"go-smart/pkg/llm"
+	"go-smart/pkg/model"
+	"go-smart/pkg/prompt"
+	"go-smart/pkg/tools"
+)
+
+// 默认从磁带回放；设置 LLM_CASSETTE_MODE=record 并提供 OPENAI_API_KEY 时访问真实服务重新录制
+// 仓库中的磁带是手写的固定数据（见文件中的 note），不是真实录制
+func newCassetteWorkflow(t *testing.T, name string) *Workflow {
+	t.Helper()
+
+	cfg := &config.Config{
+		AI: config.AIConfig{
+			Provider: "openai",
+			OpenAI: config.OpenAIConfig{
+				APIKey:  "sk-replay-placeholder",
+				BaseURL: "https://api.openai.com/v1",
+				Model:   "gpt-3.5-turbo",
+			},
+		},
+	}
+
+	mode := cassette.ModeReplay
+	if os.Getenv("LLM_CASSETTE_MODE") == string(cassette.ModeRecord) {
+		mode = cassette.ModeRecord
+		cfg.AI.OpenAI.APIKey = os.Getenv("OPENAI_API_KEY")
+		if base := os.Getenv("OPENAI_API_BASE"); base != "" {
+			cfg.AI.OpenAI.BaseURL = base
+		}
+	}
+
+	recorder, err := cassette.NewRecorder(cassette.Config{
+		Path:    "testdata/cassettes/" + name + ".json",
+		Mode:    mode,
+		Secrets: []string{cfg.AI.OpenAI.APIKey},
+	})
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %v", err)
+	}
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	modelManager := model.NewModelManager(cfg, log, nil, recorder.Client())
+	return NewWorkflow(llm.NewEinoLLMClient(modelManager), tools.NewToolManager(business.NewMockServices()), prompt.Builtin(), log)
+}
+
+func TestWorkflowReplaysCassette(t *testing.T) {
+	workflow := newCassetteWorkflow(t, "workflow_greeting")
+
+	response, err := workflow.ProcessMessage(context.Background(), "你好，你能帮我做什么？")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if !strings.Contains(response, "订单") {
+		t.Errorf("response = %q, want mention of 订单", response)
+	}
+}
+
+// looping 每次都要求以相同参数调用工具的模型，vary 时每次参数不同，用于测试处理限制
+func looping(vary bool) replyFunc {
+	return func(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error) {
+		args := map[string]interface{}{"order_id": "ORD123456"}
+		if vary {
+			args["page"] = call
+		}
+		return &llm.ChatResponse{ToolCalls: []llm.ToolCall{toolCall("call_1", "echo_tool", args)}}, nil
+	}
+}
+
+// blocking 阻塞直到上下文结束的模型
+func blocking(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// newLimitedWorkflow 创建使用给定模型和处理限制的工作流，注册原样返回参数的只读工具 echo_tool 和给定的工具
+func newLimitedWorkflow(t *testing.T, client llm.LLMClient, limits Limits, extra ...tools.ToolFunction) *Workflow {
+	t.Helper()
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatalf("DefaultLogger() error: %v", err)
+	}
+	toolManager := tools.NewToolManager(business.NewMockServices())
+	toolManager.Use(tools.Recover(log))
+	for _, tool := range append([]tools.ToolFunction{&stubTool{name: "echo_tool", readOnly: true}}, extra...) {
+		if err := toolManager.RegisterTool(tool); err != nil {
+			t.Fatal(err)
+		}
+	}
+	workflow := NewWorkflow(client, toolManager, prompt.Builtin(), log)
+	workflow.SetLimits(limits)
+	return workflow
+}
+
+func TestWorkflowStopsAtLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     replyFunc
+		limits    Limits
+		wantLimit string
+		wantCalls int
+	}{
+		{
+			name:      "repeated identical tool call",
+			reply:     looping(false),
+			limits:    Limits{MaxModelTurns: 10, MaxRepeatedToolCalls: 2},
+			wantLimit: LimitRepeatedToolCall,
+			wantCalls: 3,
+		},
+		{
+			name:      "model turns",
+			reply:     looping(true),
+			limits:    Limits{MaxModelTurns: 4},
+			wantLimit: LimitModelTurns,
+			wantCalls: 4,
+		},
+		{
+			name:      "tool calls",
+			reply:     looping(true),
+			limits:    Limits{MaxToolCalls: 2},
+			wantLimit: LimitToolCalls,
+			wantCalls: 3,
+		},
+		{
+			name:      "deadline",
+			reply:     blocking,
+			limits:    Limits{Timeout: 20 * time.Millisecond},
+			wantLimit: LimitTimeout,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limits.FallbackAnswer = "兜底回复"
+			client := &fakeClient{reply: tt.reply}
+			workflow := newLimitedWorkflow(t, client, tt.limits)
+
+			response, err := workflow.ProcessMessage(context.Background(), "查询订单")
+			if err != nil {
+				t.Fatalf("ProcessMessage() error: %v", err)
+			}
+			if response != "兜底回复" {
+				t.Errorf("response = %q, want fallback answer", response)
+			}
+			if workflow.state.StopReason != tt.wantLimit {
+				t.Errorf("StopReason = %q, want %q", workflow.state.StopReason, tt.wantLimit)
+			}
+			if client.calls != tt.wantCalls {
+				t.Errorf("model calls = %d, want %d", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWorkflowKeepsOrderedTranscript(t *testing.T) {
+	// 第一次调用要求执行两个工具，其中一个不存在，之后直接回答
+	client := &fakeClient{reply: callToolsOnce([]llm.ToolCall{
+		toolCall("call_a", "echo_tool", map[string]interface{}{"order_id": "ORD1"}),
+		toolCall("call_b", "missing_tool", map[string]interface{}{}),
+	}, answer("两个订单都已查询"))}
+	workflow := newLimitedWorkflow(t, client, DefaultLimits())
+
+	response, err := workflow.ProcessMessage(context.Background(), "查询两个订单")
+	if err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if response != "两个订单都已查询" {
+		t.Fatalf("response = %q", response)
+	}
+
+	// 第二次调用应看到：system, user, 带工具调用的assistant, 按顺序关联ID的两条tool消息
+	second := client.received[1]
+	roles := make([]string, 0, len(second))
+	for _, message := range second {
+		roles = append(roles, message["role"].(string))
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool,tool" {
+		t.Fatalf("roles = %s", got)
+	}
+	if calls, _ := second[2]["tool_calls"].([]llm.ToolCall); len(calls) != 2 || calls[0].ID != "call_a" {
+		t.Fatalf("assistant message lost tool calls: %#v", second[2])
+	}
+	if second[3]["tool_call_id"] != "call_a" || !strings.Contains(second[3]["content"].(string), "ORD1") {
+		t.Fatalf("first tool message = %#v", second[3])
+	}
+	if second[4]["tool_call_id"] != "call_b" || !strings.Contains(second[4]["content"].(string), "工具不存在") {
+		t.Fatalf("second tool message = %#v", second[4])
+	}
+
+	// 状态中不应出现空的助手消息
+	for _, message := range workflow.state.Messages {
+		if message.Role == "assistant" && message.Content == "" && len(message.ToolCalls) == 0 {
+			t.Fatalf("empty assistant message stored: %#v", workflow.state.Messages)
+		}
+	}
+}
+
+// newSlowTool 耗时的工具，mode 为 panic 时panic，为 hang 时等待上下文结束
+func newSlowTool() *stubTool {
+	return &stubTool{name: "slow_tool", run: func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+		switch args["mode"] {
+		case "panic":
+			panic("boom")
+		case "hang":
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		default:
+			time.Sleep(100 * time.Millisecond)
+		}
+		return args, nil
+	}}
+}
+
+func slowCall(id string, args map[string]interface{}) llm.ToolCall {
+	return toolCall(id, "slow_tool", args)
+}
+
+func TestWorkflowRunsToolCallsInParallel(t *testing.T) {
+	calls := []llm.ToolCall{
+		slowCall("call_0", map[string]interface{}{"n": "0"}),
+		slowCall("call_1", map[string]interface{}{"mode": "panic"}),
+		slowCall("call_2", map[string]interface{}{"n": "2"}),
+		slowCall("call_3", map[string]interface{}{"mode": "hang"}),
+	}
+	client := &fakeClient{reply: callToolsOnce(calls, answer("处理完成"))}
+	workflow := newLimitedWorkflow(t, client, Limits{MaxParallelTools: 4}, newSlowTool())
+	workflow.SetRequireApproval(false)
+	workflow.toolManager.Use(tools.Timeout(300*time.Millisecond, nil))
+
+	start := time.Now()
+	if _, err := workflow.ProcessMessage(context.Background(), "并发执行"); err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+		t.Errorf("tool calls took %s, want them to run concurrently", elapsed)
+	}
+
+	results := workflow.State().ToolResults
+	if len(results) != len(calls) {
+		t.Fatalf("got %d results, want %d", len(results), len(calls))
+	}
+	for i, result := range results {
+		if result.ToolCallID != calls[i].ID {
+			t.Errorf("results[%d].ToolCallID = %q, want %q", i, result.ToolCallID, calls[i].ID)
+		}
+	}
+	if results[0].Error != "" || results[0].Result["n"] != "0" || results[2].Result["n"] != "2" {
+		t.Errorf("unexpected successful results: %+v, %+v", results[0], results[2])
+	}
+	if !strings.Contains(results[1].Error, "工具执行异常") {
+		t.Errorf("panic result error = %q", results[1].Error)
+	}
+	if results[3].Error != "工具执行超时" {
+		t.Errorf("hanging result error = %q", results[3].Error)
+	}
+}
+
+func TestWorkflowLimitsToolConcurrency(t *testing.T) {
+	client := &fakeClient{reply: callToolsOnce([]llm.ToolCall{
+		slowCall("call_0", map[string]interface{}{"n": "0"}),
+		slowCall("call_1", map[string]interface{}{"n": "1"}),
+		slowCall("call_2", map[string]interface{}{"n": "2"}),
+	}, answer("处理完成"))}
+	workflow := newLimitedWorkflow(t, client, Limits{MaxParallelTools: 1}, newSlowTool())
+	workflow.SetRequireApproval(false)
+
+	start := time.Now()
+	if _, err := workflow.ProcessMessage(context.Background(), "逐个执行"); err != nil {
+		t.Fatalf("ProcessMessage() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("tool calls took %s with one worker, want them to run sequentially", elapsed)
+	}
+}
+
+func TestWorkflowContinuesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenCheckpointStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+
+	// 工具执行后、第二次调用模型时请求被取消，模拟处理中途服务关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := []llm.ToolCall{toolCall("call_a", "echo_tool", map[string]interface{}{"order_id": "ORD1"})}
+	interrupted := callToolsOnce(calls, func(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	workflow := newLimitedWorkflow(t, &fakeClient{reply: interrupted}, DefaultLimits())
+	workflow.SetCheckpointStore(store)
+	workflow.Load("session_1")
+
+	if _, err := workflow.ProcessMessage(ctx, "查询订单ORD1"); err == nil {
+		t.Fatal("ProcessMessage() error = nil, want interruption")
+	}
+	if !workflow.Interrupted() {
+		t.Fatal("Interrupted() = false after cancellation")
+	}
+
+	// 重新打开存储，模拟服务重启后继续
+	reopened, err := OpenCheckpointStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+	if checkpoint, ok := reopened.Load("session_1"); !ok || checkpoint.Status != StatusRunning {
+		t.Fatalf("checkpoint = %+v, %v, want running", checkpoint, ok)
+	}
+
+	client := &fakeClient{reply: answer("处理完成")}
+	resumed := newLimitedWorkflow(t, client, DefaultLimits())
+	resumed.SetCheckpointStore(reopened)
+	resumed.Load("session_1")
+	if _, err := resumed.ProcessMessage(context.Background(), "新消息"); err != ErrRunInterrupted {
+		t.Errorf("ProcessMessage() before Continue error = %v, want ErrRunInterrupted", err)
+	}
+	response, err := resumed.Continue(context.Background())
+	if err != nil {
+		t.Fatalf("Continue() error: %v", err)
+	}
+	if response != "处理完成" {
+		t.Errorf("response = %q", response)
+	}
+
+	// 继续时不重复执行已完成的节点，模型看到之前的工具结果
+	if client.calls != 1 {
+		t.Errorf("model calls after Continue = %d, want 1", client.calls)
+	}
+	roles := make([]string, 0, len(client.last()))
+	for _, message := range client.last() {
+		roles = append(roles, message["role"].(string))
+	}
+	if got := strings.Join(roles, ","); got != "system,user,assistant,tool" {
+		t.Errorf("roles = %s", got)
+	}
+	if checkpoint, _ := reopened.Load("session_1"); checkpoint.Status != StatusCompleted || len(checkpoint.State.Messages) != 4 {
+		t.Errorf("checkpoint after Continue = %s with %d messages", checkpoint.Status, len(checkpoint.State.Messages))
+	}
+	if _, err := resumed.Continue(context.Background()); err != ErrNotInterrupted {
+		t.Errorf("second Continue() error = %v, want ErrNotInterrupted", err)
+	}
+}
+
+// refundReply 先查询订单并申请退款，拿到工具结果后给出回复
+func refundReply(ctx context.Context, call int, messages []map[string]interface{}) (*llm.ChatResponse, error) {
+	last := messages[len(messages)-1]
+	if last["role"] == "tool" {
+		return &llm.ChatResponse{Content: "处理结果: " + last["content"].(string)}, nil
+	}
+	return &llm.ChatResponse{ToolCalls: []llm.ToolCall{
+		toolCall("call_query", "echo_tool", map[string]interface{}{"order_id": "ORD1"}),
+		toolCall("call_refund", "refund_stub", map[string]interface{}{"order_id": "ORD1"}),
+	}}, nil
+}
+
+func TestWorkflowWaitsForApproval(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenCheckpointStore(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenCheckpointStore() error: %v", err)
+	}
+
+	for _, approved := range []bool{true, false} {
+		// 会修改数据的工具，两个工作流共用以统计实际执行次数
+		refund := &stubTool{name: "refund_stub", run: func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"refund_id": "REF1"}, nil
+		}}
+		sessionID := fmt.Sprintf("session_%v", approved)
+		workflow := newLimitedWorkflow(t, &fakeClient{reply: refundReply}, DefaultLimits(), refund)
+		workflow.SetCheckpointStore(store)
+		workflow.Load(sessionID)
+
+		response, err := workflow.ProcessMessage(context.Background(), "订单ORD1申请退款")
+		if err != nil {
+			t.Fatalf("ProcessMessage() error: %v", err)
+		}
+		pending := workflow.Pending()
+		if pending == nil {
+			t.Fatalf("Pending() = nil, want approval for refund_stub")
+		}
+		if response != pending.Message || !strings.Contains(response, "refund_stub") {
+			t.Errorf("response = %q, want confirmation message", response)
+		}
+		if len(pending.Mutating) != 1 || pending.Mutating[0] != "call_refund" {
+			t.Errorf("Mutating = %v, want [call_refund]", pending.Mutating)
+		}
+		if calls := refund.calls.Load(); calls != 0 {
+			t.Fatalf("refund executed %d times before approval", calls)
+		}
+		if _, err := workflow.ProcessMessage(context.Background(), "再来一条"); err != ErrApprovalPending {
+			t.Errorf("ProcessMessage() while pending error = %v, want ErrApprovalPending", err)
+		}
+
+		// 从磁盘重新加载检查点，模拟服务重启后再确认
+		reopened, err := OpenCheckpointStore(dir, 0)
+		if err != nil {
+			t.Fatalf("OpenCheckpointStore() error: %v", err)
+		}
+		checkpoint, ok := reopened.FindApproval(pending.ID)
+		if !ok || checkpoint.ID != sessionID {
+			t.Fatalf("FindApproval() = %+v, %v, want checkpoint of %s", checkpoint, ok, sessionID)
+		}
+
+		resumed := newLimitedWorkflow(t, &fakeClient{reply: refundReply}, DefaultLimits(), refund)
+		resumed.SetCheckpointStore(reopened)
+		resumed.Load(checkpoint.ID)
+		response, err = resumed.Resume(context.Background(), approved)
+		if err != nil {
+			t.Fatalf("Resume(%v) error: %v", approved, err)
+		}
+
+		wantCalls, wantResponse := int32(1), "REF1"
+		if !approved {
+			wantCalls, wantResponse = 0, defaultRejectedAnswer
+		}
+		if calls := refund.calls.Load(); calls != wantCalls {
+			t.Errorf("approved=%v: refund executed %d times, want %d", approved, calls, wantCalls)
+		}
+		if !strings.Contains(response, wantResponse) {
+			t.Errorf("approved=%v: response = %q, want %q", approved, response, wantResponse)
+		}
+		if resumed.Pending() != nil || !resumed.State().IsComplete {
+			t.Errorf("approved=%v: workflow still pending after Resume", approved)
+		}
+		if _, ok := reopened.FindApproval(pending.ID); ok {
+			t.Errorf("approved=%v: approval still stored after Resume", approved)
+		}
+		if _, err := resumed.Resume(context.Background(), approved); err != ErrNoPendingApproval {
+			t.Errorf("second Resume() error = %v, want ErrNoPendingApproval", err)
+		}
+	}
+}
+
+func TestWorkflowRoutesToSpecialists(t *testing.T) {
+	// 对第一条消息要求调用发票工具，之后直接回答
+	client := &fakeClient{reply: callToolsOnce([]llm.ToolCall{
+		toolCall("call_a", "invoice_tool", map[string]interface{}{"action": "query", "invoice_id": "INV1"}),
+	}, answer("好的"))}
+	workflow := newLimitedWorkflow(t, client, DefaultLimits())
+	workflow.SetSupervisor(NewSupervisor(nil))
+	workflow.SetRequireApproval(false)
+	workflow.Load("session_routing")
+
+	// 退款专员不能使用发票工具，补充订单号时仍由退款专员处理，之后转交发票专员
+	for _, message := range []string{"我要退款", "订单号是ORD123456", "再帮我开张发票"} {
+		if _, err := workflow.ProcessMessage(context.Background(), message); err != nil {
+			t.Fatalf("ProcessMessage(%q) error: %v", message, err)
+		}
+	}
+	if workflow.State().Agent != SpecialistInvoice {
+		t.Fatalf("agent = %q, want invoice", workflow.State().Agent)
+	}
+
+	wantTools := []string{"order_query,refund_request", "order_query,refund_request", "order_query,refund_request", "invoice_tool,order_query"}
+	if len(client.tools) != len(wantTools) {
+		t.Fatalf("model called %d times, want %d", len(client.tools), len(wantTools))
+	}
+	for i, want := range wantTools {
+		if got := strings.Join(client.tools[i], ","); got != want {
+			t.Errorf("call %d tools = %s, want %s", i, got, want)
+		}
+	}
+	systemPrompt := func(call int) string { return client.received[call][0]["content"].(string) }
+	if !strings.Contains(systemPrompt(0), "退款专员") || !strings.Contains(systemPrompt(3), "发票专员") {
+		t.Errorf("prompts = %q, %q", systemPrompt(0), systemPrompt(3))
+	}
+	if content := client.received[1][3]["content"].(string); !strings.Contains(content, "当前专员不能使用工具") {
+		t.Errorf("tool result = %q, want rejection of invoice_tool", content)
+	}
+	// 发票专员能看到之前与退款专员的全部对话
+	if got := len(client.received[3]); got != 8 {
+		t.Errorf("invoice specialist received %d messages, want 8", got)
+	}
+}
@@ -0,0 +1,77 @@
+package jsonschema
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Coerce 按Schema转换可以安全转换的值，返回转换后的值，不修改原值
+// 值已符合type关键字时保持不变；否则数字和布尔值形式的字符串（如 "2"、"true"）转换为对应类型，
+// 数值转换为字符串。对象的字段和数组的元素递归转换，无法转换的值保持原样，由 Validate 报告错误
+// value 应为 json.Unmarshal 到 interface{} 得到的值
+func (s *Schema) Coerce(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		value = s.coerceScalar(value)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		coerced := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			if property, defined := s.Properties[name]; defined {
+				coerced[name] = property.Coerce(field)
+			} else if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+				coerced[name] = s.AdditionalProperties.Schema.Coerce(field)
+			} else {
+				coerced[name] = field
+			}
+		}
+		return coerced
+	case []interface{}:
+		if s.Items == nil {
+			return v
+		}
+		coerced := make([]interface{}, len(v))
+		for i, item := range v {
+			coerced[i] = s.Items.Coerce(item)
+		}
+		return coerced
+	}
+	return value
+}
+
+// coerceScalar 按type关键字中的顺序尝试转换标量值
+func (s *Schema) coerceScalar(value interface{}) interface{} {
+	for _, expected := range s.Type {
+		switch v := value.(type) {
+		case string:
+			text := strings.TrimSpace(v)
+			switch expected {
+			case "integer":
+				if n, err := strconv.ParseFloat(text, 64); err == nil && n == math.Trunc(n) && !math.IsInf(n, 0) {
+					return n
+				}
+			case "number":
+				if n, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+					return n
+				}
+			case "boolean":
+				switch text {
+				case "true":
+					return true
+				case "false":
+					return false
+				}
+			}
+		case float64:
+			if expected == "string" {
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	contrib "github.com/eino-contrib/jsonschema"
+)
+
+// Schema JSON Schema 的常用子集，用于描述和校验模型输出、工具参数等JSON数据
+// 不支持的关键字在解析时被忽略
+type Schema struct {
+	Type                 TypeList           `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+}
+
+// TypeList type关键字，可以是单个类型或类型数组
+type TypeList []string
+
+// UnmarshalJSON 同时支持 "string" 和 ["string", "null"] 两种写法
+func (t *TypeList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = TypeList{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("type 必须是字符串或字符串数组: %w", err)
+	}
+	*t = multiple
+	return nil
+}
+
+// MarshalJSON 只有一个类型时输出字符串
+func (t TypeList) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// Additional additionalProperties关键字，可以是布尔值或Schema
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON 解析布尔值或Schema
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		*a = Additional{Allowed: allowed}
+		return nil
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("additionalProperties 必须是布尔值或Schema: %w", err)
+	}
+	*a = Additional{Allowed: true, Schema: &schema}
+	return nil
+}
+
+// MarshalJSON 输出布尔值或Schema
+func (a Additional) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// Parse 从JSON解析Schema
+func Parse(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("解析JSON Schema失败: %w", err)
+	}
+	return &schema, nil
+}
+
+// FromMap 从 map 形式的Schema转换，如工具的参数定义
+func FromMap(m map[string]interface{}) (*Schema, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("序列化JSON Schema失败: %w", err)
+	}
+	return Parse(data)
+}
+
+// Reflect 从Go类型生成Schema
+// 字段名取json标签，未标记omitempty的字段为必填，可用jsonschema标签补充描述、枚举等约束
+func Reflect(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("无法从nil生成JSON Schema")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	reflector := &contrib.Reflector{
+		Anonymous:      true,
+		DoNotReference: true,
+		ExpandedStruct: t.Kind() == reflect.Struct,
+	}
+	data, err := json.Marshal(reflector.ReflectFromType(t))
+	if err != nil {
+		return nil, fmt.Errorf("序列化JSON Schema失败: %w", err)
+	}
+	return Parse(data)
+}
+
+// String 返回Schema的JSON表示
+func (s *Schema) String() string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -0,0 +1,297 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError 单个校验错误
+type ValidationError struct {
+	Path    string `json:"path"` // 出错位置，如 $.items[0].name
+	Message string `json:"message"`
+}
+
+// Error 实现error接口
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors 校验错误列表
+type ValidationErrors []ValidationError
+
+// Error 实现error接口，每个错误一行
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Validate 校验JSON值是否符合Schema
+// value 应为 json.Unmarshal 到 interface{} 得到的值，不符合时返回 ValidationErrors
+func (s *Schema) Validate(value interface{}) error {
+	var errs ValidationErrors
+	s.validate("$", value, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidateJSON 解析JSON文本并校验
+func (s *Schema) ValidateJSON(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, ValidationErrors{{Path: "$", Message: "不是合法的JSON: " + err.Error()}}
+	}
+	return value, s.Validate(value)
+}
+
+// validate 递归校验
+func (s *Schema) validate(path string, value interface{}, errs *ValidationErrors) {
+	if s == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		fail("类型应为 %s，实际为 %s", strings.Join(s.Type, " 或 "), typeOf(value))
+		return
+	}
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("取值应为 %s 之一", formatValues(s.Enum))
+	}
+	if s.Const != nil && !equalValues(s.Const, value) {
+		fail("取值应为 %s", formatValues([]interface{}{s.Const}))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(path, v, errs)
+	case []interface{}:
+		s.validateArray(path, v, errs)
+	case string:
+		s.validateString(path, v, fail)
+	case float64, json.Number:
+		s.validateNumber(toFloat(v), fail)
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(path, value, errs)
+	}
+	if len(s.AnyOf) > 0 && countMatches(s.AnyOf, path, value) == 0 {
+		fail("不符合 anyOf 中的任何一个Schema")
+	}
+	if len(s.OneOf) > 0 {
+		if matched := countMatches(s.OneOf, path, value); matched != 1 {
+			fail("应恰好符合 oneOf 中的一个Schema，实际符合 %d 个", matched)
+		}
+	}
+}
+
+// validateObject 校验对象
+func (s *Schema) validateObject(path string, object map[string]interface{}, errs *ValidationErrors) {
+	for _, name := range s.Required {
+		if _, exists := object[name]; !exists {
+			*errs = append(*errs, ValidationError{Path: joinPath(path, name), Message: "缺少必填字段"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, defined := s.Properties[name]; defined {
+			property.validate(joinPath(path, name), object[name], errs)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if s.AdditionalProperties.Schema != nil {
+			s.AdditionalProperties.Schema.validate(joinPath(path, name), object[name], errs)
+		} else if !s.AdditionalProperties.Allowed {
+			*errs = append(*errs, ValidationError{Path: joinPath(path, name), Message: "不允许的字段"})
+		}
+	}
+}
+
+// validateArray 校验数组
+func (s *Schema) validateArray(path string, array []interface{}, errs *ValidationErrors) {
+	if s.MinItems != nil && len(array) < *s.MinItems {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("至少需要 %d 个元素", *s.MinItems)})
+	}
+	if s.MaxItems != nil && len(array) > *s.MaxItems {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("最多允许 %d 个元素", *s.MaxItems)})
+	}
+	if s.Items != nil {
+		for i, item := range array {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+	}
+}
+
+// validateString 校验字符串
+func (s *Schema) validateString(path string, value string, fail func(string, ...interface{})) {
+	length := utf8.RuneCountInString(value)
+	if s.MinLength != nil && length < *s.MinLength {
+		fail("长度至少为 %d", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		fail("长度最多为 %d", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			fail("无效的pattern: %s", s.Pattern)
+		} else if !pattern.MatchString(value) {
+			fail("应匹配 %s", s.Pattern)
+		}
+	}
+}
+
+// validateNumber 校验数值
+func (s *Schema) validateNumber(value float64, fail func(string, ...interface{})) {
+	if s.Minimum != nil && value < *s.Minimum {
+		fail("不能小于 %v", *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		fail("不能大于 %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && value <= *s.ExclusiveMinimum {
+		fail("必须大于 %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && value >= *s.ExclusiveMaximum {
+		fail("必须小于 %v", *s.ExclusiveMaximum)
+	}
+}
+
+// matchesType 判断值是否符合type关键字
+func (s *Schema) matchesType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, expected := range s.Type {
+		if expected == actual {
+			return true
+		}
+		// 整数也是合法的number
+		if expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf 获取值的JSON类型
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64, json.Number:
+		if f := toFloat(v); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toFloat 将JSON数值转换为float64
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	}
+	return 0
+}
+
+// countMatches 统计符合的子Schema数量
+func countMatches(schemas []*Schema, path string, value interface{}) int {
+	matched := 0
+	for _, sub := range schemas {
+		var subErrs ValidationErrors
+		sub.validate(path, value, &subErrs)
+		if len(subErrs) == 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+// containsValue 判断取值是否在枚举中
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues 按JSON语义比较两个值，数值统一按float64比较
+func equalValues(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		return toNumber(a) == toNumber(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isNumber 判断是否为数值类型
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case float64, float32, int, int64, json.Number:
+		return true
+	}
+	return false
+}
+
+// toNumber 将各种数值类型转换为float64
+func toNumber(value interface{}) float64 {
+	switch v := value.(type) {
+	case float32:
+		return float64(v)
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return toFloat(value)
+}
+
+// formatValues 格式化取值列表用于错误信息
+func formatValues(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// joinPath 拼接字段路径
+func joinPath(path, name string) string {
+	return path + "." + name
+}
@@ -0,0 +1,60 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type intentResult struct {
+	Intent     string   `json:"intent" jsonschema:"enum=query_order,enum=refund,enum=chitchat"`
+	Confidence float64  `json:"confidence" jsonschema:"minimum=0,maximum=1"`
+	OrderIDs   []string `json:"order_ids,omitempty"`
+}
+
+func TestReflectAndValidate(t *testing.T) {
+	schema, err := Reflect(&intentResult{})
+	if err != nil {
+		t.Fatalf("Reflect() error: %v", err)
+	}
+
+	if _, err := schema.ValidateJSON([]byte(`{"intent":"refund","confidence":0.9,"order_ids":["ORD1"]}`)); err != nil {
+		t.Errorf("valid document rejected: %v", err)
+	}
+
+	_, err = schema.ValidateJSON([]byte(`{"intent":"weather","confidence":1.5,"extra":true}`))
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("error = %v, want ValidationErrors", err)
+	}
+	message := err.Error()
+	for _, want := range []string{"$.intent", "$.confidence", "$.extra"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("errors %q missing %s", message, want)
+		}
+	}
+}
+
+func TestValidateFromMap(t *testing.T) {
+	schema, err := FromMap(map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"order_id": map[string]interface{}{"type": "string", "pattern": "^ORD\\d+$"},
+			"amount":   map[string]interface{}{"type": []string{"number", "null"}},
+		},
+		"required": []string{"order_id"},
+	})
+	if err != nil {
+		t.Fatalf("FromMap() error: %v", err)
+	}
+
+	if err := schema.Validate(map[string]interface{}{"order_id": "ORD1", "amount": nil}); err != nil {
+		t.Errorf("valid value rejected: %v", err)
+	}
+	if err := schema.Validate(map[string]interface{}{"order_id": "123", "amount": "10"}); err == nil {
+		t.Error("invalid value accepted")
+	}
+	if err := schema.Validate(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "缺少必填字段") {
+		t.Errorf("missing required error = %v", err)
+	}
+}
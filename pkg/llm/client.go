@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"go-smart/pkg/model"
+	"go-smart/pkg/trace"
+	"time"
 	
+	einomodel "github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 )
 
 // LLMClient 大语言模型客户端接口
 type LLMClient interface {
-	// Chat 对话
-	Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}) (*ChatResponse, error)
+	// Chat 对话，opts 为单次调用的生成参数，优先于请求级和配置中的参数
+	Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}, opts ...einomodel.Option) (*ChatResponse, error)
 	// GetModelInfo 获取模型信息
 	GetModelInfo() map[string]string
 }
@@ -48,47 +51,25 @@ func NewEinoLLMClient(modelManager *model.ModelManager) *EinoLLMClient {
 }
 
 // Chat 实现对话
-func (c *EinoLLMClient) Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}) (*ChatResponse, error) {
-	// 获取当前模型
-	model := c.modelManager.GetCurrentModel()
-	if model == nil {
-		return nil, fmt.Errorf("模型未初始化")
+func (c *EinoLLMClient) Chat(ctx context.Context, messages []map[string]interface{}, tools []map[string]interface{}, opts ...einomodel.Option) (*ChatResponse, error) {
+	// 按请求档位、租户默认档位或当前模型解析本次使用的模型
+	chatModel, err := c.modelManager.ModelFor(ctx)
+	if err != nil {
+		return nil, err
 	}
 	
 	// 转换消息格式
-	einoMessages, err := c.convertMessages(messages)
+	schemaMessages, err := c.convertMessages(messages)
 	if err != nil {
 		return nil, fmt.Errorf("消息格式转换失败: %v", err)
 	}
 	
-	// 转换为schema.Message类型
-	schemaMessages := make([]*schema.Message, 0, len(einoMessages))
-	for _, msg := range einoMessages {
-		if msgMap, ok := msg.(map[string]interface{}); ok {
-			role, _ := msgMap["role"].(string)
-			content, _ := msgMap["content"].(string)
-			
-			var message *schema.Message
-			switch role {
-			case "system":
-				message = schema.SystemMessage(content)
-			case "assistant":
-				// AssistantMessage需要额外的ToolCall参数
-				message = schema.AssistantMessage(content, nil)
-			case "user":
-				message = schema.UserMessage(content)
-			default:
-				message = schema.UserMessage(content)
-			}
-			
-			schemaMessages = append(schemaMessages, message)
-		}
-	}
-	
-	// 调用模型
-	result, err := model.Generate(ctx, schemaMessages)
+	// 调用模型，请求级生成参数在前，单次调用选项在后以便覆盖
+	start := time.Now()
+	result, err := chatModel.Generate(ctx, schemaMessages, model.CallOptions(ctx, opts...)...)
+	trace.FromContext(ctx).Model(model.ProfileFromContext(ctx), schemaMessages, result, start, err)
 	if err != nil {
-		return nil, fmt.Errorf("模型调用失败: %v", err)
+		return nil, fmt.Errorf("模型调用失败: %w", err)
 	}
 	
 	// 转换响应格式
@@ -121,16 +102,20 @@ func (c *EinoLLMClient) Chat(ctx context.Context, messages []map[string]interfac
 	return response, nil
 }
 
+// GenerateJSON 使用按上下文解析的模型生成符合Schema的JSON，详见包级函数 GenerateJSON
+func (c *EinoLLMClient) GenerateJSON(ctx context.Context, messages []*schema.Message, out interface{}, cfg StructuredConfig) error {
+	return GenerateJSON(ctx, c.modelManager.ChatModel(), messages, out, cfg)
+}
+
 // GetModelInfo 获取模型信息
 func (c *EinoLLMClient) GetModelInfo() map[string]string {
 	return c.modelManager.GetCurrentModelInfo()
 }
 
 // convertMessages 转换消息格式
-func (c *EinoLLMClient) convertMessages(messages []map[string]interface{}) ([]interface{}, error) {
-	// 这里需要根据Eino的实际消息格式进行转换
-	// 以下是伪代码，需要根据Eino的实际API实现
-	var einoMessages []interface{}
+// 助手消息的 tool_calls 和工具消息的 tool_call_id 原样保留，保证工具结果与调用对应
+func (c *EinoLLMClient) convertMessages(messages []map[string]interface{}) ([]*schema.Message, error) {
+	schemaMessages := make([]*schema.Message, 0, len(messages))
 	
 	for _, msg := range messages {
 		role, ok := msg["role"].(string)
@@ -143,16 +128,62 @@ func (c *EinoLLMClient) convertMessages(messages []map[string]interface{}) ([]in
 			return nil, fmt.Errorf("消息内容缺失")
 		}
 		
-		// 根据Eino的实际API创建消息对象
-		einoMsg := map[string]interface{}{
-			"role":    role,
-			"content": content,
+		var message *schema.Message
+		switch role {
+		case "system":
+			message = schema.SystemMessage(content)
+		case "assistant":
+			toolCalls, err := convertToolCalls(msg["tool_calls"])
+			if err != nil {
+				return nil, err
+			}
+			message = schema.AssistantMessage(content, toolCalls)
+		case "tool":
+			toolCallID, _ := msg["tool_call_id"].(string)
+			if toolCallID == "" {
+				return nil, fmt.Errorf("工具消息缺少tool_call_id")
+			}
+			message = schema.ToolMessage(content, toolCallID)
+		default:
+			message = schema.UserMessage(content)
 		}
 		
-		einoMessages = append(einoMessages, einoMsg)
+		schemaMessages = append(schemaMessages, message)
+	}
+	
+	return schemaMessages, nil
+}
+
+// convertToolCalls 将助手消息中的工具调用转换为Eino格式，参数序列化为JSON字符串
+func convertToolCalls(value interface{}) ([]schema.ToolCall, error) {
+	if value == nil {
+		return nil, nil
+	}
+	calls, ok := value.([]ToolCall)
+	if !ok {
+		return nil, fmt.Errorf("tool_calls格式不正确")
 	}
 	
-	return einoMessages, nil
+	toolCalls := make([]schema.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		arguments := []byte("{}")
+		if call.Function.Arguments != nil {
+			var err error
+			arguments, err = json.Marshal(call.Function.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("序列化工具调用参数失败: %w", err)
+			}
+		}
+		toolCalls = append(toolCalls, schema.ToolCall{
+			ID:   call.ID,
+			Type: "function",
+			Function: schema.FunctionCall{
+				Name:      call.Function.Name,
+				Arguments: string(arguments),
+			},
+		})
+	}
+	return toolCalls, nil
 }
 
 // convertTools 转换工具格式
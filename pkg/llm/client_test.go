@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestConvertMessagesKeepsToolCallLinks(t *testing.T) {
+	client := &EinoLLMClient{}
+	messages, err := client.convertMessages([]map[string]interface{}{
+		{"role": "user", "content": "查询订单"},
+		{"role": "assistant", "content": "", "tool_calls": []ToolCall{{
+			ID:       "call_1",
+			Function: ToolCallFunction{Name: "order_query", Arguments: map[string]interface{}{"order_id": "ORD1"}},
+		}}},
+		{"role": "tool", "content": `{"status":"已发货"}`, "tool_call_id": "call_1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assistant := messages[1]
+	if assistant.Role != schema.Assistant || len(assistant.ToolCalls) != 1 {
+		t.Fatalf("assistant message = %#v", assistant)
+	}
+	if call := assistant.ToolCalls[0]; call.ID != "call_1" || call.Function.Arguments != `{"order_id":"ORD1"}` {
+		t.Fatalf("tool call = %#v", call)
+	}
+	if tool := messages[2]; tool.Role != schema.Tool || tool.ToolCallID != "call_1" {
+		t.Fatalf("tool message = %#v", tool)
+	}
+
+	if _, err := client.convertMessages([]map[string]interface{}{{"role": "tool", "content": "x"}}); err == nil {
+		t.Fatal("expected error for tool message without tool_call_id")
+	}
+}
@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"go-smart/pkg/jsonschema"
+	"go-smart/pkg/model"
+)
+
+// ErrStructuredOutput 多次修复后模型仍未返回符合Schema的JSON
+var ErrStructuredOutput = errors.New("structured output validation failed")
+
+// defaultStructuredAttempts 默认最多调用模型的次数，包括首次调用
+const defaultStructuredAttempts = 3
+
+// resultField 顶层不是对象的Schema包装到该字段中，JSON模式只能返回对象
+const resultField = "result"
+
+// StructuredConfig 结构化输出配置
+type StructuredConfig struct {
+	Schema       *jsonschema.Schema // 期望的JSON Schema，为空时从输出参数的类型生成
+	MaxAttempts  int                // 最多调用模型的次数（含首次），默认3
+	ModelOptions []einomodel.Option // 额外的调用选项，如固定温度
+}
+
+// StructuredOutputError 结构化输出失败的详情
+type StructuredOutputError struct {
+	Attempts int    // 已调用模型的次数
+	Raw      string // 最后一次模型回复
+	Err      error  // 最后一次的解析或校验错误
+}
+
+// Error 实现error接口
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("模型在 %d 次尝试后仍未返回有效的JSON: %v", e.Attempts, e.Err)
+}
+
+// Unwrap 支持 errors.Is(err, ErrStructuredOutput)
+func (e *StructuredOutputError) Unwrap() []error {
+	return []error{ErrStructuredOutput, e.Err}
+}
+
+// GenerateTyped 要求模型返回符合类型T的JSON并解析
+func GenerateTyped[T any](ctx context.Context, chatModel einomodel.BaseChatModel, messages []*schema.Message, cfg StructuredConfig) (T, error) {
+	var result T
+	err := GenerateJSON(ctx, chatModel, messages, &result, cfg)
+	return result, err
+}
+
+// GenerateJSON 以JSON模式调用模型，按Schema校验回复并解析到out
+// 校验失败时把错误反馈给模型要求修正，最多调用 MaxAttempts 次
+// 不支持JSON模式的模型会忽略 response_format，仍依靠提示词约束输出
+// JSON模式只能返回对象，顶层为数组等其他类型的Schema包装为 {"result": ...} 后请求，解析时再取出
+func GenerateJSON(ctx context.Context, chatModel einomodel.BaseChatModel, messages []*schema.Message, out interface{}, cfg StructuredConfig) error {
+	if out == nil || reflect.TypeOf(out).Kind() != reflect.Ptr {
+		return fmt.Errorf("输出参数必须是非空指针")
+	}
+
+	outputSchema := cfg.Schema
+	if outputSchema == nil {
+		reflected, err := jsonschema.Reflect(out)
+		if err != nil {
+			return fmt.Errorf("生成JSON Schema失败: %w", err)
+		}
+		outputSchema = reflected
+	}
+
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultStructuredAttempts
+	}
+
+	// 顶层不是对象时包装后请求，解析到信封中再取出结果
+	target := out
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	wrapped := !isObjectSchema(outputSchema)
+	if wrapped {
+		outputSchema = &jsonschema.Schema{
+			Type:       jsonschema.TypeList{"object"},
+			Properties: map[string]*jsonschema.Schema{resultField: outputSchema},
+			Required:   []string{resultField},
+		}
+		target = &envelope
+	}
+
+	opts := append([]einomodel.Option{model.WithResponseFormat(model.ResponseFormatJSON)}, cfg.ModelOptions...)
+	conversation := withSchemaInstruction(messages, outputSchema)
+
+	var lastErr error
+	var raw string
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err := chatModel.Generate(ctx, conversation, opts...)
+		if err != nil {
+			return fmt.Errorf("模型调用失败: %w", err)
+		}
+		raw = result.Content
+
+		lastErr = decodeStructured(raw, outputSchema, target)
+		if lastErr == nil && wrapped {
+			if err := json.Unmarshal(envelope.Result, out); err != nil {
+				lastErr = jsonschema.ValidationErrors{{Path: "$." + resultField, Message: "无法解析为目标类型: " + err.Error()}}
+			}
+		}
+		if lastErr == nil {
+			return nil
+		}
+
+		// 把模型的回复和校验错误加入对话，要求模型修正
+		conversation = append(conversation,
+			schema.AssistantMessage(raw, nil),
+			schema.UserMessage(fmt.Sprintf("你的回复不符合要求:\n%s\n请修正后只输出符合JSON Schema的JSON，不要输出其他内容。", lastErr.Error())),
+		)
+	}
+
+	return &StructuredOutputError{Attempts: maxAttempts, Raw: raw, Err: lastErr}
+}
+
+// isObjectSchema 判断Schema顶层是否只能是对象
+func isObjectSchema(s *jsonschema.Schema) bool {
+	if len(s.Type) == 0 {
+		return len(s.Properties) > 0
+	}
+	return len(s.Type) == 1 && s.Type[0] == "object"
+}
+
+// withSchemaInstruction 在系统提示中加入JSON输出要求，不修改调用方的消息
+func withSchemaInstruction(messages []*schema.Message, outputSchema *jsonschema.Schema) []*schema.Message {
+	instruction := "请只输出一个符合以下JSON Schema的JSON值，不要输出解释或Markdown代码块:\n" + outputSchema.String()
+
+	conversation := make([]*schema.Message, 0, len(messages)+3)
+	if len(messages) > 0 && messages[0].Role == schema.System {
+		system := *messages[0]
+		system.Content = system.Content + "\n\n" + instruction
+		conversation = append(conversation, &system)
+		return append(conversation, messages[1:]...)
+	}
+
+	conversation = append(conversation, schema.SystemMessage(instruction))
+	return append(conversation, messages...)
+}
+
+// decodeStructured 从回复中提取JSON，校验后解析到out
+func decodeStructured(raw string, outputSchema *jsonschema.Schema, out interface{}) error {
+	text := extractJSON(raw)
+	if text == "" {
+		return jsonschema.ValidationErrors{{Path: "$", Message: "回复中没有JSON"}}
+	}
+
+	if _, err := outputSchema.ValidateJSON([]byte(text)); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(text), out); err != nil {
+		return jsonschema.ValidationErrors{{Path: "$", Message: "无法解析为目标类型: " + err.Error()}}
+	}
+	return nil
+}
+
+// extractJSON 去掉Markdown代码块和前后说明文字，取出第一个JSON对象或数组
+func extractJSON(raw string) string {
+	text := strings.TrimSpace(raw)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+	if json.Valid([]byte(text)) {
+		return text
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return ""
+	}
+	closing := "}"
+	if text[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(text, closing)
+	if end <= start {
+		return ""
+	}
+	return text[start : end+1]
+}
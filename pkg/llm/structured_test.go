@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"go-smart/pkg/model"
+)
+
+type orderIntent struct {
+	Intent  string `json:"intent" jsonschema:"enum=query_order,enum=refund"`
+	OrderID string `json:"order_id"`
+}
+
+func TestGenerateJSONRepairsInvalidOutput(t *testing.T) {
+	mock, err := model.NewMockModelWithFixture(&model.MockFixture{
+		Rules: []model.MockRule{{
+			Name:  "intent",
+			Match: model.MockMatch{Contains: "ORD123", Target: model.MatchTargetTranscript},
+			Responses: []model.MockResponse{
+				{Content: "好的，结果如下：```json\n{\"intent\": \"查询\"}\n```"},
+				{Content: `{"intent": "query_order", "order_id": "ORD123"}`},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []*schema.Message{schema.UserMessage("帮我查一下ORD123")}
+	result, err := GenerateTyped[orderIntent](context.Background(), mock, messages, StructuredConfig{})
+	if err != nil {
+		t.Fatalf("GenerateTyped() error: %v", err)
+	}
+	if result.Intent != "query_order" || result.OrderID != "ORD123" {
+		t.Errorf("result = %+v", result)
+	}
+	if len(messages) != 1 {
+		t.Errorf("caller messages modified: %d", len(messages))
+	}
+}
+
+func TestGenerateJSONGivesUpAfterMaxAttempts(t *testing.T) {
+	mock, err := model.NewMockModelWithFixture(&model.MockFixture{DefaultResponse: "我不会输出JSON"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out map[string]interface{}
+	err = GenerateJSON(context.Background(), mock, []*schema.Message{schema.UserMessage("你好")}, &out, StructuredConfig{MaxAttempts: 2})
+	var structuredErr *StructuredOutputError
+	if !errors.Is(err, ErrStructuredOutput) || !errors.As(err, &structuredErr) || structuredErr.Attempts != 2 {
+		t.Errorf("error = %v, want StructuredOutputError after 2 attempts", err)
+	}
+}
+
+func TestGenerateJSONWrapsArraySchema(t *testing.T) {
+	// 数组无法以JSON模式返回，要求模型放在 result 字段中
+	mock, err := model.NewMockModelWithFixture(&model.MockFixture{
+		Rules: []model.MockRule{{
+			Name:      "wrapped",
+			Match:     model.MockMatch{Contains: `"result"`, Target: model.MatchTargetTranscript},
+			Responses: []model.MockResponse{{Content: `{"result": [{"intent": "refund", "order_id": "ORD1"}, {"intent": "query_order", "order_id": "ORD2"}]}`}},
+		}},
+		DefaultResponse: `[{"intent": "refund", "order_id": "ORD1"}]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := GenerateTyped[[]orderIntent](context.Background(), mock, []*schema.Message{schema.UserMessage("ORD1退款，再查一下ORD2")}, StructuredConfig{})
+	if err != nil {
+		t.Fatalf("GenerateTyped() error: %v", err)
+	}
+	if len(result) != 2 || result[0].Intent != "refund" || result[1].OrderID != "ORD2" {
+		t.Errorf("result = %+v", result)
+	}
+}
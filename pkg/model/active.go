@@ -0,0 +1,51 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"go-smart/pkg/trace"
+)
+
+// ActiveModel 当前模型代理，每次调用时从模型管理器解析模型
+// 编译后的链路持有该代理，模型热切换、请求指定档位和租户默认档位都能立即生效
+// 上下文中的请求级生成参数会作为调用选项传给模型
+type ActiveModel struct {
+	manager *ModelManager
+}
+
+// BindTools 绑定工具
+func (a *ActiveModel) BindTools(tools []*schema.ToolInfo) error {
+	return a.manager.BindTools(tools)
+}
+
+// Generate 使用当前模型生成回复，开启追踪时记录发送的消息和回复
+func (a *ActiveModel) Generate(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	current, err := a.manager.ModelFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	result, err := current.Generate(ctx, messages, CallOptions(ctx, opts...)...)
+	trace.FromContext(ctx).Model(ProfileFromContext(ctx), messages, result, start, err)
+	return result, err
+}
+
+// Stream 使用当前模型流式生成回复
+func (a *ActiveModel) Stream(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	current, err := a.manager.ModelFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	stream, err := current.Stream(ctx, messages, CallOptions(ctx, opts...)...)
+	trace.FromContext(ctx).Model(ProfileFromContext(ctx), messages, nil, start, err)
+	return stream, err
+}
+
+// GetType 获取模型类型
+func (a *ActiveModel) GetType() string {
+	return a.manager.GetProvider()
+}
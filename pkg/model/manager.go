@@ -1,18 +1,21 @@
 package model
 
 import (
-	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"sync"
 
 	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
 	"github.com/cloudwego/eino-ext/components/model/openai"
 	"go-smart/internal/config"
 	"go-smart/internal/logger"
+	"go-smart/pkg/usage"
 )
 
-// ModelManager 模型管理器
+// ModelManager 模型管理器，是进程内当前模型的唯一来源
+// 各条处理链路通过 ChatModel 获取代理模型，每次调用时解析当前模型，热切换后无需重建链路
 type ModelManager struct {
 	currentModel     model.BaseChatModel
 	currentModelName string
@@ -21,20 +24,32 @@ type ModelManager struct {
 	mu               sync.RWMutex
 	logger           *logger.Logger
 	config           *config.Config
+	usageTracker     *usage.Tracker
+	httpClient       *http.Client
+	tools            []*schema.ToolInfo
+	profiles         map[string]*profileEntry
+	tenantProfiles   map[string]string
 }
 
 // NewModelManager 创建模型管理器
-func NewModelManager(cfg *config.Config, log *logger.Logger) *ModelManager {
+// httpClient 用于HTTP模型提供商的请求，为空时使用默认客户端
+func NewModelManager(cfg *config.Config, log *logger.Logger, usageTracker *usage.Tracker, httpClient *http.Client) *ModelManager {
 	mm := &ModelManager{
 		currentModelName: cfg.AI.OpenAI.Model,
 		currentAPIKey:    cfg.AI.OpenAI.APIKey,
 		currentAPIBase:   cfg.AI.OpenAI.BaseURL,
 		logger:           log,
 		config:           cfg,
+		usageTracker:     usageTracker,
+		httpClient:       httpClient,
 	}
 	
-	// 初始化模型
-	if err := mm.initModel(); err != nil {
+	// 初始化模型和档位
+	mm.mu.Lock()
+	err := mm.initModel()
+	mm.loadProfiles()
+	mm.mu.Unlock()
+	if err != nil {
 		log.Error("初始化模型失败", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -43,11 +58,8 @@ func NewModelManager(cfg *config.Config, log *logger.Logger) *ModelManager {
 	return mm
 }
 
-// initModel 初始化模型
+// initModel 初始化模型，调用方需持有写锁
 func (mm *ModelManager) initModel() error {
-	mm.mu.Lock()
-	defer mm.mu.Unlock()
-	
 	var err error
 	var modelInstance model.BaseChatModel
 	
@@ -55,6 +67,8 @@ func (mm *ModelManager) initModel() error {
 	switch mm.config.AI.Provider {
 	case "openai":
 		modelInstance, err = mm.createOpenAIModel()
+	case "spark":
+		modelInstance, err = mm.createSparkModel()
 	case "mock":
 		modelInstance, err = mm.createMockModel()
 	default:
@@ -67,30 +81,72 @@ func (mm *ModelManager) initModel() error {
 		return fmt.Errorf("创建模型失败: %w", err)
 	}
 	
-	mm.currentModel = modelInstance
+	// 重新绑定之前绑定过的工具
+	if len(mm.tools) > 0 {
+		if chatModel, ok := modelInstance.(model.ChatModel); ok {
+			if err := chatModel.BindTools(mm.tools); err != nil {
+				return fmt.Errorf("绑定工具失败: %w", err)
+			}
+		}
+	}
+	
+	// 包装计量器以记录token用量
+	mm.currentModel = usage.NewMeteredModel(modelInstance, mm.activeModelName(), mm.usageTracker, mm.config.AI.MaxPromptTokens)
 	mm.logger.Info("模型初始化成功", map[string]interface{}{
 		"provider": mm.config.AI.Provider,
-		"model":    mm.currentModelName,
+		"model":    mm.activeModelName(),
 	})
 	
 	return nil
 }
 
+// activeModelName 获取当前提供商下的模型名称，用于用量统计
+func (mm *ModelManager) activeModelName() string {
+	switch mm.config.AI.Provider {
+	case "spark":
+		return mm.config.AI.Spark.Domain
+	case "mock":
+		return "mock-model"
+	default:
+		return mm.currentModelName
+	}
+}
+
 // createOpenAIModel 创建OpenAI模型
 func (mm *ModelManager) createOpenAIModel() (model.BaseChatModel, error) {
 	cfg := &openai.ChatModelConfig{
-		Model:   mm.currentModelName,
-		APIKey:  mm.currentAPIKey,
-		BaseURL: mm.currentAPIBase,
+		Model:      mm.currentModelName,
+		APIKey:     mm.currentAPIKey,
+		BaseURL:    mm.currentAPIBase,
+		HTTPClient: mm.httpClient,
 	}
 	
-	return openai.NewChatModel(context.Background(), cfg)
+	return newOpenAIChatModel(cfg, openAIGeneration(mm.config.AI.OpenAI))
+}
+
+// createSparkModel 创建讯飞星火模型
+func (mm *ModelManager) createSparkModel() (model.BaseChatModel, error) {
+	sparkCfg := mm.config.AI.Spark
+	return NewSparkModel(SparkModelConfig{
+		AppID:       sparkCfg.AppID,
+		APIKey:      sparkCfg.APIKey,
+		APISecret:   sparkCfg.APISecret,
+		HostURL:     sparkCfg.HostURL,
+		Domain:      sparkCfg.Domain,
+		Temperature: sparkCfg.Temperature,
+		MaxTokens:   sparkCfg.MaxTokens,
+		TopK:        sparkCfg.TopK,
+	})
 }
 
 // createMockModel 创建Mock模型
 func (mm *ModelManager) createMockModel() (model.BaseChatModel, error) {
-	// 这里应该实现一个Mock模型，暂时返回错误
-	return nil, fmt.Errorf("Mock模型暂未实现")
+	mockCfg := mm.config.AI.Mock
+	return NewMockModel(MockModelConfig{
+		FixturesFile:    mockCfg.FixturesFile,
+		ResponseDelay:   mockCfg.ResponseDelay,
+		DefaultResponse: mockCfg.DefaultResponse,
+	})
 }
 
 // GetCurrentModel 获取当前模型
@@ -100,6 +156,39 @@ func (mm *ModelManager) GetCurrentModel() model.BaseChatModel {
 	return mm.currentModel
 }
 
+// ChatModel 获取代理模型，每次调用时按上下文解析档位或当前模型
+func (mm *ModelManager) ChatModel() *ActiveModel {
+	return &ActiveModel{manager: mm}
+}
+
+// BindTools 绑定工具，模型切换后会自动绑定到新模型
+func (mm *ModelManager) BindTools(tools []*schema.ToolInfo) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	
+	mm.tools = tools
+	if chatModel, ok := mm.currentModel.(model.ChatModel); ok {
+		if err := chatModel.BindTools(tools); err != nil {
+			return err
+		}
+	}
+	for _, entry := range mm.profiles {
+		if chatModel, ok := entry.model.(model.ChatModel); ok {
+			if err := chatModel.BindTools(tools); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetProvider 获取当前模型提供商
+func (mm *ModelManager) GetProvider() string {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	return mm.config.AI.Provider
+}
+
 // GetCurrentModelInfo 获取当前模型信息
 func (mm *ModelManager) GetCurrentModelInfo() map[string]string {
 	mm.mu.RLock()
@@ -107,7 +196,7 @@ func (mm *ModelManager) GetCurrentModelInfo() map[string]string {
 	
 	return map[string]string{
 		"provider":   mm.config.AI.Provider,
-		"model_name": mm.currentModelName,
+		"model_name": mm.activeModelName(),
 		"api_base":   mm.currentAPIBase,
 		"status":     "active",
 	}
@@ -123,22 +212,39 @@ func (mm *ModelManager) UpdateModel(provider, modelName, apiKey, apiBase string)
 	oldModelName := mm.currentModelName
 	oldAPIKey := mm.currentAPIKey
 	oldAPIBase := mm.currentAPIBase
+	oldOpenAI := mm.config.AI.OpenAI
+	oldSpark := mm.config.AI.Spark
 	
-	// 更新配置
+	// 更新配置，模型名称、密钥和地址写入所选提供商的配置
 	if provider != "" {
 		mm.config.AI.Provider = provider
 	}
-	if modelName != "" {
-		mm.currentModelName = modelName
-		mm.config.AI.OpenAI.Model = modelName
-	}
-	if apiKey != "" {
-		mm.currentAPIKey = apiKey
-		mm.config.AI.OpenAI.APIKey = apiKey
-	}
-	if apiBase != "" {
-		mm.currentAPIBase = apiBase
-		mm.config.AI.OpenAI.BaseURL = apiBase
+	switch mm.config.AI.Provider {
+	case "spark":
+		if modelName != "" {
+			mm.config.AI.Spark.Domain = modelName
+		}
+		if apiKey != "" {
+			mm.config.AI.Spark.APIKey = apiKey
+		}
+		if apiBase != "" {
+			mm.config.AI.Spark.HostURL = apiBase
+		}
+	case "mock":
+		// Mock模型按回复规则文件回复，没有模型名称和密钥
+	default:
+		if modelName != "" {
+			mm.currentModelName = modelName
+			mm.config.AI.OpenAI.Model = modelName
+		}
+		if apiKey != "" {
+			mm.currentAPIKey = apiKey
+			mm.config.AI.OpenAI.APIKey = apiKey
+		}
+		if apiBase != "" {
+			mm.currentAPIBase = apiBase
+			mm.config.AI.OpenAI.BaseURL = apiBase
+		}
 	}
 	
 	// 初始化新模型
@@ -146,6 +252,8 @@ func (mm *ModelManager) UpdateModel(provider, modelName, apiKey, apiBase string)
 	if err != nil {
 		// 回滚到旧配置
 		mm.config.AI.Provider = oldProvider
+		mm.config.AI.OpenAI = oldOpenAI
+		mm.config.AI.Spark = oldSpark
 		mm.currentModelName = oldModelName
 		mm.currentAPIKey = oldAPIKey
 		mm.currentAPIBase = oldAPIBase
@@ -159,7 +267,7 @@ func (mm *ModelManager) UpdateModel(provider, modelName, apiKey, apiBase string)
 	
 	mm.logger.Info("模型更新成功", map[string]interface{}{
 		"provider":   mm.config.AI.Provider,
-		"model_name": mm.currentModelName,
+		"model_name": mm.activeModelName(),
 	})
 	
 	return nil
@@ -202,7 +310,7 @@ func (mm *ModelManager) ReloadFromEnv() error {
 
 // GetAvailableProviders 获取可用的模型提供商列表
 func (mm *ModelManager) GetAvailableProviders() []string {
-	return []string{"openai", "mock"}
+	return []string{"openai", "spark", "mock"}
 }
 
 // GetAvailableModels 获取指定提供商的可用模型列表
@@ -210,6 +318,8 @@ func (mm *ModelManager) GetAvailableModels(provider string) []string {
 	switch provider {
 	case "openai":
 		return []string{"gpt-3.5-turbo", "gpt-4", "gpt-4-turbo"}
+	case "spark":
+		return []string{"lite", "generalv3", "pro-128k", "generalv3.5", "max-32k", "4.0Ultra"}
 	case "mock":
 		return []string{"mock-model"}
 	default:
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+	"go-smart/pkg/usage"
+)
+
+func TestActiveModelFollowsHotSwap(t *testing.T) {
+	var mu sync.Mutex
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requested = append(requested, body.Model)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"1","object":"chat.completion","model":"`+body.Model+`","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AI: config.AIConfig{
+		Provider: "openai",
+		OpenAI:   config.OpenAIConfig{APIKey: "sk-test", BaseURL: server.URL, Model: "model-a"},
+	}}
+	manager := NewModelManager(cfg, log, nil, nil)
+
+	// 链路在切换前就持有代理，切换后无需重建
+	proxy := manager.ChatModel()
+	messages := []*schema.Message{schema.UserMessage("你好")}
+	if _, err := proxy.Generate(context.Background(), messages); err != nil {
+		t.Fatalf("Generate() before swap error: %v", err)
+	}
+
+	if err := manager.UpdateModel("", "model-b", "", ""); err != nil {
+		t.Fatalf("UpdateModel() error: %v", err)
+	}
+	if _, err := proxy.Generate(context.Background(), messages); err != nil {
+		t.Fatalf("Generate() after swap error: %v", err)
+	}
+
+	if len(requested) != 2 || requested[0] != "model-a" || requested[1] != "model-b" {
+		t.Errorf("requested models = %v, want [model-a model-b]", requested)
+	}
+	if got := manager.GetCurrentModelInfo()["model_name"]; got != "model-b" {
+		t.Errorf("current model = %q, want model-b", got)
+	}
+}
+
+func TestUpdateModelWritesProviderConfig(t *testing.T) {
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AI: config.AIConfig{
+		Provider: "openai",
+		OpenAI:   config.OpenAIConfig{APIKey: "sk-test", Model: "gpt-3.5-turbo"},
+		Spark:    config.SparkConfig{AppID: "app", APIKey: "key", APISecret: "secret", Domain: "generalv3"},
+	}}
+	manager := NewModelManager(cfg, log, nil, nil)
+
+	// 切换到星火时模型名称写入星火的 domain，OpenAI配置不变
+	if err := manager.UpdateModel("spark", "4.0Ultra", "", "wss://spark.example.com/v4.0/chat"); err != nil {
+		t.Fatalf("UpdateModel(spark) error: %v", err)
+	}
+	if got := manager.GetCurrentModelInfo()["model_name"]; got != "4.0Ultra" {
+		t.Errorf("current model = %q, want 4.0Ultra", got)
+	}
+	if cfg.AI.Spark.HostURL != "wss://spark.example.com/v4.0/chat" || cfg.AI.OpenAI.Model != "gpt-3.5-turbo" {
+		t.Errorf("spark = %+v, openai = %+v", cfg.AI.Spark, cfg.AI.OpenAI)
+	}
+
+	// 创建失败时星火配置一并回滚
+	cfg.AI.Spark.AppID = ""
+	t.Setenv("SPARK_APP_ID", "")
+	if err := manager.UpdateModel("spark", "lite", "", ""); err == nil {
+		t.Fatal("UpdateModel() without credentials error = nil")
+	}
+	if cfg.AI.Spark.Domain != "4.0Ultra" {
+		t.Errorf("spark domain after rollback = %q, want 4.0Ultra", cfg.AI.Spark.Domain)
+	}
+}
+
+func TestModelForResolvesProfiles(t *testing.T) {
+	requested := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requested <- body.Model
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{AI: config.AIConfig{
+		Provider: "mock",
+		OpenAI:   config.OpenAIConfig{APIKey: "sk-test", BaseURL: server.URL, Model: "model-default"},
+		Mock:     config.MockConfig{DefaultResponse: "本地回复"},
+		Profiles: []config.ModelProfile{
+			{Name: "fast", Provider: "openai", Model: "model-fast"},
+			{Name: "local", Provider: "mock"},
+		},
+		TenantProfiles: []config.TenantProfile{{TenantID: "acme", Profile: "fast"}},
+	}}
+	manager := NewModelManager(cfg, log, nil, nil)
+	proxy := manager.ChatModel()
+	messages := []*schema.Message{schema.UserMessage("你好")}
+
+	// 租户默认档位
+	tenantCtx := usage.WithScope(context.Background(), usage.Scope{TenantID: "acme"})
+	if _, err := proxy.Generate(tenantCtx, messages); err != nil {
+		t.Fatalf("Generate() with tenant default error: %v", err)
+	}
+	if got := <-requested; got != "model-fast" {
+		t.Errorf("tenant default model = %q, want model-fast", got)
+	}
+
+	// 请求指定的档位优先于租户默认档位
+	result, err := proxy.Generate(WithProfile(tenantCtx, "local"), messages)
+	if err != nil || result.Content != "本地回复" {
+		t.Errorf("Generate() with profile = %v, %v", result, err)
+	}
+
+	if _, err := proxy.Generate(WithProfile(context.Background(), "missing"), messages); !errors.Is(err, ErrProfileNotFound) {
+		t.Errorf("unknown profile error = %v, want ErrProfileNotFound", err)
+	}
+	if err := manager.DeleteProfile("fast"); err == nil {
+		t.Error("DeleteProfile() of tenant default should fail")
+	}
+}
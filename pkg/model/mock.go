@@ -0,0 +1,354 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"go-smart/pkg/usage"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrMockInjected 由fixture规则注入的模拟错误
+var ErrMockInjected = errors.New("mock model injected error")
+
+// 规则匹配的对象
+const (
+	MatchTargetLastUser   = "last_user"  // 最后一条用户消息（默认）
+	MatchTargetTranscript = "transcript" // 全部对话记录，每条消息为一行 "role: content"
+)
+
+// defaultMockResponse 未命中任何规则且未配置默认回复时的回复
+const defaultMockResponse = "感谢您的咨询，我会尽力为您提供帮助。"
+
+// MockModelConfig 模拟模型配置
+type MockModelConfig struct {
+	FixturesFile    string // fixture文件路径，支持YAML和JSON，为空时使用内置规则
+	ResponseDelay   string // 每次回复前的模拟延迟，如 500ms
+	DefaultResponse string // 未命中任何规则时的回复
+}
+
+// MockFixture 模拟模型的fixture定义
+type MockFixture struct {
+	DefaultResponse string        `yaml:"default_response" json:"default_response"`
+	ResponseDelay   time.Duration `yaml:"response_delay" json:"response_delay"`
+	Rules           []MockRule    `yaml:"rules" json:"rules"`
+}
+
+// MockRule 匹配规则，按定义顺序匹配，第一条命中的规则生效
+type MockRule struct {
+	Name      string         `yaml:"name" json:"name"`
+	Match     MockMatch      `yaml:"match" json:"match"`
+	Responses []MockResponse `yaml:"responses" json:"responses"`
+	Cycle     bool           `yaml:"cycle" json:"cycle"` // 回复序列用完后是否从头循环，默认重复最后一条
+}
+
+// MockMatch 匹配条件，同时配置时需全部满足
+type MockMatch struct {
+	Contains string `yaml:"contains" json:"contains"`
+	Regex    string `yaml:"regex" json:"regex"`
+	Target   string `yaml:"target" json:"target"` // last_user 或 transcript
+}
+
+// MockResponse 单次回复
+type MockResponse struct {
+	Content   string         `yaml:"content" json:"content"`
+	ToolCalls []MockToolCall `yaml:"tool_calls" json:"tool_calls"`
+	Error     string         `yaml:"error" json:"error"`
+	Delay     time.Duration  `yaml:"delay" json:"delay"` // 覆盖全局延迟
+}
+
+// MockToolCall 注入的工具调用
+type MockToolCall struct {
+	Name      string      `yaml:"name" json:"name"`
+	Arguments interface{} `yaml:"arguments" json:"arguments"` // 对象或JSON字符串
+}
+
+// mockRule 编译后的规则及其序列状态
+type mockRule struct {
+	MockRule
+	pattern *regexp.Regexp
+	calls   int
+}
+
+// MockModel 用于测试的模拟模型，按fixture规则生成回复
+type MockModel struct {
+	mu              sync.Mutex
+	rules           []*mockRule
+	defaultResponse string
+	delay           time.Duration
+	toolCallSeq     int
+}
+
+// NewMockModel 创建模拟模型实例
+func NewMockModel(config MockModelConfig) (*MockModel, error) {
+	fixture := builtinMockFixture()
+	if config.FixturesFile != "" {
+		loaded, err := LoadMockFixture(config.FixturesFile)
+		if err != nil {
+			return nil, err
+		}
+		fixture = loaded
+	}
+
+	// 配置项优先于fixture文件中的全局设置
+	if config.ResponseDelay != "" {
+		delay, err := time.ParseDuration(config.ResponseDelay)
+		if err != nil {
+			return nil, fmt.Errorf("解析模拟延迟失败: %w", err)
+		}
+		fixture.ResponseDelay = delay
+	}
+	if config.DefaultResponse != "" {
+		fixture.DefaultResponse = config.DefaultResponse
+	}
+
+	return NewMockModelWithFixture(fixture)
+}
+
+// NewMockModelWithFixture 根据fixture创建模拟模型实例
+func NewMockModelWithFixture(fixture *MockFixture) (*MockModel, error) {
+	m := &MockModel{
+		defaultResponse: fixture.DefaultResponse,
+		delay:           fixture.ResponseDelay,
+	}
+	if m.defaultResponse == "" {
+		m.defaultResponse = defaultMockResponse
+	}
+
+	for i, rule := range fixture.Rules {
+		if len(rule.Responses) == 0 {
+			return nil, fmt.Errorf("规则 %d(%s) 没有配置回复", i, rule.Name)
+		}
+		switch rule.Match.Target {
+		case "":
+			rule.Match.Target = MatchTargetLastUser
+		case MatchTargetLastUser, MatchTargetTranscript:
+		default:
+			return nil, fmt.Errorf("规则 %d(%s) 的匹配对象无效: %s", i, rule.Name, rule.Match.Target)
+		}
+
+		compiled := &mockRule{MockRule: rule}
+		if rule.Match.Regex != "" {
+			pattern, err := regexp.Compile(rule.Match.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("规则 %d(%s) 的正则表达式无效: %w", i, rule.Name, err)
+			}
+			compiled.pattern = pattern
+		}
+		m.rules = append(m.rules, compiled)
+	}
+
+	return m, nil
+}
+
+// LoadMockFixture 从YAML或JSON文件加载fixture
+func LoadMockFixture(path string) (*MockFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取fixture文件失败: %w", err)
+	}
+
+	// JSON是YAML的子集，统一按YAML解析
+	fixture := &MockFixture{}
+	if err := yaml.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("解析fixture文件失败: %w", err)
+	}
+	return fixture, nil
+}
+
+// builtinMockFixture 未配置fixture文件时使用的内置规则
+func builtinMockFixture() *MockFixture {
+	return &MockFixture{
+		Rules: []MockRule{
+			{Name: "yesterday_order", Match: MockMatch{Regex: "^我昨天下的单$"}, Responses: []MockResponse{{Content: "您昨天下的订单已经发货，预计明天送达。订单号：ORD20240114001。"}}},
+			{Name: "query_order", Match: MockMatch{Regex: "^查订单$"}, Responses: []MockResponse{{Content: "请提供您的订单号，我将为您查询订单状态。"}}},
+			{Name: "refund", Match: MockMatch{Regex: "^退款$"}, Responses: []MockResponse{{Content: "请提供您需要退款的订单号，我将为您处理退款申请。"}}},
+		},
+	}
+}
+
+// BindTools 绑定工具（模拟模型按fixture返回工具调用，不需要绑定）
+func (m *MockModel) BindTools(tools []*schema.ToolInfo) error {
+	return nil
+}
+
+// Generate 生成回复
+func (m *MockModel) Generate(ctx context.Context, messages []*schema.Message, options ...model.Option) (*schema.Message, error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("没有提供消息")
+	}
+
+	response, ruleName := m.next(messages)
+
+	delay := m.delay
+	if response.Delay > 0 {
+		delay = response.Delay
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("%w: %s(规则 %s)", ErrMockInjected, response.Error, ruleName)
+	}
+
+	toolCalls, err := m.buildToolCalls(response.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.AssistantMessage(response.Content, toolCalls), nil
+}
+
+// Stream 流式生成回复，整条回复作为单个分片返回
+func (m *MockModel) Stream(ctx context.Context, messages []*schema.Message, options ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	result, err := m.Generate(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{result}), nil
+}
+
+// GetType 获取模型类型
+func (m *MockModel) GetType() string {
+	return "mock"
+}
+
+// GetTokenCount 获取 token 数量（本地估算）
+func (m *MockModel) GetTokenCount(ctx context.Context, messages []*schema.Message) (int, error) {
+	return usage.EstimateMessagesTokens(messages), nil
+}
+
+// next 查找命中的规则并推进其回复序列
+func (m *MockModel) next(messages []*schema.Message) (MockResponse, string) {
+	lastUser := lastUserContent(messages)
+	transcript := ""
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rule := range m.rules {
+		text := lastUser
+		if rule.Match.Target == MatchTargetTranscript {
+			if transcript == "" {
+				transcript = buildTranscript(messages)
+			}
+			text = transcript
+		}
+		if !rule.matches(text) {
+			continue
+		}
+
+		index := rule.calls
+		if index >= len(rule.Responses) {
+			if rule.Cycle {
+				index %= len(rule.Responses)
+			} else {
+				index = len(rule.Responses) - 1
+			}
+		}
+		rule.calls++
+		return rule.Responses[index], rule.Name
+	}
+
+	return MockResponse{Content: m.defaultResponse}, "default"
+}
+
+// buildToolCalls 将fixture中的工具调用转换为模型输出格式
+func (m *MockModel) buildToolCalls(calls []MockToolCall) ([]schema.ToolCall, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	toolCalls := make([]schema.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		arguments, err := mockArguments(call.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("序列化工具 %s 的参数失败: %w", call.Name, err)
+		}
+		m.toolCallSeq++
+		toolCalls = append(toolCalls, schema.ToolCall{
+			ID:   fmt.Sprintf("call_mock_%d", m.toolCallSeq),
+			Type: "function",
+			Function: schema.FunctionCall{
+				Name:      call.Name,
+				Arguments: arguments,
+			},
+		})
+	}
+	return toolCalls, nil
+}
+
+// matches 判断文本是否满足规则的匹配条件
+func (r *mockRule) matches(text string) bool {
+	if r.Match.Contains != "" && !strings.Contains(text, r.Match.Contains) {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(text) {
+		return false
+	}
+	return true
+}
+
+// mockArguments 将工具参数转换为JSON字符串
+func mockArguments(arguments interface{}) (string, error) {
+	switch value := arguments.(type) {
+	case nil:
+		return "{}", nil
+	case string:
+		return value, nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
+
+// lastUserContent 获取最后一条用户消息的内容
+func lastUserContent(messages []*schema.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.User {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// buildTranscript 将对话记录拼接为多行文本
+func buildTranscript(messages []*schema.Message) string {
+	var builder strings.Builder
+	for _, msg := range messages {
+		builder.WriteString(string(msg.Role))
+		builder.WriteString(": ")
+		builder.WriteString(msg.Content)
+		for _, call := range msg.ToolCalls {
+			builder.WriteString(" [tool_call ")
+			builder.WriteString(call.Function.Name)
+			builder.WriteString(" ")
+			builder.WriteString(call.Function.Arguments)
+			builder.WriteString("]")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
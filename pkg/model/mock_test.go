@@ -0,0 +1,89 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestMockModelFixtureRules(t *testing.T) {
+	fixture := `
+default_response: "默认回复"
+rules:
+  - name: lookup
+    match:
+      regex: "ORD\\d+"
+    responses:
+      - tool_calls:
+          - name: query_order
+            arguments: {order_id: "ORD123456"}
+      - content: "订单已发货"
+  - name: followup
+    match:
+      target: transcript
+      contains: "assistant: 请提供订单号"
+    responses:
+      - content: "收到订单号"
+  - name: broken
+    match:
+      contains: "故障"
+    responses:
+      - error: "upstream timeout"
+`
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock, err := NewMockModel(MockModelConfig{FixturesFile: path})
+	if err != nil {
+		t.Fatalf("NewMockModel() error: %v", err)
+	}
+	ctx := context.Background()
+
+	// 序列回复：先返回工具调用，再返回文本，之后重复最后一条
+	query := []*schema.Message{schema.UserMessage("查一下ORD123456")}
+	first, err := mock.Generate(ctx, query)
+	if err != nil || len(first.ToolCalls) != 1 || first.ToolCalls[0].Function.Arguments != `{"order_id":"ORD123456"}` {
+		t.Fatalf("first response = %+v, %v", first, err)
+	}
+	for i := 0; i < 2; i++ {
+		if next, _ := mock.Generate(ctx, query); next.Content != "订单已发货" {
+			t.Errorf("response %d = %q", i+2, next.Content)
+		}
+	}
+
+	transcript := []*schema.Message{
+		schema.UserMessage("查订单"),
+		schema.AssistantMessage("请提供订单号", nil),
+		schema.UserMessage("好的"),
+	}
+	if got, _ := mock.Generate(ctx, transcript); got.Content != "收到订单号" {
+		t.Errorf("transcript response = %q", got.Content)
+	}
+
+	if _, err := mock.Generate(ctx, []*schema.Message{schema.UserMessage("模拟故障")}); !errors.Is(err, ErrMockInjected) {
+		t.Errorf("error = %v, want ErrMockInjected", err)
+	}
+
+	if got, _ := mock.Generate(ctx, []*schema.Message{schema.UserMessage("你好")}); got.Content != "默认回复" {
+		t.Errorf("default response = %q", got.Content)
+	}
+}
+
+func TestMockModelResponseDelayHonoursContext(t *testing.T) {
+	mock, err := NewMockModel(MockModelConfig{ResponseDelay: "1h"})
+	if err != nil {
+		t.Fatalf("NewMockModel() error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := mock.Generate(ctx, []*schema.Message{schema.UserMessage("查订单")}); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"go-smart/internal/config"
+)
+
+const (
+	// ResponseFormatText 普通文本回复
+	ResponseFormatText = "text"
+	// ResponseFormatJSON 要求模型返回JSON对象
+	ResponseFormatJSON = "json_object"
+)
+
+// GenerationOptions 生成参数，为空的字段表示沿用上一层的设置
+// 生效顺序为：提供商配置 < 档位 < 请求 < 单次调用选项
+type GenerationOptions struct {
+	Temperature    *float64 `json:"temperature,omitempty"`
+	MaxTokens      *int     `json:"max_tokens,omitempty"`
+	TopP           *float64 `json:"top_p,omitempty"`
+	Stop           []string `json:"stop,omitempty"`
+	Seed           *int     `json:"seed,omitempty"`
+	ResponseFormat string   `json:"response_format,omitempty"` // text, json_object
+}
+
+// Validate 校验生成参数的取值范围
+func (o GenerationOptions) Validate() error {
+	if o.Temperature != nil && (*o.Temperature < 0 || *o.Temperature > 2) {
+		return fmt.Errorf("temperature 必须在0到2之间")
+	}
+	if o.TopP != nil && (*o.TopP < 0 || *o.TopP > 1) {
+		return fmt.Errorf("top_p 必须在0到1之间")
+	}
+	if o.MaxTokens != nil && *o.MaxTokens < 0 {
+		return fmt.Errorf("max_tokens 不能为负数")
+	}
+	switch o.ResponseFormat {
+	case "", ResponseFormatText, ResponseFormatJSON:
+	default:
+		return fmt.Errorf("不支持的 response_format: %s", o.ResponseFormat)
+	}
+	return nil
+}
+
+// Merge 用override中已设置的字段覆盖当前参数
+func (o GenerationOptions) Merge(override GenerationOptions) GenerationOptions {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.MaxTokens != nil {
+		o.MaxTokens = override.MaxTokens
+	}
+	if override.TopP != nil {
+		o.TopP = override.TopP
+	}
+	if override.Stop != nil {
+		o.Stop = override.Stop
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	if override.ResponseFormat != "" {
+		o.ResponseFormat = override.ResponseFormat
+	}
+	return o
+}
+
+// ModelOptions 转换为模型调用选项，只包含已设置的字段
+func (o GenerationOptions) ModelOptions() []model.Option {
+	opts := make([]model.Option, 0, 6)
+	if o.Temperature != nil {
+		opts = append(opts, model.WithTemperature(float32(*o.Temperature)))
+	}
+	if o.MaxTokens != nil {
+		opts = append(opts, model.WithMaxTokens(*o.MaxTokens))
+	}
+	if o.TopP != nil {
+		opts = append(opts, model.WithTopP(float32(*o.TopP)))
+	}
+	if o.Stop != nil {
+		opts = append(opts, model.WithStop(o.Stop))
+	}
+	if o.Seed != nil {
+		opts = append(opts, WithSeed(*o.Seed))
+	}
+	if o.ResponseFormat != "" {
+		opts = append(opts, WithResponseFormat(o.ResponseFormat))
+	}
+	return opts
+}
+
+// extraOptions eino通用选项未覆盖的生成参数，由各提供商适配器自行解释
+type extraOptions struct {
+	Seed           *int
+	ResponseFormat string
+}
+
+// WithSeed 设置采样种子，相同种子和输入尽量得到相同输出
+func WithSeed(seed int) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *extraOptions) {
+		o.Seed = &seed
+	})
+}
+
+// WithResponseFormat 设置回复格式，如 json_object
+func WithResponseFormat(format string) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *extraOptions) {
+		o.ResponseFormat = format
+	})
+}
+
+// getExtraOptions 获取调用选项中的扩展生成参数
+func getExtraOptions(opts ...model.Option) *extraOptions {
+	return model.GetImplSpecificOptions(&extraOptions{}, opts...)
+}
+
+// generationKey 上下文中请求级生成参数的键
+type generationKey struct{}
+
+// WithGenerationOptions 将请求级生成参数写入上下文
+func WithGenerationOptions(ctx context.Context, options GenerationOptions) context.Context {
+	return context.WithValue(ctx, generationKey{}, options)
+}
+
+// GenerationOptionsFromContext 从上下文获取请求级生成参数
+func GenerationOptionsFromContext(ctx context.Context) GenerationOptions {
+	options, _ := ctx.Value(generationKey{}).(GenerationOptions)
+	return options
+}
+
+// CallOptions 合并请求级生成参数和单次调用选项，单次调用选项优先
+func CallOptions(ctx context.Context, opts ...model.Option) []model.Option {
+	requestOptions := GenerationOptionsFromContext(ctx).ModelOptions()
+	if len(requestOptions) == 0 {
+		return opts
+	}
+	return append(requestOptions, opts...)
+}
+
+// openAIGeneration 从OpenAI提供商配置获取默认生成参数
+func openAIGeneration(cfg config.OpenAIConfig) GenerationOptions {
+	options := GenerationOptions{
+		Temperature:    cfg.Temperature,
+		TopP:           cfg.TopP,
+		Stop:           cfg.Stop,
+		Seed:           cfg.Seed,
+		ResponseFormat: cfg.ResponseFormat,
+	}
+	if cfg.MaxTokens > 0 {
+		maxTokens := cfg.MaxTokens
+		options.MaxTokens = &maxTokens
+	}
+	return options
+}
+
+// profileGeneration 获取档位设置的生成参数
+func profileGeneration(profile config.ModelProfile) GenerationOptions {
+	options := GenerationOptions{
+		Temperature:    profile.Temperature,
+		TopP:           profile.TopP,
+		Stop:           profile.Stop,
+		Seed:           profile.Seed,
+		ResponseFormat: profile.ResponseFormat,
+	}
+	if profile.MaxTokens > 0 {
+		maxTokens := profile.MaxTokens
+		options.MaxTokens = &maxTokens
+	}
+	return options
+}
+
+// newOpenAIChatModel 按生成参数创建OpenAI模型
+func newOpenAIChatModel(cfg *openai.ChatModelConfig, options GenerationOptions) (model.BaseChatModel, error) {
+	if options.Temperature != nil {
+		temperature := float32(*options.Temperature)
+		cfg.Temperature = &temperature
+	}
+	if options.TopP != nil {
+		topP := float32(*options.TopP)
+		cfg.TopP = &topP
+	}
+	cfg.MaxTokens = options.MaxTokens
+	cfg.Stop = options.Stop
+	cfg.Seed = options.Seed
+	switch options.ResponseFormat {
+	case ResponseFormatJSON:
+		cfg.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	case ResponseFormatText:
+		cfg.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeText}
+	}
+
+	chatModel, err := openai.NewChatModel(context.Background(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &openAIChatModel{ChatModel: chatModel}, nil
+}
+
+// openAIChatModel 将扩展生成参数转换为OpenAI请求字段
+type openAIChatModel struct {
+	*openai.ChatModel
+}
+
+// Generate 生成回复
+func (m *openAIChatModel) Generate(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.ChatModel.Generate(ctx, messages, m.translate(opts)...)
+}
+
+// Stream 流式生成回复
+func (m *openAIChatModel) Stream(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return m.ChatModel.Stream(ctx, messages, m.translate(opts)...)
+}
+
+// translate 将 seed 和 response_format 作为额外请求字段传给OpenAI
+func (m *openAIChatModel) translate(opts []model.Option) []model.Option {
+	extra := getExtraOptions(opts...)
+	fields := make(map[string]any, 2)
+	if extra.Seed != nil {
+		fields["seed"] = *extra.Seed
+	}
+	if extra.ResponseFormat != "" {
+		fields["response_format"] = map[string]string{"type": extra.ResponseFormat}
+	}
+	if len(fields) == 0 {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], openai.WithExtraFields(fields))
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"go-smart/internal/config"
+	"go-smart/internal/logger"
+)
+
+func TestGenerationOptionsReachOpenAIRequest(t *testing.T) {
+	bodies := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies <- body
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	temperature := 0.7
+	cfg := &config.Config{AI: config.AIConfig{
+		Provider: "openai",
+		OpenAI: config.OpenAIConfig{
+			APIKey:      "sk-test",
+			BaseURL:     server.URL,
+			Model:       "model-a",
+			MaxTokens:   1000,
+			Temperature: &temperature,
+			Stop:        []string{"END"},
+		},
+	}}
+	proxy := NewModelManager(cfg, log, nil, nil).ChatModel()
+	messages := []*schema.Message{schema.UserMessage("你好")}
+
+	// 只有配置中的参数
+	if _, err := proxy.Generate(context.Background(), messages); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	body := <-bodies
+	if body["max_tokens"] != float64(1000) || body["temperature"] != 0.7 {
+		t.Errorf("config params not sent: max_tokens=%v temperature=%v", body["max_tokens"], body["temperature"])
+	}
+
+	// 请求级参数覆盖配置，单次调用选项覆盖请求级参数
+	requestTopP := 0.5
+	requestMaxTokens := 200
+	ctx := WithGenerationOptions(context.Background(), GenerationOptions{
+		TopP:           &requestTopP,
+		MaxTokens:      &requestMaxTokens,
+		ResponseFormat: ResponseFormatText,
+	})
+	if _, err := proxy.Generate(ctx, messages, model.WithMaxTokens(50), WithSeed(7), WithResponseFormat(ResponseFormatJSON)); err != nil {
+		t.Fatalf("Generate() with options error: %v", err)
+	}
+	body = <-bodies
+	if body["top_p"] != 0.5 || body["max_tokens"] != float64(50) || body["seed"] != float64(7) {
+		t.Errorf("override params = top_p:%v max_tokens:%v seed:%v", body["top_p"], body["max_tokens"], body["seed"])
+	}
+	if format, _ := body["response_format"].(map[string]interface{}); format["type"] != ResponseFormatJSON {
+		t.Errorf("response_format = %v, want json_object", body["response_format"])
+	}
+	if stop, _ := body["stop"].([]interface{}); len(stop) != 1 || stop[0] != "END" {
+		t.Errorf("stop = %v, want [END]", body["stop"])
+	}
+}
+
+func TestGenerationOptionsValidate(t *testing.T) {
+	tooHot := 3.0
+	if err := (GenerationOptions{Temperature: &tooHot}).Validate(); err == nil {
+		t.Error("Validate() should reject temperature above 2")
+	}
+	if err := (GenerationOptions{ResponseFormat: "xml"}).Validate(); err == nil {
+		t.Error("Validate() should reject unknown response_format")
+	}
+}
@@ -0,0 +1,323 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/components/model"
+	"go-smart/internal/config"
+	"go-smart/pkg/usage"
+)
+
+// ErrProfileNotFound 模型档位不存在
+var ErrProfileNotFound = errors.New("model profile not found")
+
+// profileKey 上下文中请求指定档位的键
+type profileKey struct{}
+
+// WithProfile 将请求指定的模型档位写入上下文
+func WithProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, profileKey{}, name)
+}
+
+// ProfileFromContext 从上下文获取请求指定的模型档位
+func ProfileFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(profileKey{}).(string)
+	return name
+}
+
+// ProfileInfo 模型档位信息，不包含密钥
+type ProfileInfo struct {
+	Name       string            `json:"name"`
+	Provider   string            `json:"provider"`
+	Model      string            `json:"model"`
+	Generation GenerationOptions `json:"generation"`
+	BaseURL    string            `json:"base_url,omitempty"`
+}
+
+// profileEntry 档位配置及其已创建的模型
+type profileEntry struct {
+	profile config.ModelProfile
+	model   model.BaseChatModel
+}
+
+// loadProfiles 从配置加载档位和租户默认档位，调用方需持有写锁
+// 档位的模型在首次使用时创建，避免未配置密钥的档位影响启动
+func (mm *ModelManager) loadProfiles() {
+	mm.profiles = make(map[string]*profileEntry, len(mm.config.AI.Profiles))
+	for _, profile := range mm.config.AI.Profiles {
+		if profile.Name == "" {
+			mm.logger.Warn("忽略未命名的模型档位", nil)
+			continue
+		}
+		mm.profiles[profile.Name] = &profileEntry{profile: profile}
+	}
+
+	mm.tenantProfiles = make(map[string]string, len(mm.config.AI.TenantProfiles))
+	for _, tenant := range mm.config.AI.TenantProfiles {
+		if _, exists := mm.profiles[tenant.Profile]; !exists {
+			mm.logger.Warn("租户默认档位不存在，已忽略", map[string]interface{}{
+				"tenant_id": tenant.TenantID,
+				"profile":   tenant.Profile,
+			})
+			continue
+		}
+		mm.tenantProfiles[tenant.TenantID] = tenant.Profile
+	}
+}
+
+// ModelFor 解析本次调用使用的模型
+// 优先使用请求指定的档位，其次是租户默认档位，都没有时使用当前模型
+func (mm *ModelManager) ModelFor(ctx context.Context) (model.BaseChatModel, error) {
+	name := ProfileFromContext(ctx)
+	if name == "" {
+		mm.mu.RLock()
+		name = mm.tenantProfiles[usage.ScopeFromContext(ctx).TenantID]
+		mm.mu.RUnlock()
+	}
+	if name == "" {
+		current := mm.GetCurrentModel()
+		if current == nil {
+			return nil, fmt.Errorf("模型未初始化")
+		}
+		return current, nil
+	}
+
+	return mm.profileModel(name)
+}
+
+// HasProfile 判断档位是否存在
+func (mm *ModelManager) HasProfile(name string) bool {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	_, exists := mm.profiles[name]
+	return exists
+}
+
+// ListProfiles 获取所有档位
+func (mm *ModelManager) ListProfiles() []ProfileInfo {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	profiles := make([]ProfileInfo, 0, len(mm.profiles))
+	for _, entry := range mm.profiles {
+		profile := entry.profile
+		profiles = append(profiles, ProfileInfo{
+			Name:       profile.Name,
+			Provider:   profile.Provider,
+			Model:      mm.profileModelName(profile),
+			Generation: profileGeneration(profile),
+			BaseURL:    profile.BaseURL,
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// SaveProfile 新增或更新档位
+// 会立即创建模型以校验配置，只替换该档位的模型，不影响其他档位和当前模型
+func (mm *ModelManager) SaveProfile(profile config.ModelProfile) error {
+	if profile.Name == "" {
+		return fmt.Errorf("档位名称不能为空")
+	}
+
+	if err := profileGeneration(profile).Validate(); err != nil {
+		return err
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	// 更新时未提供密钥则沿用原档位的密钥
+	if existing, exists := mm.profiles[profile.Name]; exists && profile.APIKey == "" {
+		profile.APIKey = existing.profile.APIKey
+	}
+
+	chatModel, err := mm.buildProfileModel(profile)
+	if err != nil {
+		return fmt.Errorf("创建档位 %s 的模型失败: %w", profile.Name, err)
+	}
+	mm.profiles[profile.Name] = &profileEntry{profile: profile, model: chatModel}
+
+	mm.logger.Info("模型档位已保存", map[string]interface{}{
+		"profile":  profile.Name,
+		"provider": profile.Provider,
+		"model":    mm.profileModelName(profile),
+	})
+	return nil
+}
+
+// DeleteProfile 删除档位，被租户设为默认档位时不允许删除
+func (mm *ModelManager) DeleteProfile(name string) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if _, exists := mm.profiles[name]; !exists {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	for tenantID, profile := range mm.tenantProfiles {
+		if profile == name {
+			return fmt.Errorf("档位 %s 是租户 %s 的默认档位，请先修改租户默认档位", name, tenantID)
+		}
+	}
+
+	delete(mm.profiles, name)
+	mm.logger.Info("模型档位已删除", map[string]interface{}{
+		"profile": name,
+	})
+	return nil
+}
+
+// TenantProfiles 获取租户默认档位
+func (mm *ModelManager) TenantProfiles() map[string]string {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	tenants := make(map[string]string, len(mm.tenantProfiles))
+	for tenantID, profile := range mm.tenantProfiles {
+		tenants[tenantID] = profile
+	}
+	return tenants
+}
+
+// SetTenantProfile 设置租户默认档位，profile为空时清除
+func (mm *ModelManager) SetTenantProfile(tenantID, profile string) error {
+	if tenantID == "" {
+		return fmt.Errorf("租户ID不能为空")
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if profile == "" {
+		delete(mm.tenantProfiles, tenantID)
+		return nil
+	}
+	if _, exists := mm.profiles[profile]; !exists {
+		return fmt.Errorf("%w: %s", ErrProfileNotFound, profile)
+	}
+	mm.tenantProfiles[tenantID] = profile
+
+	mm.logger.Info("租户默认档位已设置", map[string]interface{}{
+		"tenant_id": tenantID,
+		"profile":   profile,
+	})
+	return nil
+}
+
+// profileModel 获取档位的模型，首次使用时创建
+func (mm *ModelManager) profileModel(name string) (model.BaseChatModel, error) {
+	mm.mu.RLock()
+	entry, exists := mm.profiles[name]
+	var chatModel model.BaseChatModel
+	if exists {
+		chatModel = entry.model
+	}
+	mm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	if chatModel != nil {
+		return chatModel, nil
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	// 重新获取，档位可能已被其他请求创建或被修改
+	entry, exists = mm.profiles[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	if entry.model == nil {
+		chatModel, err := mm.buildProfileModel(entry.profile)
+		if err != nil {
+			return nil, fmt.Errorf("创建档位 %s 的模型失败: %w", name, err)
+		}
+		entry.model = chatModel
+	}
+	return entry.model, nil
+}
+
+// buildProfileModel 根据档位创建模型，调用方需持有锁
+// 档位未设置的字段沿用对应提供商的全局配置
+func (mm *ModelManager) buildProfileModel(profile config.ModelProfile) (model.BaseChatModel, error) {
+	var modelInstance model.BaseChatModel
+	var err error
+
+	switch profile.Provider {
+	case "openai":
+		openaiCfg := mm.config.AI.OpenAI
+		cfg := &openai.ChatModelConfig{
+			Model:      mm.profileModelName(profile),
+			APIKey:     firstNonEmpty(profile.APIKey, openaiCfg.APIKey),
+			BaseURL:    firstNonEmpty(profile.BaseURL, openaiCfg.BaseURL),
+			HTTPClient: mm.httpClient,
+		}
+		modelInstance, err = newOpenAIChatModel(cfg, openAIGeneration(openaiCfg).Merge(profileGeneration(profile)))
+	case "spark":
+		sparkCfg := mm.config.AI.Spark
+		sparkModelCfg := SparkModelConfig{
+			AppID:       sparkCfg.AppID,
+			APIKey:      firstNonEmpty(profile.APIKey, sparkCfg.APIKey),
+			APISecret:   sparkCfg.APISecret,
+			HostURL:     firstNonEmpty(profile.BaseURL, sparkCfg.HostURL),
+			Domain:      mm.profileModelName(profile),
+			Temperature: sparkCfg.Temperature,
+			MaxTokens:   sparkCfg.MaxTokens,
+			TopK:        sparkCfg.TopK,
+		}
+		if profile.Temperature != nil {
+			sparkModelCfg.Temperature = *profile.Temperature
+		}
+		if profile.MaxTokens > 0 {
+			sparkModelCfg.MaxTokens = profile.MaxTokens
+		}
+		modelInstance, err = NewSparkModel(sparkModelCfg)
+	case "mock":
+		modelInstance, err = mm.createMockModel()
+	default:
+		return nil, fmt.Errorf("不支持的模型提供商: %s", profile.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(mm.tools) > 0 {
+		if chatModel, ok := modelInstance.(model.ChatModel); ok {
+			if err := chatModel.BindTools(mm.tools); err != nil {
+				return nil, fmt.Errorf("绑定工具失败: %w", err)
+			}
+		}
+	}
+
+	return usage.NewMeteredModel(modelInstance, mm.profileModelName(profile), mm.usageTracker, mm.config.AI.MaxPromptTokens), nil
+}
+
+// profileModelName 获取档位实际使用的模型名称
+func (mm *ModelManager) profileModelName(profile config.ModelProfile) string {
+	switch profile.Provider {
+	case "spark":
+		return firstNonEmpty(profile.Model, mm.config.AI.Spark.Domain)
+	case "mock":
+		return "mock-model"
+	default:
+		return firstNonEmpty(profile.Model, mm.config.AI.OpenAI.Model)
+	}
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,488 @@
+package model
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gorilla/websocket"
+	"go-smart/pkg/usage"
+)
+
+const (
+	// defaultSparkURL 讯飞星火默认接口地址（Spark 3.5 Max）
+	defaultSparkURL = "wss://spark-api.xf-yun.com/v3.5/chat"
+	// defaultSparkDomain 与默认接口地址对应的domain参数
+	defaultSparkDomain = "generalv3.5"
+)
+
+// sparkPaths 各domain对应的接口路径，星火的domain必须与接口版本一致，
+// 通过 model.WithModel 切换模型（如超出配额后降级）时按domain改用同一主机上的对应接口
+var sparkPaths = map[string]string{
+	"lite":        "/v1.1/chat",
+	"generalv3":   "/v3.1/chat",
+	"pro-128k":    "/chat/pro-128k",
+	"generalv3.5": "/v3.5/chat",
+	"max-32k":     "/chat/max-32k",
+	"4.0Ultra":    "/v4.0/chat",
+}
+
+// SparkModelConfig 讯飞星火模型配置
+type SparkModelConfig struct {
+	AppID       string
+	APIKey      string
+	APISecret   string
+	HostURL     string // WebSocket接口地址，如 wss://spark-api.xf-yun.com/v3.5/chat
+	Domain      string // 与接口版本对应的domain，如 generalv3.5
+	Temperature float64
+	MaxTokens   int
+	TopK        int
+}
+
+// SparkModel 讯飞星火模型适配器
+type SparkModel struct {
+	appID       string
+	apiKey      string
+	apiSecret   string
+	hostURL     string
+	domain      string
+	temperature float64
+	maxTokens   int
+	topK        int
+	dialer      *websocket.Dialer
+
+	mu    sync.RWMutex
+	tools []*schema.ToolInfo
+}
+
+// NewSparkModel 创建讯飞星火模型实例
+func NewSparkModel(config SparkModelConfig) (model.BaseChatModel, error) {
+	if config.AppID == "" {
+		config.AppID = os.Getenv("SPARK_APP_ID")
+	}
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("SPARK_API_KEY")
+	}
+	if config.APISecret == "" {
+		config.APISecret = os.Getenv("SPARK_API_SECRET")
+	}
+	if config.AppID == "" || config.APIKey == "" || config.APISecret == "" {
+		return nil, fmt.Errorf("未设置讯飞星火鉴权信息，请设置环境变量 SPARK_APP_ID、SPARK_API_KEY、SPARK_API_SECRET 或在配置中提供")
+	}
+
+	if config.HostURL == "" {
+		config.HostURL = defaultSparkURL
+	}
+	if config.Domain == "" {
+		config.Domain = defaultSparkDomain
+	}
+
+	return &SparkModel{
+		appID:       config.AppID,
+		apiKey:      config.APIKey,
+		apiSecret:   config.APISecret,
+		hostURL:     config.HostURL,
+		domain:      config.Domain,
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+		topK:        config.TopK,
+		dialer:      &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+	}, nil
+}
+
+// SparkRequest 星火 WebSocket 请求结构
+type SparkRequest struct {
+	Header    SparkRequestHeader    `json:"header"`
+	Parameter SparkRequestParameter `json:"parameter"`
+	Payload   SparkRequestPayload   `json:"payload"`
+}
+
+// SparkRequestHeader 请求头部
+type SparkRequestHeader struct {
+	AppID string `json:"app_id"`
+	UID   string `json:"uid,omitempty"`
+}
+
+// SparkRequestParameter 请求参数
+type SparkRequestParameter struct {
+	Chat SparkChatParameter `json:"chat"`
+}
+
+// SparkChatParameter 对话参数
+type SparkChatParameter struct {
+	Domain      string  `json:"domain"`
+	Temperature float64 `json:"temperature,omitempty"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+	TopK        int     `json:"top_k,omitempty"`
+}
+
+// SparkRequestPayload 请求负载
+type SparkRequestPayload struct {
+	Message   SparkMessageList    `json:"message"`
+	Functions *SparkFunctionsList `json:"functions,omitempty"`
+}
+
+// SparkMessageList 消息列表
+type SparkMessageList struct {
+	Text []SparkMessage `json:"text"`
+}
+
+// SparkMessage 星火消息结构
+type SparkMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// SparkFunctionsList 函数列表
+type SparkFunctionsList struct {
+	Text []SparkFunction `json:"text"`
+}
+
+// SparkFunction 函数定义
+type SparkFunction struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  interface{} `json:"parameters"`
+}
+
+// SparkResponse 星火 WebSocket 响应帧
+type SparkResponse struct {
+	Header struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		SID     string `json:"sid"`
+		Status  int    `json:"status"`
+	} `json:"header"`
+	Payload struct {
+		Choices struct {
+			Status int `json:"status"`
+			Seq    int `json:"seq"`
+			Text   []struct {
+				Content      string `json:"content"`
+				Role         string `json:"role"`
+				Index        int    `json:"index"`
+				FunctionCall *struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function_call,omitempty"`
+			} `json:"text"`
+		} `json:"choices"`
+		Usage *struct {
+			Text struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+				TotalTokens      int `json:"total_tokens"`
+			} `json:"text"`
+		} `json:"usage,omitempty"`
+	} `json:"payload"`
+}
+
+// BindTools 绑定工具，星火通过 functions 字段支持函数调用
+func (m *SparkModel) BindTools(tools []*schema.ToolInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tools = tools
+	return nil
+}
+
+// Generate 生成回复
+func (m *SparkModel) Generate(ctx context.Context, messages []*schema.Message, options ...model.Option) (*schema.Message, error) {
+	stream, err := m.Stream(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	chunks := make([]*schema.Message, 0)
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("没有收到响应")
+	}
+
+	return schema.ConcatMessages(chunks)
+}
+
+// Stream 流式生成回复
+func (m *SparkModel) Stream(ctx context.Context, messages []*schema.Message, options ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("没有提供消息")
+	}
+
+	request, err := m.buildRequest(messages, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	hostURL, err := m.endpoint(request.Parameter.Chat.Domain)
+	if err != nil {
+		return nil, err
+	}
+	authURL, err := m.buildAuthURL(hostURL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, resp, err := m.dialer.DialContext(ctx, authURL, nil)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("连接星火服务失败: %w, 状态码: %d, 响应: %s", err, resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("连接星火服务失败: %w", err)
+	}
+
+	if err := conn.WriteJSON(request); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+
+	reader, writer := schema.Pipe[*schema.Message](8)
+	go m.receive(ctx, conn, writer)
+
+	return reader, nil
+}
+
+// receive 读取响应帧并写入流
+func (m *SparkModel) receive(ctx context.Context, conn *websocket.Conn, writer *schema.StreamWriter[*schema.Message]) {
+	defer writer.Close()
+	defer conn.Close()
+
+	// 上下文取消时关闭连接以中断阻塞的读取
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	toolCallIndex := 0
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			writer.Send(nil, fmt.Errorf("读取响应失败: %w", err))
+			return
+		}
+
+		var frame SparkResponse
+		if err := json.Unmarshal(data, &frame); err != nil {
+			writer.Send(nil, fmt.Errorf("解析响应失败: %w", err))
+			return
+		}
+
+		if frame.Header.Code != 0 {
+			writer.Send(nil, fmt.Errorf("API 错误: %d %s (sid: %s)", frame.Header.Code, frame.Header.Message, frame.Header.SID))
+			return
+		}
+
+		chunk := &schema.Message{Role: schema.Assistant}
+		for _, text := range frame.Payload.Choices.Text {
+			chunk.Content += text.Content
+			if text.FunctionCall != nil {
+				index := toolCallIndex
+				toolCallIndex++
+				chunk.ToolCalls = append(chunk.ToolCalls, schema.ToolCall{
+					Index: &index,
+					ID:    fmt.Sprintf("%s_%d", frame.Header.SID, index),
+					Type:  "function",
+					Function: schema.FunctionCall{
+						Name:      text.FunctionCall.Name,
+						Arguments: text.FunctionCall.Arguments,
+					},
+				})
+			}
+		}
+
+		finished := frame.Header.Status == 2
+		if finished {
+			chunk.ResponseMeta = &schema.ResponseMeta{FinishReason: "stop"}
+			if len(chunk.ToolCalls) > 0 {
+				chunk.ResponseMeta.FinishReason = "tool_calls"
+			}
+			if frame.Payload.Usage != nil {
+				chunk.ResponseMeta.Usage = &schema.TokenUsage{
+					PromptTokens:     frame.Payload.Usage.Text.PromptTokens,
+					CompletionTokens: frame.Payload.Usage.Text.CompletionTokens,
+					TotalTokens:      frame.Payload.Usage.Text.TotalTokens,
+				}
+			}
+		}
+
+		if closed := writer.Send(chunk, nil); closed {
+			return
+		}
+
+		if finished {
+			return
+		}
+	}
+}
+
+// buildRequest 构建星火请求
+func (m *SparkModel) buildRequest(messages []*schema.Message, options ...model.Option) (*SparkRequest, error) {
+	m.mu.RLock()
+	tools := m.tools
+	m.mu.RUnlock()
+
+	commonOptions := model.GetCommonOptions(&model.Options{Tools: tools}, options...)
+
+	sparkMessages := make([]SparkMessage, 0, len(messages))
+	for _, msg := range messages {
+		switch msg.Role {
+		case schema.System:
+			sparkMessages = append(sparkMessages, SparkMessage{Role: "system", Content: msg.Content})
+		case schema.Assistant:
+			content := msg.Content
+			// 星火历史消息中不支持携带function_call，使用文本描述代替
+			if content == "" && len(msg.ToolCalls) > 0 {
+				calls := make([]string, 0, len(msg.ToolCalls))
+				for _, call := range msg.ToolCalls {
+					calls = append(calls, fmt.Sprintf("%s(%s)", call.Function.Name, call.Function.Arguments))
+				}
+				content = "调用工具: " + strings.Join(calls, "; ")
+			}
+			sparkMessages = append(sparkMessages, SparkMessage{Role: "assistant", Content: content})
+		case schema.Tool:
+			// 星火没有tool角色，工具结果以用户消息回传
+			sparkMessages = append(sparkMessages, SparkMessage{
+				Role:    "user",
+				Content: fmt.Sprintf("工具 %s 的执行结果: %s", msg.ToolName, msg.Content),
+			})
+		default:
+			sparkMessages = append(sparkMessages, SparkMessage{Role: "user", Content: msg.Content})
+		}
+	}
+
+	request := &SparkRequest{
+		Header: SparkRequestHeader{AppID: m.appID},
+		Parameter: SparkRequestParameter{
+			Chat: SparkChatParameter{
+				Domain:      m.domain,
+				Temperature: m.temperature,
+				MaxTokens:   m.maxTokens,
+				TopK:        m.topK,
+			},
+		},
+		Payload: SparkRequestPayload{
+			Message: SparkMessageList{Text: sparkMessages},
+		},
+	}
+
+	if commonOptions.Model != nil && *commonOptions.Model != "" {
+		request.Parameter.Chat.Domain = *commonOptions.Model
+	}
+	if commonOptions.Temperature != nil {
+		request.Parameter.Chat.Temperature = float64(*commonOptions.Temperature)
+	}
+	if commonOptions.MaxTokens != nil {
+		request.Parameter.Chat.MaxTokens = *commonOptions.MaxTokens
+	}
+	// 星火接口不支持 top_p、stop、seed 和 response_format，这些选项被忽略
+
+	if len(commonOptions.Tools) > 0 {
+		functions := make([]SparkFunction, 0, len(commonOptions.Tools))
+		for _, tool := range commonOptions.Tools {
+			var parameters interface{} = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+			if tool.ParamsOneOf != nil {
+				jsonSchema, err := tool.ParamsOneOf.ToJSONSchema()
+				if err != nil {
+					return nil, fmt.Errorf("转换工具参数失败: %w", err)
+				}
+				if jsonSchema != nil {
+					parameters = jsonSchema
+				}
+			}
+			functions = append(functions, SparkFunction{
+				Name:        tool.Name,
+				Description: tool.Desc,
+				Parameters:  parameters,
+			})
+		}
+		request.Payload.Functions = &SparkFunctionsList{Text: functions}
+	}
+
+	return request, nil
+}
+
+// endpoint 获取domain对应的接口地址，与配置的domain相同时使用配置的地址
+func (m *SparkModel) endpoint(domain string) (string, error) {
+	if domain == m.domain {
+		return m.hostURL, nil
+	}
+	path, ok := sparkPaths[domain]
+	if !ok {
+		return "", fmt.Errorf("星火不支持切换到模型 %s", domain)
+	}
+	u, err := url.Parse(m.hostURL)
+	if err != nil {
+		return "", fmt.Errorf("解析星火接口地址失败: %w", err)
+	}
+	u.Path = path
+	return u.String(), nil
+}
+
+// buildAuthURL 按讯飞鉴权规则为接口地址生成带HMAC签名的WebSocket地址
+func (m *SparkModel) buildAuthURL(hostURL string, now time.Time) (string, error) {
+	u, err := url.Parse(hostURL)
+	if err != nil {
+		return "", fmt.Errorf("解析星火接口地址失败: %w", err)
+	}
+
+	date := now.UTC().Format(http.TimeFormat)
+	signatureOrigin := fmt.Sprintf("host: %s\ndate: %s\nGET %s HTTP/1.1", u.Host, date, u.Path)
+
+	mac := hmac.New(sha256.New, []byte(m.apiSecret))
+	mac.Write([]byte(signatureOrigin))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	authorizationOrigin := fmt.Sprintf(`api_key="%s", algorithm="hmac-sha256", headers="host date request-line", signature="%s"`,
+		m.apiKey, signature)
+	authorization := base64.StdEncoding.EncodeToString([]byte(authorizationOrigin))
+
+	query := url.Values{}
+	query.Set("authorization", authorization)
+	query.Set("date", date)
+	query.Set("host", u.Host)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// GetType 获取模型类型
+func (m *SparkModel) GetType() string {
+	return "spark"
+}
+
+// GetTokenCount 获取 token 数量（本地估算，用于请求前的预算检查）
+func (m *SparkModel) GetTokenCount(ctx context.Context, messages []*schema.Message) (int, error) {
+	return usage.EstimateMessagesTokens(messages), nil
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gorilla/websocket"
+)
+
+func TestSparkModelGenerate(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("date") == "" || query.Get("host") == "" {
+			t.Errorf("missing date or host in auth url: %s", r.URL.RawQuery)
+		}
+		authorization, err := base64.StdEncoding.DecodeString(query.Get("authorization"))
+		if err != nil || !strings.Contains(string(authorization), `api_key="test-key"`) {
+			t.Errorf("unexpected authorization: %s", authorization)
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var request SparkRequest
+		if err := conn.ReadJSON(&request); err != nil {
+			t.Errorf("read request failed: %v", err)
+			return
+		}
+		if request.Header.AppID != "test-app" || request.Parameter.Chat.Domain != "generalv3.5" {
+			t.Errorf("unexpected request header: %+v", request)
+		}
+		if request.Payload.Functions == nil || len(request.Payload.Functions.Text) != 1 {
+			t.Errorf("expected bound tool to be sent as function")
+		}
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"header":{"code":0,"sid":"sid1","status":1},"payload":{"choices":{"status":1,"seq":0,"text":[{"content":"您好，","role":"assistant","index":0}]}}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"header":{"code":0,"sid":"sid1","status":2},"payload":{"choices":{"status":2,"seq":1,"text":[{"content":"","role":"assistant","index":0,"function_call":{"name":"order_query","arguments":"{\"order_id\":\"ORD123456\"}"}}]},"usage":{"text":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}}}`))
+	}))
+	defer server.Close()
+
+	chatModel, err := NewSparkModel(SparkModelConfig{
+		AppID:     "test-app",
+		APIKey:    "test-key",
+		APISecret: "test-secret",
+		HostURL:   "ws" + strings.TrimPrefix(server.URL, "http") + "/v3.5/chat",
+	})
+	if err != nil {
+		t.Fatalf("NewSparkModel() error: %v", err)
+	}
+
+	sparkModel := chatModel.(*SparkModel)
+	sparkModel.BindTools([]*schema.ToolInfo{{
+		Name: "order_query",
+		Desc: "查询订单",
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"order_id": {Type: schema.String, Required: true},
+		}),
+	}})
+
+	result, err := chatModel.Generate(context.Background(), []*schema.Message{schema.UserMessage("查一下ORD123456")})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	if result.Content != "您好，" {
+		t.Errorf("Generate() content = %q", result.Content)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Function.Name != "order_query" {
+		t.Errorf("Generate() tool calls = %+v", result.ToolCalls)
+	}
+	if result.ResponseMeta == nil || result.ResponseMeta.Usage == nil || result.ResponseMeta.Usage.TotalTokens != 20 {
+		t.Errorf("Generate() usage = %+v", result.ResponseMeta)
+	}
+}
+
+func TestSparkModelSwitchesDomain(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	requests := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var request SparkRequest
+		if err := conn.ReadJSON(&request); err != nil {
+			t.Errorf("read request failed: %v", err)
+			return
+		}
+		requests <- r.URL.Path + " " + request.Parameter.Chat.Domain
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"header":{"code":0,"sid":"sid1","status":2},"payload":{"choices":{"status":2,"seq":0,"text":[{"content":"好的","role":"assistant","index":0}]}}}`))
+	}))
+	defer server.Close()
+
+	chatModel, err := NewSparkModel(SparkModelConfig{
+		AppID:     "test-app",
+		APIKey:    "test-key",
+		APISecret: "test-secret",
+		HostURL:   "ws" + strings.TrimPrefix(server.URL, "http") + "/v3.5/chat",
+	})
+	if err != nil {
+		t.Fatalf("NewSparkModel() error: %v", err)
+	}
+	messages := []*schema.Message{schema.UserMessage("你好")}
+
+	// 降级到 lite 时domain和接口版本一起切换
+	if _, err := chatModel.Generate(context.Background(), messages, model.WithModel("lite")); err != nil {
+		t.Fatalf("Generate(lite) error: %v", err)
+	}
+	if got := <-requests; got != "/v1.1/chat lite" {
+		t.Errorf("request = %q, want /v1.1/chat lite", got)
+	}
+
+	if _, err := chatModel.Generate(context.Background(), messages, model.WithModel("gpt-4o-mini")); err == nil {
+		t.Error("Generate(gpt-4o-mini) error = nil, want unsupported model")
+	}
+}
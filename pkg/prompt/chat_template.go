@@ -0,0 +1,41 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+
+	einoprompt "github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ChatTemplate 基于注册表的对话模板，每次格式化时读取生效版本，模板热更新后立即生效
+// 生成系统提示和用户消息两条消息，用户消息取变量 query
+type ChatTemplate struct {
+	registry *Registry
+	name     string
+}
+
+var _ einoprompt.ChatTemplate = (*ChatTemplate)(nil)
+
+// ChatTemplate 创建使用指定系统提示的对话模板
+func (r *Registry) ChatTemplate(name string) *ChatTemplate {
+	return &ChatTemplate{registry: r, name: name}
+}
+
+// Format 渲染系统提示并附加用户消息，上下文中指定了版本时使用该版本
+func (t *ChatTemplate) Format(ctx context.Context, vs map[string]any, opts ...einoprompt.Option) ([]*schema.Message, error) {
+	system, err := t.registry.RenderContext(ctx, t.name, vs)
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := vs["query"].(string)
+	if !ok {
+		return nil, fmt.Errorf("对话模板缺少变量 query")
+	}
+
+	return []*schema.Message{
+		schema.SystemMessage(system),
+		schema.UserMessage(query),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package prompt
+
+import "context"
+
+// versionsKey 上下文中请求指定的提示词版本
+type versionsKey struct{}
+
+// WithVersions 为本次请求指定提示词版本（提示词名称 -> 版本），如A/B实验分组
+// 多次调用时合并，后指定的覆盖先指定的
+func WithVersions(ctx context.Context, versions map[string]string) context.Context {
+	if len(versions) == 0 {
+		return ctx
+	}
+	merged := make(map[string]string, len(versions))
+	for name, version := range versionsFromContext(ctx) {
+		merged[name] = version
+	}
+	for name, version := range versions {
+		merged[name] = version
+	}
+	return context.WithValue(ctx, versionsKey{}, merged)
+}
+
+// VersionFromContext 获取请求为提示词指定的版本，未指定时返回空字符串
+func VersionFromContext(ctx context.Context, name string) string {
+	return versionsFromContext(ctx)[name]
+}
+
+// versionsFromContext 获取请求指定的全部提示词版本
+func versionsFromContext(ctx context.Context) map[string]string {
+	if versions, ok := ctx.Value(versionsKey{}).(map[string]string); ok {
+		return versions
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikolalohinski/gonja"
+	"github.com/nikolalohinski/gonja/exec"
+	"go-smart/internal/logger"
+	"go-smart/pkg/trace"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrPromptNotFound 提示词或版本不存在
+var ErrPromptNotFound = errors.New("prompt not found")
+
+// 目录约定：<dir>/<name>/<version>.j2 为模板，<dir>/manifest.yaml 指定各提示词的生效版本
+const (
+	templateExt  = ".j2"
+	manifestFile = "manifest.yaml"
+)
+
+// Manifest 生效版本清单
+type Manifest struct {
+	Active map[string]string `yaml:"active"` // 提示词名称 -> 版本，未列出时使用最新版本
+}
+
+// Info 提示词信息
+type Info struct {
+	Name          string   `json:"name"`
+	ActiveVersion string   `json:"active_version"`
+	Versions      []string `json:"versions"`
+	Source        string   `json:"source"` // 生效版本的文件路径，内置模板为 builtin
+}
+
+// version 已解析的模板版本
+type version struct {
+	name     string
+	source   string
+	template *exec.Template
+}
+
+// promptSet 同一提示词的全部版本
+type promptSet struct {
+	versions map[string]*version
+	active   string
+}
+
+// Registry 提示词注册表，从目录加载带版本的Jinja模板，目录缺失的提示词使用内置模板
+type Registry struct {
+	dir      string
+	logger   *logger.Logger
+	mu       sync.RWMutex
+	prompts  map[string]*promptSet
+	loadedAt time.Time
+}
+
+// NewRegistry 创建提示词注册表并加载目录中的模板
+// dir 为空或不存在时只使用内置模板
+func NewRegistry(dir string, log *logger.Logger) (*Registry, error) {
+	r := &Registry{dir: dir, logger: log}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Builtin 创建只包含内置模板的注册表，用于测试和未配置提示词目录的场景
+func Builtin() *Registry {
+	r := &Registry{}
+	prompts, err := r.load()
+	if err != nil {
+		panic("内置提示词模板无效: " + err.Error())
+	}
+	r.prompts = prompts
+	return r
+}
+
+// Reload 重新加载目录中的模板，任一模板无效时保留原有模板并返回错误
+func (r *Registry) Reload() error {
+	prompts, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.prompts = prompts
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+
+	if r.logger != nil {
+		r.logger.Info("提示词模板已加载", map[string]interface{}{
+			"dir":     r.dir,
+			"prompts": len(prompts),
+		})
+	}
+	return nil
+}
+
+// Render 使用生效版本渲染提示词
+// 未提供时自动注入 current_date（2006-01-02）和 current_time（2006-01-02 15:04）
+func (r *Registry) Render(name string, vars map[string]interface{}) (string, error) {
+	return r.RenderVersion(name, "", vars)
+}
+
+// RenderVersion 使用指定版本渲染提示词，version 为空时使用生效版本
+func (r *Registry) RenderVersion(name, versionName string, vars map[string]interface{}) (string, error) {
+	r.mu.RLock()
+	set, exists := r.prompts[name]
+	var selected *version
+	if exists {
+		if versionName == "" {
+			versionName = set.active
+		}
+		selected = set.versions[versionName]
+	}
+	r.mu.RUnlock()
+
+	if selected == nil {
+		return "", fmt.Errorf("%w: %s@%s", ErrPromptNotFound, name, versionName)
+	}
+
+	now := time.Now()
+	values := map[string]interface{}{
+		"current_date": now.Format("2006-01-02"),
+		"current_time": now.Format("2006-01-02 15:04"),
+	}
+	for key, value := range vars {
+		values[key] = value
+	}
+
+	text, err := selected.template.Execute(values)
+	if err != nil {
+		return "", fmt.Errorf("渲染提示词 %s@%s 失败: %w", name, versionName, err)
+	}
+	return text, nil
+}
+
+// RenderContext 渲染提示词，优先使用上下文中为本次请求指定的版本
+// 指定的版本不存在时（如热更新后被删除）回退到生效版本并记录警告
+func (r *Registry) RenderContext(ctx context.Context, name string, vars map[string]interface{}) (string, error) {
+	versionName := VersionFromContext(ctx, name)
+	if versionName != "" && !r.HasVersion(name, versionName) {
+		if r.logger != nil {
+			r.logger.Warn("请求指定的提示词版本不存在，使用生效版本", map[string]interface{}{
+				"prompt":  name,
+				"version": versionName,
+			})
+		}
+		versionName = ""
+	}
+	if recorder := trace.FromContext(ctx); recorder != nil {
+		resolved := versionName
+		if resolved == "" {
+			resolved = r.activeVersion(name)
+		}
+		recorder.Prompt(name, resolved)
+	}
+	return r.RenderVersion(name, versionName, vars)
+}
+
+// activeVersion 获取提示词的生效版本，提示词不存在时返回空字符串
+func (r *Registry) activeVersion(name string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if set, exists := r.prompts[name]; exists {
+		return set.active
+	}
+	return ""
+}
+
+// HasVersion 判断提示词的指定版本是否存在
+func (r *Registry) HasVersion(name, versionName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set, exists := r.prompts[name]
+	if !exists {
+		return false
+	}
+	_, exists = set.versions[versionName]
+	return exists
+}
+
+// List 获取所有提示词及其生效版本
+func (r *Registry) List() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]Info, 0, len(r.prompts))
+	for name, set := range r.prompts {
+		versions := make([]string, 0, len(set.versions))
+		for versionName := range set.versions {
+			versions = append(versions, versionName)
+		}
+		sortVersions(versions)
+		infos = append(infos, Info{
+			Name:          name,
+			ActiveVersion: set.active,
+			Versions:      versions,
+			Source:        set.versions[set.active].source,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// LoadedAt 最近一次成功加载的时间
+func (r *Registry) LoadedAt() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loadedAt
+}
+
+// load 加载内置模板和目录中的模板
+func (r *Registry) load() (map[string]*promptSet, error) {
+	prompts := make(map[string]*promptSet)
+
+	if r.dir != "" {
+		if err := r.loadDir(prompts); err != nil {
+			return nil, err
+		}
+	}
+
+	// 目录中没有的提示词使用内置模板
+	for name, text := range builtinTemplates {
+		if _, exists := prompts[name]; exists {
+			continue
+		}
+		tpl, err := parseTemplate(text)
+		if err != nil {
+			return nil, fmt.Errorf("解析内置提示词 %s 失败: %w", name, err)
+		}
+		prompts[name] = &promptSet{
+			versions: map[string]*version{BuiltinVersion: {name: BuiltinVersion, source: BuiltinVersion, template: tpl}},
+			active:   BuiltinVersion,
+		}
+	}
+
+	return prompts, nil
+}
+
+// loadDir 加载目录中的模板和生效版本清单
+func (r *Registry) loadDir(prompts map[string]*promptSet) error {
+	entries, err := os.ReadDir(r.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取提示词目录失败: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		files, err := filepath.Glob(filepath.Join(r.dir, name, "*"+templateExt))
+		if err != nil {
+			return fmt.Errorf("读取提示词 %s 失败: %w", name, err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		set := &promptSet{versions: make(map[string]*version, len(files))}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("读取提示词模板 %s 失败: %w", file, err)
+			}
+			// 与Jinja默认行为一致，去掉文件末尾的一个换行
+			text := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+			tpl, err := parseTemplate(text)
+			if err != nil {
+				return fmt.Errorf("解析提示词模板 %s 失败: %w", file, err)
+			}
+			versionName := strings.TrimSuffix(filepath.Base(file), templateExt)
+			set.versions[versionName] = &version{name: versionName, source: file, template: tpl}
+		}
+		prompts[name] = set
+	}
+
+	manifest, err := r.loadManifest()
+	if err != nil {
+		return err
+	}
+	for name, set := range prompts {
+		if active, pinned := manifest.Active[name]; pinned {
+			if _, exists := set.versions[active]; !exists {
+				return fmt.Errorf("清单中提示词 %s 的版本 %s 不存在", name, active)
+			}
+			set.active = active
+			continue
+		}
+		versions := make([]string, 0, len(set.versions))
+		for versionName := range set.versions {
+			versions = append(versions, versionName)
+		}
+		sortVersions(versions)
+		set.active = versions[len(versions)-1]
+	}
+	for name, active := range manifest.Active {
+		_, exists := prompts[name]
+		_, builtin := builtinTemplates[name]
+		if !exists && !(builtin && active == BuiltinVersion) {
+			return fmt.Errorf("清单中的提示词 %s@%s 不存在", name, active)
+		}
+	}
+
+	return nil
+}
+
+// loadManifest 读取生效版本清单，文件不存在时返回空清单
+func (r *Registry) loadManifest() (*Manifest, error) {
+	manifest := &Manifest{}
+	data, err := os.ReadFile(filepath.Join(r.dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取提示词清单失败: %w", err)
+	}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("解析提示词清单失败: %w", err)
+	}
+	return manifest, nil
+}
+
+// parseTemplate 解析Jinja模板
+func parseTemplate(text string) (*exec.Template, error) {
+	// gonja 遇到未闭合的 {{ 或 {% 时不会返回，需提前检查
+	if err := checkDelimiters(text); err != nil {
+		return nil, err
+	}
+	return gonja.FromString(text)
+}
+
+// checkDelimiters 检查变量和语句标签是否都已闭合
+func checkDelimiters(text string) error {
+	pairs := [][2]string{{"{{", "}}"}, {"{%", "%}"}, {"{#", "#}"}}
+	for _, pair := range pairs {
+		rest := text
+		for {
+			start := strings.Index(rest, pair[0])
+			if start < 0 {
+				break
+			}
+			rest = rest[start+len(pair[0]):]
+			end := strings.Index(rest, pair[1])
+			if end < 0 {
+				return fmt.Errorf("标签 %s 未闭合", pair[0])
+			}
+			rest = rest[end+len(pair[1]):]
+		}
+	}
+	return nil
+}
+
+// sortVersions 按自然顺序排序版本名，如 v2 排在 v10 之前
+func sortVersions(versions []string) {
+	sort.Slice(versions, func(i, j int) bool {
+		return versionLess(versions[i], versions[j])
+	})
+}
+
+// versionLess 比较版本名，数字部分按数值比较
+func versionLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits != "" && bDigits != "" {
+			aNum, _ := strconv.Atoi(aDigits)
+			bNum, _ := strconv.Atoi(bDigits)
+			if aNum != bNum {
+				return aNum < bNum
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits 获取字符串开头的数字
+func leadingDigits(s string) string {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return s[:end]
+}
@@ -0,0 +1,146 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/tools"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryVersionsAndManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, GeneralChat, "v1.j2"), "你好，{{ name }}\n")
+	writeFile(t, filepath.Join(dir, GeneralChat, "v2.j2"), "v2 {{ name }}\n")
+	writeFile(t, filepath.Join(dir, GeneralChat, "v10.j2"), "v10 {{ name }}\n")
+
+	registry, err := NewRegistry(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未指定版本时使用自然排序的最新版本
+	text, err := registry.Render(GeneralChat, map[string]interface{}{"name": "张三"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "v10 张三" {
+		t.Fatalf("rendered %q, want latest version v10", text)
+	}
+
+	writeFile(t, filepath.Join(dir, manifestFile), "active:\n  general_chat: v1\n")
+	if err := registry.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	text, err = registry.Render(GeneralChat, map[string]interface{}{"name": "张三"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "你好，张三" {
+		t.Fatalf("rendered %q, want pinned version v1", text)
+	}
+
+	if _, err := registry.RenderVersion(GeneralChat, "v3", nil); !errors.Is(err, ErrPromptNotFound) {
+		t.Fatalf("unknown version error = %v, want ErrPromptNotFound", err)
+	}
+
+	// 目录中没有的提示词回退到内置模板
+	text, err = registry.Render(CustomerService, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "{{") || !strings.Contains(text, "当前时间是 2") {
+		t.Fatalf("builtin prompt not rendered with current_date: %q", text)
+	}
+	for _, info := range registry.List() {
+		if info.Name == CustomerService && info.ActiveVersion != BuiltinVersion {
+			t.Fatalf("customer_service active = %q, want builtin", info.ActiveVersion)
+		}
+		if info.Name == GeneralChat && strings.Join(info.Versions, ",") != "v1,v2,v10" {
+			t.Fatalf("general_chat versions = %v", info.Versions)
+		}
+	}
+}
+
+func TestRegistryReloadKeepsTemplatesOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, OrderAssistant, "v1.j2")
+	writeFile(t, path, "订单助手")
+
+	registry, err := NewRegistry(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未闭合的标签会让模板解析失败，重新加载应报错并保留原模板
+	writeFile(t, path, "订单助手 {{ current_date")
+	if err := registry.Reload(); err == nil {
+		t.Fatal("expected reload error for unterminated tag")
+	}
+	text, err := registry.Render(OrderAssistant, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "订单助手" {
+		t.Fatalf("rendered %q after failed reload, want previous template", text)
+	}
+}
+
+func TestBuiltinWorkflowPlannerListsTools(t *testing.T) {
+	text, err := Builtin().Render(WorkflowPlanner, map[string]interface{}{
+		"tools": []map[string]interface{}{
+			{"name": "a_tool", "description": "A"},
+			{"name": "b_tool", "description": "B"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "可用工具:\n- a_tool: A\n- b_tool: B\n\n使用工具的规则") {
+		t.Fatalf("unexpected tool list:\n%s", text)
+	}
+}
+
+func TestPromptsNameRegisteredTools(t *testing.T) {
+	registered := tools.NewToolManager(business.NewMockServices()).GetAllTools()
+	toolList := []map[string]interface{}{}
+	for name, tool := range registered {
+		toolList = append(toolList, map[string]interface{}{"name": name, "description": tool.GetDescription()})
+	}
+	disk, err := NewRegistry("../../prompts", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 提示词中的工具名都是小写加下划线的形式，渲染后的每个这种名称都必须是已注册的工具
+	toolName := regexp.MustCompile(`[a-z]+(?:_[a-z]+)+`)
+	for source, registry := range map[string]*Registry{"builtin": Builtin(), "prompts": disk} {
+		for _, info := range registry.List() {
+			for _, version := range info.Versions {
+				text, err := registry.RenderVersion(info.Name, version, map[string]interface{}{"tools": toolList})
+				if err != nil {
+					t.Fatalf("%s %s/%s: %v", source, info.Name, version, err)
+				}
+				for _, name := range toolName.FindAllString(text, -1) {
+					if _, ok := registered[name]; !ok {
+						t.Errorf("%s %s/%s names unregistered tool %s", source, info.Name, version, name)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce 文件变化后等待的时间，编辑器保存时通常会连续触发多个事件
+const reloadDebounce = 200 * time.Millisecond
+
+// Watch 监听提示词目录，文件变化时重新加载，直到ctx结束
+// 新模板无效时保留原有模板并记录错误
+func (r *Registry) Watch(ctx context.Context) error {
+	if r.dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(r.dir); err != nil {
+		return fmt.Errorf("提示词目录不可用: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听器失败: %w", err)
+	}
+	if err := r.watchDirs(watcher); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		reload := make(chan struct{}, 1)
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// 新建的提示词目录也需要监听
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watcher.Add(event.Name)
+					}
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDebounce, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+			case <-reload:
+				if err := r.Reload(); err != nil {
+					r.logger.Error("重新加载提示词模板失败，继续使用原有模板", map[string]interface{}{
+						"error": err.Error(),
+					})
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.Error("监听提示词目录出错", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}()
+
+	r.logger.Info("开始监听提示词目录", map[string]interface{}{
+		"dir": r.dir,
+	})
+	return nil
+}
+
+// watchDirs 监听提示词目录及其子目录
+func (r *Registry) watchDirs(watcher *fsnotify.Watcher) error {
+	if err := watcher.Add(r.dir); err != nil {
+		return fmt.Errorf("监听提示词目录失败: %w", err)
+	}
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("读取提示词目录失败: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := watcher.Add(filepath.Join(r.dir, entry.Name())); err != nil {
+			return fmt.Errorf("监听提示词目录失败: %w", err)
+		}
+	}
+	return nil
+}
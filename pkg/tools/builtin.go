@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/date"
+	"go-smart/pkg/usage"
+)
+
+// OrderQueryArgs 订单查询参数
+type OrderQueryArgs struct {
+	OrderID string `json:"order_id" jsonschema:"description=订单号，通常以'ORD'开头,pattern=^ORD\\w+$"`
+}
+
+// OrderQueryResult 订单查询结果
+type OrderQueryResult struct {
+	Success       bool                `json:"success"`
+	OrderInfo     *business.OrderInfo `json:"order_info"`
+	FormattedInfo string              `json:"formatted_info"`
+}
+
+// OrderListArgs 订单列表参数，date 与 start_date/end_date 二选一，都不提供时不限日期
+type OrderListArgs struct {
+	Date      string `json:"date,omitempty" jsonschema:"description=用户描述的日期或时间段，如 昨天、3天前、最近7天、上周、本月"`
+	StartDate string `json:"start_date,omitempty" jsonschema:"description=开始日期（含），格式 2006-01-02"`
+	EndDate   string `json:"end_date,omitempty" jsonschema:"description=结束日期（含），格式 2006-01-02"`
+	Status    string `json:"status,omitempty" jsonschema:"enum=待付款,enum=待发货,enum=已发货,enum=已送达,enum=已取消,description=订单状态，不提供时不限状态"`
+}
+
+// OrderListResult 订单列表结果
+type OrderListResult struct {
+	Success       bool                 `json:"success"`
+	DateRange     string               `json:"date_range"`
+	Orders        []business.OrderInfo `json:"orders"`
+	FormattedInfo string               `json:"formatted_info"`
+}
+
+// RefundRequestArgs 退款申请参数
+type RefundRequestArgs struct {
+	OrderID string `json:"order_id" jsonschema:"description=订单号，通常以'ORD'开头,pattern=^ORD\\w+$"`
+	Reason  string `json:"reason" jsonschema:"description=退款原因"`
+}
+
+// RefundRequestResult 退款申请结果
+type RefundRequestResult struct {
+	Success       bool                    `json:"success"`
+	RefundRequest *business.RefundRequest `json:"refund_request"`
+	FormattedInfo string                  `json:"formatted_info"`
+}
+
+// InvoiceArgs 发票参数，开具和查询共用一个工具，通过 action 区分
+type InvoiceArgs struct {
+	Action        string            `json:"action" jsonschema:"enum=create,enum=query,description=操作类型：create（创建发票）或 query（查询发票）"`
+	InvoiceID     string            `json:"invoice_id,omitempty" jsonschema:"description=发票ID，查询时必需"`
+	CustomerName  string            `json:"customer_name,omitempty" jsonschema:"description=客户名称，创建发票时必需"`
+	CustomerTaxID string            `json:"customer_tax_id,omitempty" jsonschema:"description=客户税号，创建发票时必需"`
+	OrderID       string            `json:"order_id,omitempty" jsonschema:"description=订单号，创建发票时未提供商品列表则按订单中的商品开具"`
+	Items         []InvoiceItemArgs `json:"items,omitempty" jsonschema:"description=商品列表，创建发票时必需，提供订单号时可省略"`
+}
+
+// InvoiceItemArgs 发票商品参数
+type InvoiceItemArgs struct {
+	Name      string  `json:"name" jsonschema:"description=商品名称"`
+	Quantity  int     `json:"quantity" jsonschema:"description=商品数量,minimum=1"`
+	UnitPrice float64 `json:"unit_price" jsonschema:"description=商品单价,exclusiveMinimum=0"`
+}
+
+// InvoiceResult 发票结果
+type InvoiceResult struct {
+	Success       bool              `json:"success"`
+	Invoice       *business.Invoice `json:"invoice"`
+	FormattedInfo string            `json:"formatted_info"`
+}
+
+// newOrderQueryTool 订单查询工具
+func newOrderQueryTool(orders *business.OrderService) ToolFunction {
+	return MustTypedTool("order_query", "查询订单信息，包括订单状态、物流信息等",
+		func(ctx context.Context, args OrderQueryArgs) (OrderQueryResult, error) {
+			order, err := orders.Query(ctx, args.OrderID)
+			if err != nil {
+				return OrderQueryResult{}, err
+			}
+			return OrderQueryResult{
+				Success:       true,
+				OrderInfo:     order,
+				FormattedInfo: orders.FormatOrderInfo(order),
+			}, nil
+		},
+	).WithReadOnly(func(OrderQueryArgs) bool { return true })
+}
+
+// newOrderListTool 订单列表工具，列出当前用户在某段时间内下的订单，用户取自请求范围
+func newOrderListTool(orders *business.OrderService, dates *date.DateProcessor) ToolFunction {
+	return MustTypedTool("order_list", "按下单日期和状态列出当前用户的订单，用户没有订单号、只说了下单时间（如昨天下的单）时使用",
+		func(ctx context.Context, args OrderListArgs) (OrderListResult, error) {
+			userID := usage.ScopeFromContext(ctx).UserID
+			if userID == "" {
+				return OrderListResult{}, fmt.Errorf("无法识别当前用户，请向用户询问订单号")
+			}
+
+			var dateRange date.DateRange
+			var err error
+			if args.Date != "" {
+				dateRange, err = dates.ExtractRangeFromText(args.Date)
+			} else {
+				dateRange, err = dates.ParseRange(args.StartDate, args.EndDate)
+			}
+			if err != nil {
+				return OrderListResult{}, err
+			}
+
+			listed, err := orders.List(ctx, business.OrderFilter{
+				UserID: userID,
+				From:   dateRange.Start,
+				To:     dateRange.End,
+				Status: args.Status,
+			})
+			if err != nil {
+				return OrderListResult{}, err
+			}
+			return OrderListResult{
+				Success:       true,
+				DateRange:     dateRange.String(),
+				Orders:        listed,
+				FormattedInfo: orders.FormatOrderList(listed),
+			}, nil
+		},
+	).WithReadOnly(func(OrderListArgs) bool { return true })
+}
+
+// newRefundRequestTool 退款申请工具，提交退款申请会修改数据
+func newRefundRequestTool(refunds *business.RefundService) ToolFunction {
+	return MustTypedTool("refund_request", "申请订单退款，需要提供订单号和退款原因",
+		func(ctx context.Context, args RefundRequestArgs) (RefundRequestResult, error) {
+			refund, err := refunds.SubmitRefund(ctx, args.OrderID, args.Reason)
+			if err != nil {
+				return RefundRequestResult{}, err
+			}
+			return RefundRequestResult{
+				Success:       true,
+				RefundRequest: refund,
+				FormattedInfo: refunds.FormatRefundInfo(refund),
+			}, nil
+		},
+	)
+}
+
+// newInvoiceTool 发票工具，查询发票只读，开具发票会修改数据
+func newInvoiceTool(invoices *business.InvoiceService) ToolFunction {
+	return MustTypedTool("invoice_tool", "创建或查询发票，支持发票开具和状态查询",
+		func(ctx context.Context, args InvoiceArgs) (InvoiceResult, error) {
+			var invoice *business.Invoice
+			var err error
+			switch args.Action {
+			case "create":
+				if len(args.Items) == 0 && args.OrderID != "" {
+					invoice, err = invoices.CreateOrderInvoice(ctx, args.OrderID, args.CustomerName, args.CustomerTaxID)
+					break
+				}
+				items := make([]business.InvoiceItem, 0, len(args.Items))
+				for _, item := range args.Items {
+					items = append(items, business.InvoiceItem{
+						Name:      item.Name,
+						Quantity:  item.Quantity,
+						UnitPrice: item.UnitPrice,
+					})
+				}
+				invoice, err = invoices.CreateInvoice(ctx, args.CustomerName, args.CustomerTaxID, items, time.Time{})
+			case "query":
+				invoice, err = invoices.QueryInvoice(ctx, args.InvoiceID)
+			default:
+				err = fmt.Errorf("不支持的操作: %s", args.Action)
+			}
+			if err != nil {
+				return InvoiceResult{}, err
+			}
+			return InvoiceResult{
+				Success:       true,
+				Invoice:       invoice,
+				FormattedInfo: invoices.FormatInvoiceInfo(invoice),
+			}, nil
+		},
+	).WithReadOnly(func(args InvoiceArgs) bool { return args.Action == "query" })
+}
@@ -0,0 +1,23 @@
+package tools
+
+// SideEffect 工具调用的副作用类型
+type SideEffect string
+
+const (
+	ReadOnly SideEffect = "read_only" // 只读取数据，可以直接执行
+	Mutating SideEffect = "mutating"  // 会修改数据，如提交退款、开具发票，执行前需要确认
+)
+
+// ReadOnlyTool 声明调用是否只读的工具
+// 是否只读可以取决于参数，如同一工具的查询操作只读、创建操作会修改数据
+type ReadOnlyTool interface {
+	IsReadOnly(args map[string]interface{}) bool
+}
+
+// SideEffectOf 获取工具调用的副作用，未声明的工具按会修改数据处理
+func SideEffectOf(tool ToolFunction, args map[string]interface{}) SideEffect {
+	if declared, ok := tool.(ReadOnlyTool); ok && declared.IsReadOnly(args) {
+		return ReadOnly
+	}
+	return Mutating
+}
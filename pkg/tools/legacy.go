@@ -0,0 +1,71 @@
+package tools
+
+import "context"
+
+// LegacyToolFunction 不接收上下文的旧版工具接口
+type LegacyToolFunction interface {
+	Call(args map[string]interface{}) (map[string]interface{}, error)
+	GetDescription() string
+	GetName() string
+	GetParameters() map[string]interface{}
+}
+
+// legacyTool 将旧版工具适配为 ToolFunction
+type legacyTool struct {
+	LegacyToolFunction
+}
+
+// AdaptLegacy 将旧版工具适配为 ToolFunction，旧版工具声明的只读信息保持不变
+// 旧版工具无法被取消：上下文结束时不再等待结果，工具在后台执行完毕后丢弃其结果
+func AdaptLegacy(tool LegacyToolFunction) ToolFunction {
+	adapted := legacyTool{LegacyToolFunction: tool}
+	if readOnly, ok := tool.(ReadOnlyTool); ok {
+		return &legacyReadOnlyTool{legacyTool: adapted, readOnly: readOnly}
+	}
+	return &adapted
+}
+
+// Call 在上下文结束前调用旧版工具
+func (t *legacyTool) Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type outcome struct {
+		result map[string]interface{}
+		err    error
+		panic  interface{}
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{panic: r}
+			}
+		}()
+		result, err := t.LegacyToolFunction.Call(args)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.panic != nil {
+			// 在调用方的协程中重新panic，交给 Recover 中间件处理
+			panic(o.panic)
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// legacyReadOnlyTool 声明了只读信息的旧版工具
+type legacyReadOnlyTool struct {
+	legacyTool
+	readOnly ReadOnlyTool
+}
+
+// IsReadOnly 判断调用是否只读
+func (t *legacyReadOnlyTool) IsReadOnly(args map[string]interface{}) bool {
+	return t.readOnly.IsReadOnly(args)
+}
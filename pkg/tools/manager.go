@@ -1,20 +1,25 @@
 package tools
 
 import (
-	"go-smart/pkg/tools/business"
+	"context"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/date"
 	"sync"
 )
 
 // ToolManager 工具管理器
 type ToolManager struct {
 	registry *ToolRegistry
+	services *business.Services
 	mu       sync.RWMutex
 }
 
-// NewToolManager 创建工具管理器
-func NewToolManager() *ToolManager {
+// NewToolManager 创建工具管理器，内置工具是 services 中订单、退款和发票服务的适配
+func NewToolManager(services *business.Services) *ToolManager {
 	tm := &ToolManager{
 		registry: NewToolRegistry(),
+		services: services,
 	}
 	
 	// 注册默认工具
@@ -26,16 +31,16 @@ func NewToolManager() *ToolManager {
 // registerDefaultTools 注册默认工具
 func (tm *ToolManager) registerDefaultTools() {
 	// 注册订单查询工具
-	orderQueryTool := business.NewOrderQueryTool()
-	tm.registry.RegisterTool(orderQueryTool)
+	tm.registry.RegisterTool(newOrderQueryTool(tm.services.Orders))
+	
+	// 注册订单列表工具
+	tm.registry.RegisterTool(newOrderListTool(tm.services.Orders, date.NewDateProcessor()))
 	
 	// 注册退款申请工具
-	refundRequestTool := business.NewRefundRequestTool(orderQueryTool)
-	tm.registry.RegisterTool(refundRequestTool)
+	tm.registry.RegisterTool(newRefundRequestTool(tm.services.Refunds))
 	
 	// 注册发票工具
-	invoiceTool := business.NewInvoiceTool()
-	tm.registry.RegisterTool(invoiceTool)
+	tm.registry.RegisterTool(newInvoiceTool(tm.services.Invoices))
 }
 
 // GetRegistry 获取工具注册表
@@ -59,8 +64,13 @@ func (m *ToolManager) GetToolsSchema() []map[string]interface{} {
 }
 
 // CallTool 调用工具
-func (m *ToolManager) CallTool(name string, args map[string]interface{}) (map[string]interface{}, error) {
-	return m.registry.CallTool(name, args)
+func (m *ToolManager) CallTool(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	return m.registry.CallTool(ctx, name, args)
+}
+
+// Use 添加工具调用中间件
+func (m *ToolManager) Use(middlewares ...Middleware) {
+	m.registry.Use(middlewares...)
 }
 
 // ReloadTools 重新加载所有工具
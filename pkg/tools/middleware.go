@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go-smart/internal/logger"
+	"go-smart/pkg/trace"
+	"go-smart/pkg/usage"
+)
+
+// CallHandler 执行一次工具调用
+type CallHandler func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error)
+
+// Middleware 工具调用中间件，包裹注册表中的每次调用，用于日志、指标、追踪、异常恢复等
+type Middleware func(next CallHandler) CallHandler
+
+// chain 按顺序由外到内组合中间件
+func chain(middlewares []Middleware, handler CallHandler) CallHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// callIDKey 上下文中的工具调用ID
+type callIDKey struct{}
+
+// WithCallID 在上下文中设置模型返回的工具调用ID，追踪记录据此关联调用和结果
+func WithCallID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, callIDKey{}, id)
+}
+
+// CallIDFromContext 获取上下文中的工具调用ID，没有时返回空字符串
+func CallIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(callIDKey{}).(string)
+	return id
+}
+
+// Logging 记录每次调用的工具、耗时、结果和发起调用的租户与用户
+func Logging(log *logger.Logger) Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, tool, args)
+			scope := usage.ScopeFromContext(ctx)
+			fields := map[string]interface{}{
+				"tool":     tool.GetName(),
+				"call_id":  CallIDFromContext(ctx),
+				"tenant":   scope.TenantID,
+				"user":     scope.UserID,
+				"duration": time.Since(start).String(),
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+				log.Warn("工具调用失败", fields)
+			} else {
+				log.Info("工具调用完成", fields)
+			}
+			return result, err
+		}
+	}
+}
+
+// Tracing 将每次调用的参数、结果和耗时记录到上下文中的执行记录
+func Tracing() Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, tool, args)
+			message := ""
+			if err != nil {
+				message = err.Error()
+			}
+			trace.FromContext(ctx).Tool(tool.GetName(), CallIDFromContext(ctx), args, result, start, message)
+			return result, err
+		}
+	}
+}
+
+// Recover 将工具执行中的panic转换为错误，避免一个工具的异常影响调用方
+func Recover(log *logger.Logger) Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (result map[string]interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error("工具执行发生panic", map[string]interface{}{
+						"tool":  tool.GetName(),
+						"panic": fmt.Sprint(r),
+						"stack": string(debug.Stack()),
+					})
+					result, err = nil, fmt.Errorf("工具执行异常: %v", r)
+				}
+			}()
+			return next(ctx, tool, args)
+		}
+	}
+}
+
+// DefaultTimeout 未单独设置时每次工具调用的默认时限
+const DefaultTimeout = 10 * time.Second
+
+// 调用超时或被取消时返回的错误
+var (
+	ErrTimeout  = errors.New("工具执行超时")
+	ErrCanceled = errors.New("工具执行被取消")
+)
+
+// Timeout 为每次调用设置时限，timeouts 按工具名称单独设置，覆盖 fallback，为0表示不限制
+// 时限到达或调用方取消时上下文随之结束，工具返回的错误替换为 ErrTimeout 或 ErrCanceled
+// 工具应在上下文结束时尽快返回，不接收上下文的旧版工具由 AdaptLegacy 负责不再等待
+func Timeout(fallback time.Duration, timeouts map[string]time.Duration) Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+			timeout, exists := timeouts[tool.GetName()]
+			if !exists {
+				timeout = fallback
+			}
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			result, err := next(ctx, tool, args)
+			// 响应取消的工具在上下文结束时返回的错误统一转换，便于调用方和模型区分
+			if err == nil || ctx.Err() == nil {
+				return result, err
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ErrTimeout
+			}
+			return nil, ErrCanceled
+		}
+	}
+}
+
+// ToolStats 单个工具的调用统计
+type ToolStats struct {
+	Tool            string  `json:"tool"`
+	Calls           int64   `json:"calls"`
+	Errors          int64   `json:"errors"`
+	TotalDurationMs int64   `json:"total_duration_ms"`
+	MaxDurationMs   int64   `json:"max_duration_ms"`
+	AvgDurationMs   float64 `json:"avg_duration_ms"`
+}
+
+// ToolMetrics 按工具统计调用次数、失败次数和耗时
+type ToolMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*ToolStats
+}
+
+// NewToolMetrics 创建工具调用统计
+func NewToolMetrics() *ToolMetrics {
+	return &ToolMetrics{stats: make(map[string]*ToolStats)}
+}
+
+// Middleware 统计每次调用的中间件
+func (m *ToolMetrics) Middleware() Middleware {
+	return func(next CallHandler) CallHandler {
+		return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+			start := time.Now()
+			result, err := next(ctx, tool, args)
+			m.record(tool.GetName(), time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// record 记录一次调用
+func (m *ToolMetrics) record(name string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, exists := m.stats[name]
+	if !exists {
+		stats = &ToolStats{Tool: name}
+		m.stats[name] = stats
+	}
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	ms := duration.Milliseconds()
+	stats.TotalDurationMs += ms
+	if ms > stats.MaxDurationMs {
+		stats.MaxDurationMs = ms
+	}
+}
+
+// Snapshot 获取各工具的调用统计，按工具名称排序
+func (m *ToolMetrics) Snapshot() []ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make([]ToolStats, 0, len(m.stats))
+	for _, stats := range m.stats {
+		copied := *stats
+		copied.AvgDurationMs = float64(copied.TotalDurationMs) / float64(copied.Calls)
+		snapshot = append(snapshot, copied)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Tool < snapshot[j].Tool
+	})
+	return snapshot
+}
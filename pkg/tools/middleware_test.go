@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smart/internal/logger"
+	"go-smart/pkg/trace"
+)
+
+// waitStub 等待 args["wait"] 指定的时长或上下文结束后返回
+type waitStub struct{ name string }
+
+func (s waitStub) Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	wait, _ := time.ParseDuration(args["wait"].(string))
+	select {
+	case <-time.After(wait):
+		return map[string]interface{}{"done": true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+func (s waitStub) GetDescription() string { return "wait" }
+func (s waitStub) GetName() string        { return s.name }
+func (s waitStub) GetParameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	registry := NewToolRegistry()
+	for _, name := range []string{"fast_tool", "patient_tool"} {
+		if err := registry.RegisterTool(waitStub{name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	registry.Use(Timeout(50*time.Millisecond, map[string]time.Duration{"patient_tool": 0}))
+
+	if _, err := registry.CallTool(context.Background(), "fast_tool", map[string]interface{}{"wait": "1s"}); err != ErrTimeout {
+		t.Errorf("CallTool(fast_tool) error = %v, want ErrTimeout", err)
+	}
+	// 单独设置为0的工具不限制时限
+	if result, err := registry.CallTool(context.Background(), "patient_tool", map[string]interface{}{"wait": "100ms"}); err != nil || result["done"] != true {
+		t.Errorf("CallTool(patient_tool) = %v, %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := registry.CallTool(ctx, "patient_tool", map[string]interface{}{"wait": "1s"}); err != ErrCanceled {
+		t.Errorf("CallTool(canceled) error = %v, want ErrCanceled", err)
+	}
+}
+
+// legacyStub 不接收上下文的旧版工具，mode 为 panic 时panic，为 hang 时长时间不返回
+type legacyStub struct{}
+
+func (legacyStub) Call(args map[string]interface{}) (map[string]interface{}, error) {
+	switch args["mode"] {
+	case "panic":
+		panic("boom")
+	case "hang":
+		time.Sleep(time.Second)
+	}
+	return map[string]interface{}{"mode": args["mode"]}, nil
+}
+func (legacyStub) GetDescription() string { return "legacy" }
+func (legacyStub) GetName() string        { return "legacy_stub" }
+func (legacyStub) GetParameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (legacyStub) IsReadOnly(map[string]interface{}) bool { return true }
+
+func TestRegistryMiddlewareChain(t *testing.T) {
+	log, err := logger.DefaultLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := NewToolRegistry()
+	tool := AdaptLegacy(legacyStub{})
+	if err := registry.RegisterTool(tool); err != nil {
+		t.Fatal(err)
+	}
+	if SideEffectOf(tool, nil) != ReadOnly {
+		t.Error("AdaptLegacy() lost the read-only declaration")
+	}
+
+	order := []string{}
+	named := func(name string) Middleware {
+		return func(next CallHandler) CallHandler {
+			return func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+				order = append(order, name)
+				return next(ctx, tool, args)
+			}
+		}
+	}
+	metrics := NewToolMetrics()
+	registry.Use(named("outer"), Tracing(), Logging(log), metrics.Middleware(), Recover(log), named("inner"))
+
+	store := trace.NewStore(0, 0)
+	ctx, recorder := store.Begin(context.Background(), "s1", "workflow", "调用工具")
+	result, err := registry.CallTool(WithCallID(ctx, "call_1"), "legacy_stub", map[string]interface{}{"mode": "ok"})
+	if err != nil || result["mode"] != "ok" {
+		t.Fatalf("CallTool(ok) = %v, %v", result, err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("middleware order = %v", order)
+	}
+
+	// panic转换为错误
+	_, err = registry.CallTool(ctx, "legacy_stub", map[string]interface{}{"mode": "panic"})
+	if err == nil || !strings.Contains(err.Error(), "工具执行异常: boom") {
+		t.Errorf("CallTool(panic) error = %v", err)
+	}
+
+	// 上下文结束时不再等待旧版工具
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = registry.CallTool(timeoutCtx, "legacy_stub", map[string]interface{}{"mode": "hang"})
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("CallTool(hang) error = %v after %s", err, time.Since(start))
+	}
+	recorder.Finish("", nil)
+
+	stats := metrics.Snapshot()
+	if len(stats) != 1 || stats[0].Tool != "legacy_stub" || stats[0].Calls != 3 || stats[0].Errors != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	turn, ok := store.Turn("s1", 1)
+	if !ok || len(turn.Events) != 3 {
+		t.Fatalf("Turn(s1, 1) = %+v, %v", turn, ok)
+	}
+	if event := turn.Events[0]; event.Kind != trace.KindTool || event.CallID != "call_1" || event.Error != "" {
+		t.Errorf("first tool event = %+v", event)
+	}
+	if event := turn.Events[1]; !strings.Contains(event.Error, "工具执行异常") {
+		t.Errorf("panic tool event = %+v", event)
+	}
+}
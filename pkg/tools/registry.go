@@ -1,29 +1,53 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
 	"runtime"
+
+	"go-smart/pkg/jsonschema"
 )
 
 // ToolFunction 定义工具函数的通用接口
+// Call 的上下文携带调用的时限、取消信号和请求范围（租户、用户等，见 usage.ScopeFromContext），
+// 耗时的工具应在上下文结束时尽快返回
 type ToolFunction interface {
-	Call(args map[string]interface{}) (map[string]interface{}, error)
+	Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
 	GetDescription() string
 	GetName() string
 	GetParameters() map[string]interface{}
 }
 
+// ArgumentError 工具参数校验失败，Errors 列出每个出错的位置和原因，模型可据此修正参数后重新调用
+type ArgumentError struct {
+	Tool   string
+	Errors jsonschema.ValidationErrors
+}
+
+// Error 实现error接口
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("工具 %s 的参数不符合要求，请修正后重新调用:\n%s", e.Tool, e.Errors.Error())
+}
+
+// Unwrap 返回校验错误列表
+func (e *ArgumentError) Unwrap() error {
+	return e.Errors
+}
+
 // ToolRegistry 工具注册表，用于管理所有可用的工具
 type ToolRegistry struct {
-	tools map[string]ToolFunction
+	tools       map[string]ToolFunction
+	schemas     map[string]*jsonschema.Schema // 工具名称 -> 解析后的参数Schema
+	middlewares []Middleware                  // 包裹每次调用的中间件，先添加的在外层
 }
 
 // NewToolRegistry 创建新的工具注册表
 func NewToolRegistry() *ToolRegistry {
 	return &ToolRegistry{
-		tools: make(map[string]ToolFunction),
+		tools:   make(map[string]ToolFunction),
+		schemas: make(map[string]*jsonschema.Schema),
 	}
 }
 
@@ -42,7 +66,13 @@ func (r *ToolRegistry) RegisterTool(tool ToolFunction) error {
 		return fmt.Errorf("tool with name '%s' already registered", name)
 	}
 	
+	schema, err := jsonschema.FromMap(tool.GetParameters())
+	if err != nil {
+		return fmt.Errorf("tool '%s' has invalid parameters schema: %w", name, err)
+	}
+	
 	r.tools[name] = tool
+	r.schemas[name] = schema
 	return nil
 }
 
@@ -78,14 +108,55 @@ func (r *ToolRegistry) GetToolsSchema() []map[string]interface{} {
 	return schemas
 }
 
-// CallTool 调用指定工具
-func (r *ToolRegistry) CallTool(name string, args map[string]interface{}) (map[string]interface{}, error) {
+// Use 添加调用中间件，按添加顺序由外到内包裹每次调用
+func (r *ToolRegistry) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// CallTool 按工具声明的参数Schema转换和校验参数后调用指定工具，调用经过已添加的中间件
+// 参数不符合要求时不调用工具，返回 *ArgumentError
+func (r *ToolRegistry) CallTool(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
 	tool, exists := r.tools[name]
 	if !exists {
 		return nil, fmt.Errorf("tool '%s' not found", name)
 	}
 	
-	return tool.Call(args)
+	schema := r.schemas[name]
+	handler := func(ctx context.Context, tool ToolFunction, args map[string]interface{}) (map[string]interface{}, error) {
+		args, err := CheckArgs(tool.GetName(), schema, args)
+		if err != nil {
+			return nil, err
+		}
+		return tool.Call(ctx, args)
+	}
+	return chain(r.middlewares, handler)(ctx, tool, args)
+}
+
+// CheckArgs 按参数Schema转换可以安全转换的值（如字符串形式的数字）并校验，返回转换后的参数
+// 不符合要求时返回 *ArgumentError
+func CheckArgs(name string, schema *jsonschema.Schema, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	// 统一为JSON解码得到的值，调用方可能直接传入int、[]string等Go类型
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("序列化工具 %s 的参数失败: %w", name, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("解析工具 %s 的参数失败: %w", name, err)
+	}
+	
+	value = schema.Coerce(value)
+	if err := schema.Validate(value); err != nil {
+		if errs, ok := err.(jsonschema.ValidationErrors); ok {
+			return nil, &ArgumentError{Tool: name, Errors: errs}
+		}
+		return nil, err
+	}
+	checked, _ := value.(map[string]interface{})
+	return checked, nil
 }
 
 // UnregisterTool 从注册表中移除工具
@@ -95,12 +166,14 @@ func (r *ToolRegistry) UnregisterTool(name string) error {
 	}
 	
 	delete(r.tools, name)
+	delete(r.schemas, name)
 	return nil
 }
 
 // Clear 清空所有已注册的工具
 func (r *ToolRegistry) Clear() {
 	r.tools = make(map[string]ToolFunction)
+	r.schemas = make(map[string]*jsonschema.Schema)
 }
 
 // Count 返回已注册工具的数量
@@ -153,23 +226,14 @@ func (t *BaseTool) GetParameters() map[string]interface{} {
 	return t.parameters
 }
 
-// ValidateArgs 验证参数是否符合工具的参数模式
+// ValidateArgs 验证参数是否符合工具的参数Schema，不符合时返回 *ArgumentError
 func (t *BaseTool) ValidateArgs(args map[string]interface{}) error {
-	// 简单验证：检查必需参数是否存在
-	if requiredParams, ok := t.parameters["required"].([]interface{}); ok {
-		for _, param := range requiredParams {
-			paramName, ok := param.(string)
-			if !ok {
-				continue
-			}
-			
-			if _, exists := args[paramName]; !exists {
-				return fmt.Errorf("missing required parameter: %s", paramName)
-			}
-		}
+	schema, err := jsonschema.FromMap(t.parameters)
+	if err != nil {
+		return fmt.Errorf("tool '%s' has invalid parameters schema: %w", t.name, err)
 	}
-	
-	return nil
+	_, err = CheckArgs(t.name, schema, args)
+	return err
 }
 
 // ConvertArgs 将参数转换为指定的类型
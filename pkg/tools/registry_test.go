@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/usage"
+)
+
+func TestCallToolValidatesAndCoercesArgs(t *testing.T) {
+	registry := NewToolManager(business.NewMockServices()).GetRegistry()
+
+	// 字符串形式的数字转换为数值后再解码
+	result, err := registry.CallTool(context.Background(), "invoice_tool", map[string]interface{}{
+		"action":          "create",
+		"customer_name":   "张三",
+		"customer_tax_id": "110101199001011234",
+		"items": []map[string]interface{}{
+			{"name": "手机壳", "quantity": "2", "unit_price": "49.5"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CallTool(create) error: %v", err)
+	}
+	if subtotal := result["invoice"].(map[string]interface{})["subtotal"]; subtotal != 99.0 {
+		t.Errorf("subtotal = %v, want 99", subtotal)
+	}
+
+	_, err = registry.CallTool(context.Background(), "invoice_tool", map[string]interface{}{
+		"action": "create",
+		"items": []interface{}{
+			map[string]interface{}{"name": "手机壳", "quantity": "两个", "unit_price": -1},
+		},
+	})
+	var argErr *ArgumentError
+	if !errors.As(err, &argErr) || argErr.Tool != "invoice_tool" {
+		t.Fatalf("CallTool(invalid items) error = %v, want *ArgumentError", err)
+	}
+	paths := []string{}
+	for _, validation := range argErr.Errors {
+		paths = append(paths, validation.Path)
+	}
+	if got := strings.Join(paths, ","); got != "$.items[0].quantity,$.items[0].unit_price" {
+		t.Errorf("error paths = %s", got)
+	}
+
+	_, err = registry.CallTool(context.Background(), "order_query", map[string]interface{}{"order_id": "123456"})
+	if !errors.As(err, &argErr) || !strings.Contains(err.Error(), "应匹配 ^ORD\\w+$") {
+		t.Errorf("CallTool(order_query) error = %v, want pattern error", err)
+	}
+
+	// 手写的参数定义同样按完整的Schema校验，required 声明为 []string 也能生效
+	tool := NewBaseTool("legacy", "", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"page": map[string]interface{}{"type": "integer", "minimum": 1}},
+		"required":   []string{"page"},
+	})
+	if err := tool.ValidateArgs(map[string]interface{}{}); !errors.As(err, &argErr) {
+		t.Errorf("ValidateArgs(missing) error = %v, want *ArgumentError", err)
+	}
+	if err := tool.ValidateArgs(map[string]interface{}{"page": "3"}); err != nil {
+		t.Errorf("ValidateArgs(numeric string) error = %v", err)
+	}
+}
+
+func TestBuiltinToolsShareServices(t *testing.T) {
+	services := business.NewMockServices()
+	manager := NewToolManager(services)
+
+	// 通过工具提交的退款在服务中可以查到，反之亦然
+	result, err := manager.CallTool(context.Background(), "refund_request", map[string]interface{}{
+		"order_id": "ORD345678",
+		"reason":   "不想要了",
+	})
+	if err != nil {
+		t.Fatalf("CallTool(refund_request) error: %v", err)
+	}
+	refundID := result["refund_request"].(map[string]interface{})["request_id"].(string)
+	if _, err := services.Refunds.QueryRefund(context.Background(), refundID); err != nil {
+		t.Errorf("QueryRefund(%s) error: %v", refundID, err)
+	}
+
+	invoice, err := services.Invoices.CreateInvoice(context.Background(), "张三", "110101199001011234",
+		[]business.InvoiceItem{{Name: "手机壳", Quantity: 1, UnitPrice: 49}}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.CallTool(context.Background(), "invoice_tool", map[string]interface{}{
+		"action":     "query",
+		"invoice_id": invoice.InvoiceID,
+	}); err != nil {
+		t.Errorf("CallTool(invoice_tool query) error: %v", err)
+	}
+}
+
+func TestOrderListToolUsesRequestUser(t *testing.T) {
+	manager := NewToolManager(business.NewMockServices())
+	ctx := usage.WithScope(context.Background(), usage.Scope{UserID: "U1001"})
+
+	// 示例订单中 U1001 最近两天只有 ORD345678，ORD123456 是三天前下的
+	result, err := manager.CallTool(ctx, "order_list", map[string]interface{}{"date": "最近2天"})
+	if err != nil {
+		t.Fatalf("CallTool(order_list) error: %v", err)
+	}
+	orders := result["orders"].([]interface{})
+	if len(orders) != 1 || orders[0].(map[string]interface{})["order_id"] != "ORD345678" {
+		t.Errorf("orders = %v", orders)
+	}
+
+	result, err = manager.CallTool(ctx, "order_list", map[string]interface{}{"status": "已发货"})
+	if err != nil || !strings.Contains(result["formatted_info"].(string), "ORD123456") {
+		t.Errorf("CallTool(order_list 已发货) = %v, %v", result, err)
+	}
+	if _, err := manager.CallTool(ctx, "order_list", map[string]interface{}{"status": "配送中"}); err == nil {
+		t.Error("CallTool(order_list) with unknown status error = nil")
+	}
+
+	// 没有用户身份时不列出任何订单
+	if _, err := manager.CallTool(context.Background(), "order_list", map[string]interface{}{"date": "昨天"}); err == nil {
+		t.Error("CallTool(order_list) without user error = nil")
+	}
+}
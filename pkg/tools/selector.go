@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 工具被选中的原因
+const (
+	ReasonIntent   = "intent"   // 意图对应的工具
+	ReasonMatch    = "match"    // 关键词与工具名称、描述匹配
+	ReasonFallback = "fallback" // 没有匹配的工具时回退到流程允许的工具
+)
+
+// 没有意图对应或匹配的工具时的处理
+const (
+	FallbackAllowed = "allowed" // 提供流程允许的全部工具（不超过上限）
+	FallbackNone    = "none"    // 流程不限制工具，不提供工具，由模型直接回答
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// SelectionRequest 选择工具的依据
+type SelectionRequest struct {
+	Query   string   // 用户消息
+	Intent  string   // 识别的意图，如工作流的专员
+	Allowed []string // 流程允许使用的工具，为空时不限制
+}
+
+// Selection 选择结果
+type Selection struct {
+	Tools    []string          `json:"tools"`              // 按优先级排序的工具名称
+	Reasons  map[string]string `json:"reasons"`            // 工具名称 -> 选中原因，匹配的工具附带得分
+	Fallback string            `json:"fallback,omitempty"` // 没有意图对应或匹配的工具时的处理，见 FallbackAllowed、FallbackNone
+}
+
+// ToolSelector 工具选择器，每轮只向模型提供相关的工具，减少token消耗并提高选择工具的准确率
+// 先限定在流程允许的工具内，再依次加入意图对应的工具、按BM25与消息匹配的工具，
+// 都没有时回退到流程允许的工具，流程不限制工具时不提供工具，总数不超过上限
+type ToolSelector struct {
+	maxTools    int
+	intentTools map[string][]string
+}
+
+// NewToolSelector 创建工具选择器，maxTools 为每轮最多提供的工具数，0表示不限制
+// intentTools 为意图 -> 优先提供的工具
+func NewToolSelector(maxTools int, intentTools map[string][]string) *ToolSelector {
+	if intentTools == nil {
+		intentTools = make(map[string][]string)
+	}
+	return &ToolSelector{
+		maxTools:    maxTools,
+		intentTools: intentTools,
+	}
+}
+
+// Select 从可用的工具中选择本轮提供给模型的工具
+func (s *ToolSelector) Select(available map[string]ToolFunction, req SelectionRequest) Selection {
+	candidates := make(map[string]ToolFunction, len(available))
+	if len(req.Allowed) == 0 {
+		for name, tool := range available {
+			candidates[name] = tool
+		}
+	} else {
+		for _, name := range req.Allowed {
+			if tool, exists := available[name]; exists {
+				candidates[name] = tool
+			}
+		}
+	}
+
+	selection := Selection{Tools: []string{}, Reasons: make(map[string]string)}
+	add := func(name, reason string) {
+		if _, selected := selection.Reasons[name]; selected {
+			return
+		}
+		if s.maxTools > 0 && len(selection.Tools) >= s.maxTools {
+			return
+		}
+		selection.Tools = append(selection.Tools, name)
+		selection.Reasons[name] = reason
+	}
+
+	for _, name := range s.intentTools[req.Intent] {
+		if _, exists := candidates[name]; exists {
+			add(name, ReasonIntent)
+		}
+	}
+	for _, match := range rankTools(candidates, req.Query) {
+		add(match.name, fmt.Sprintf("%s:%.2f", ReasonMatch, match.score))
+	}
+	if len(selection.Tools) == 0 {
+		// 任意挑选的工具与消息无关，只在流程限定了工具时按流程给出的顺序提供
+		if len(req.Allowed) == 0 {
+			selection.Fallback = FallbackNone
+			return selection
+		}
+		selection.Fallback = FallbackAllowed
+		for _, name := range req.Allowed {
+			if _, exists := candidates[name]; exists {
+				add(name, ReasonFallback)
+			}
+		}
+	}
+	return selection
+}
+
+// toolScore 工具与消息的匹配得分
+type toolScore struct {
+	name  string
+	score float64
+}
+
+// rankTools 按BM25计算消息与工具名称、描述和参数说明的匹配得分，返回得分大于0的工具，得分高的在前
+func rankTools(candidates map[string]ToolFunction, query string) []toolScore {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 || len(candidates) == 0 {
+		return nil
+	}
+
+	documents := make(map[string][]string, len(candidates))
+	frequencies := make(map[string]int)
+	totalLength := 0
+	for name, tool := range candidates {
+		terms := tokenize(toolText(tool))
+		documents[name] = terms
+		totalLength += len(terms)
+		seen := make(map[string]bool)
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				frequencies[term]++
+			}
+		}
+	}
+	averageLength := float64(totalLength) / float64(len(documents))
+
+	scores := make([]toolScore, 0, len(documents))
+	for name, terms := range documents {
+		counts := make(map[string]int, len(terms))
+		for _, term := range terms {
+			counts[term]++
+		}
+		score := 0.0
+		for _, term := range uniqueTerms(queryTerms) {
+			count := float64(counts[term])
+			if count == 0 {
+				continue
+			}
+			df := float64(frequencies[term])
+			idf := math.Log(1 + (float64(len(documents))-df+0.5)/(df+0.5))
+			norm := 1 - bm25B + bm25B*float64(len(terms))/averageLength
+			score += idf * count * (bm25K1 + 1) / (count + bm25K1*norm)
+		}
+		if score > 0 {
+			scores = append(scores, toolScore{name: name, score: score})
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].score != scores[j].score {
+			return scores[i].score > scores[j].score
+		}
+		return scores[i].name < scores[j].name
+	})
+	return scores
+}
+
+// toolText 工具用于匹配的文本：名称、描述和参数说明
+func toolText(tool ToolFunction) string {
+	parts := []string{strings.ReplaceAll(tool.GetName(), "_", " "), tool.GetDescription()}
+	if properties, ok := tool.GetParameters()["properties"].(map[string]interface{}); ok {
+		for name, property := range properties {
+			parts = append(parts, strings.ReplaceAll(name, "_", " "))
+			if definition, ok := property.(map[string]interface{}); ok {
+				if description, ok := definition["description"].(string); ok {
+					parts = append(parts, description)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tokenize 切分文本：英文单词和数字转为小写，连续的中文按相邻两字切分，单个汉字单独作为一项
+func tokenize(text string) []string {
+	var terms []string
+	var word []rune
+	var han []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 {
+			terms = append(terms, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+// uniqueTerms 去除重复的词
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-smart/pkg/business"
+)
+
+func TestToolSelectorSelectsRelevantTools(t *testing.T) {
+	available := NewToolManager(business.NewMockServices()).GetAllTools()
+	selector := NewToolSelector(2, map[string][]string{"refund": {"refund_request"}})
+
+	tests := []struct {
+		name     string
+		req      SelectionRequest
+		want     []string
+		reasons  []string
+		fallback string
+	}{
+		{
+			name:    "关键词匹配工具描述",
+			req:     SelectionRequest{Query: "帮我开一张发票"},
+			want:    []string{"invoice_tool"},
+			reasons: []string{ReasonMatch},
+		},
+		{
+			name:    "意图对应的工具优先",
+			req:     SelectionRequest{Query: "查询订单ORD123456的物流", Intent: "refund"},
+			want:    []string{"refund_request", "order_query"},
+			reasons: []string{ReasonIntent, ReasonMatch},
+		},
+		{
+			name:     "没有匹配时回退到允许的工具",
+			req:      SelectionRequest{Query: "帮我开一张发票", Allowed: []string{"refund_request", "order_query"}},
+			want:     []string{"refund_request", "order_query"},
+			reasons:  []string{ReasonFallback, ReasonFallback},
+			fallback: FallbackAllowed,
+		},
+		{
+			name:     "不限制工具时没有匹配则不提供工具",
+			req:      SelectionRequest{Query: "你好"},
+			want:     []string{},
+			fallback: FallbackNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection := selector.Select(available, tt.req)
+			if !reflect.DeepEqual(selection.Tools, tt.want) {
+				t.Fatalf("Select() tools = %v, want %v", selection.Tools, tt.want)
+			}
+			if selection.Fallback != tt.fallback {
+				t.Errorf("fallback = %q, want %q", selection.Fallback, tt.fallback)
+			}
+			for i, name := range tt.want {
+				if !strings.HasPrefix(selection.Reasons[name], tt.reasons[i]) {
+					t.Errorf("reason of %s = %q, want %s", name, selection.Reasons[name], tt.reasons[i])
+				}
+			}
+		})
+	}
+}
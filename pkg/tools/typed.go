@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"go-smart/pkg/jsonschema"
+)
+
+// TypedTool 以Go结构体定义参数和结果的工具
+// 参数的JSON Schema由 In 的json和jsonschema标签生成：未标记omitempty的字段必填，
+// jsonschema标签可补充 description、enum、pattern、minimum 等约束。调用时先按Schema转换和校验参数，
+// 再解码到 In，结果 Out 序列化为JSON对象返回
+type TypedTool[In, Out any] struct {
+	name        string
+	description string
+	schema      *jsonschema.Schema
+	parameters  map[string]interface{}
+	fn          func(ctx context.Context, in In) (Out, error)
+	readOnly    func(in In) bool
+}
+
+// NewTypedTool 创建类型化工具，In 必须是结构体
+func NewTypedTool[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) (*TypedTool[In, Out], error) {
+	var in In
+	if t := reflect.TypeOf(in); t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("工具 %s 的参数类型必须是结构体", name)
+	}
+
+	schema, err := jsonschema.Reflect(in)
+	if err != nil {
+		return nil, fmt.Errorf("生成工具 %s 的参数Schema失败: %w", name, err)
+	}
+	parameters, err := toMap(schema)
+	if err != nil {
+		return nil, fmt.Errorf("生成工具 %s 的参数Schema失败: %w", name, err)
+	}
+
+	return &TypedTool[In, Out]{
+		name:        name,
+		description: description,
+		schema:      schema,
+		parameters:  parameters,
+		fn:          fn,
+	}, nil
+}
+
+// MustTypedTool 创建类型化工具，参数类型不正确时panic，用于注册内置工具
+func MustTypedTool[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) *TypedTool[In, Out] {
+	tool, err := NewTypedTool(name, description, fn)
+	if err != nil {
+		panic(err)
+	}
+	return tool
+}
+
+// WithReadOnly 声明哪些调用只读，未声明时按会修改数据处理
+func (t *TypedTool[In, Out]) WithReadOnly(readOnly func(in In) bool) *TypedTool[In, Out] {
+	t.readOnly = readOnly
+	return t
+}
+
+// GetName 获取工具名称
+func (t *TypedTool[In, Out]) GetName() string {
+	return t.name
+}
+
+// GetDescription 获取工具描述
+func (t *TypedTool[In, Out]) GetDescription() string {
+	return t.description
+}
+
+// GetParameters 获取由参数类型生成的JSON Schema
+func (t *TypedTool[In, Out]) GetParameters() map[string]interface{} {
+	return t.parameters
+}
+
+// Schema 获取参数的JSON Schema
+func (t *TypedTool[In, Out]) Schema() *jsonschema.Schema {
+	return t.schema
+}
+
+// IsReadOnly 解码参数后判断调用是否只读，参数无效时按会修改数据处理
+func (t *TypedTool[In, Out]) IsReadOnly(args map[string]interface{}) bool {
+	if t.readOnly == nil {
+		return false
+	}
+	in, err := t.Decode(args)
+	if err != nil {
+		return false
+	}
+	return t.readOnly(in)
+}
+
+// Call 校验并解码参数后调用，结果序列化为JSON对象，不是对象时放在 result 字段中
+func (t *TypedTool[In, Out]) Call(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	in, err := t.Decode(args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.fn(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return t.encode(out)
+}
+
+// Decode 按参数Schema转换和校验参数后解码到 In，不符合要求时返回 *ArgumentError
+func (t *TypedTool[In, Out]) Decode(args map[string]interface{}) (In, error) {
+	return decodeArgs[In](t.name, t.schema, args)
+}
+
+// DecodeArgs 按 In 生成的参数Schema转换和校验参数后解码到 In，不符合要求时返回 *ArgumentError
+// 用于工具之外接收同样参数的入口（如HTTP接口），与同名工具的参数处理一致
+func DecodeArgs[In any](name string, args map[string]interface{}) (In, error) {
+	var in In
+	schema, err := jsonschema.Reflect(in)
+	if err != nil {
+		return in, fmt.Errorf("生成工具 %s 的参数Schema失败: %w", name, err)
+	}
+	return decodeArgs[In](name, schema, args)
+}
+
+// decodeArgs 按Schema转换和校验参数后解码到 In
+func decodeArgs[In any](name string, schema *jsonschema.Schema, args map[string]interface{}) (In, error) {
+	var in In
+	checked, err := CheckArgs(name, schema, args)
+	if err != nil {
+		return in, err
+	}
+	data, err := json.Marshal(checked)
+	if err != nil {
+		return in, fmt.Errorf("序列化工具 %s 的参数失败: %w", name, err)
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return in, fmt.Errorf("解析工具 %s 的参数失败: %w", name, err)
+	}
+	return in, nil
+}
+
+// encode 将结果序列化为JSON对象
+func (t *TypedTool[In, Out]) encode(out Out) (map[string]interface{}, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, fmt.Errorf("序列化工具 %s 的结果失败: %w", t.name, err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err == nil && result != nil {
+		return result, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("序列化工具 %s 的结果失败: %w", t.name, err)
+	}
+	return map[string]interface{}{"result": value}, nil
+}
+
+// toMap 将Schema转换为 map 形式的参数定义
+func toMap(schema *jsonschema.Schema) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var parameters map[string]interface{}
+	if err := json.Unmarshal(data, &parameters); err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-smart/pkg/business"
+	"go-smart/pkg/jsonschema"
+)
+
+func TestTypedToolSchemaAndCall(t *testing.T) {
+	tool, exists := NewToolManager(business.NewMockServices()).GetTool("invoice_tool")
+	if !exists {
+		t.Fatal("invoice_tool not registered")
+	}
+
+	parameters, _ := json.Marshal(tool.GetParameters())
+	schema, err := jsonschema.Parse(parameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"action"}) {
+		t.Errorf("required = %v, want [action]", schema.Required)
+	}
+	if action := schema.Properties["action"]; len(action.Enum) != 2 || !strings.Contains(action.Description, "create") {
+		t.Errorf("action schema = %s", action)
+	}
+	item := schema.Properties["items"].Items
+	if item.Properties["quantity"].Type[0] != "integer" || len(item.Required) != 3 {
+		t.Errorf("item schema = %s", item)
+	}
+
+	// 模型返回的JSON数字解码为float64，应能解码到int字段
+	result, err := tool.Call(context.Background(), map[string]interface{}{
+		"action":          "create",
+		"customer_name":   "张三",
+		"customer_tax_id": "110101199001011234",
+		"items": []interface{}{
+			map[string]interface{}{"name": "智能手表", "quantity": float64(2), "unit_price": 1299.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Call(create) error: %v", err)
+	}
+	invoice := result["invoice"].(map[string]interface{})
+	if invoice["subtotal"] != 2598.0 || result["success"] != true {
+		t.Errorf("invoice = %v", invoice)
+	}
+	if SideEffectOf(tool, map[string]interface{}{"action": "query"}) != ReadOnly ||
+		SideEffectOf(tool, map[string]interface{}{"action": "create"}) != Mutating {
+		t.Error("only query should be read-only")
+	}
+
+	_, err = tool.Call(context.Background(), map[string]interface{}{"action": "delete", "items": []interface{}{map[string]interface{}{"name": "手表", "quantity": 1.5}}})
+	var validation jsonschema.ValidationErrors
+	if !errors.As(err, &validation) || len(validation) != 3 {
+		t.Fatalf("Call(invalid) error = %v, want enum, integer and required errors", err)
+	}
+}
+
+func TestTypedToolWrapsNonObjectResult(t *testing.T) {
+	type args struct {
+		Text string `json:"text"`
+	}
+	tool, err := NewTypedTool("count", "统计字数", func(ctx context.Context, in args) (int, error) {
+		return len([]rune(in.Text)), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := tool.Call(context.Background(), map[string]interface{}{"text": "你好"})
+	if err != nil || result["result"] != 2.0 {
+		t.Errorf("Call() = %v, %v, want result 2", result, err)
+	}
+
+	if _, err := NewTypedTool("bad", "", func(ctx context.Context, in string) (int, error) { return 0, nil }); err == nil {
+		t.Error("NewTypedTool() with non-struct args should fail")
+	}
+}
@@ -0,0 +1,170 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store 保存最近会话的对话记录
+// 超过会话数上限时淘汰最久未更新的会话，单个会话超过轮次上限时丢弃最早的轮次
+type Store struct {
+	mu          sync.Mutex
+	maxSessions int
+	maxTurns    int
+	sessions    map[string]*session
+}
+
+// session 会话的对话记录
+type session struct {
+	turns     []Turn
+	nextTurn  int
+	updatedAt time.Time
+}
+
+// SessionInfo 会话记录概要
+type SessionInfo struct {
+	SessionID string    `json:"session_id"`
+	Turns     int       `json:"turns"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewStore 创建对话记录存储，上限为0表示不限制
+func NewStore(maxSessions, maxTurns int) *Store {
+	return &Store{
+		maxSessions: maxSessions,
+		maxTurns:    maxTurns,
+		sessions:    make(map[string]*session),
+	}
+}
+
+// Begin 开始记录一轮对话，返回挂载了记录器的上下文
+// 没有会话ID时（单轮对话）使用随机生成的ID；store 为nil时不记录
+func (s *Store) Begin(ctx context.Context, sessionID, source, input string) (context.Context, *Recorder) {
+	if s == nil {
+		return ctx, nil
+	}
+	if sessionID == "" {
+		sessionID = "req_" + randomHex()
+	}
+
+	s.mu.Lock()
+	current, exists := s.sessions[sessionID]
+	if !exists {
+		current = &session{}
+		s.sessions[sessionID] = current
+	}
+	current.nextTurn++
+	current.updatedAt = time.Now()
+	turn := current.nextTurn
+	s.evict()
+	s.mu.Unlock()
+
+	recorder := &Recorder{
+		store: s,
+		turn: &Turn{
+			SessionID: sessionID,
+			Turn:      turn,
+			Source:    source,
+			Input:     input,
+			Events:    make([]Event, 0),
+			StartedAt: time.Now(),
+		},
+	}
+	return WithRecorder(ctx, recorder), recorder
+}
+
+// Sessions 获取有记录的会话，最近更新的在前
+func (s *Store) Sessions() []SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]SessionInfo, 0, len(s.sessions))
+	for id, current := range s.sessions {
+		infos = append(infos, SessionInfo{SessionID: id, Turns: len(current.turns), UpdatedAt: current.updatedAt})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].UpdatedAt.After(infos[j].UpdatedAt)
+	})
+	return infos
+}
+
+// Session 获取会话的全部对话记录，按轮次排序
+func (s *Store) Session(sessionID string) ([]Turn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.sessions[sessionID]
+	if !exists {
+		return nil, false
+	}
+	return append([]Turn{}, current.turns...), true
+}
+
+// Turn 获取会话指定轮次的对话记录
+func (s *Store) Turn(sessionID string, turn int) (Turn, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.sessions[sessionID]
+	if !exists {
+		return Turn{}, false
+	}
+	for _, recorded := range current.turns {
+		if recorded.Turn == turn {
+			return recorded, true
+		}
+	}
+	return Turn{}, false
+}
+
+// save 保存结束的一轮对话，同一会话并发处理时按轮次插入
+func (s *Store) save(turn Turn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.sessions[turn.SessionID]
+	if !exists {
+		// 处理期间会话已被淘汰
+		current = &session{nextTurn: turn.Turn}
+		s.sessions[turn.SessionID] = current
+	}
+	index := sort.Search(len(current.turns), func(i int) bool {
+		return current.turns[i].Turn > turn.Turn
+	})
+	current.turns = append(current.turns, Turn{})
+	copy(current.turns[index+1:], current.turns[index:])
+	current.turns[index] = turn
+	if s.maxTurns > 0 && len(current.turns) > s.maxTurns {
+		current.turns = current.turns[len(current.turns)-s.maxTurns:]
+	}
+	current.updatedAt = time.Now()
+	s.evict()
+}
+
+// evict 淘汰超出上限的会话，调用方需持有锁
+func (s *Store) evict() {
+	for s.maxSessions > 0 && len(s.sessions) > s.maxSessions {
+		oldestID := ""
+		var oldest time.Time
+		for id, current := range s.sessions {
+			if oldestID == "" || current.updatedAt.Before(oldest) {
+				oldestID, oldest = id, current.updatedAt
+			}
+		}
+		delete(s.sessions, oldestID)
+	}
+}
+
+// randomHex 生成随机的十六进制字符串
+func randomHex() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
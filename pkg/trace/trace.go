@@ -0,0 +1,257 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 事件类型
+const (
+	KindPrompt     = "prompt"     // 渲染提示词
+	KindModel      = "model"      // 调用模型
+	KindTool       = "tool"       // 执行工具
+	KindTransition = "transition" // 多轮对话的意图和步骤变化
+	KindTools      = "tools"      // 选择提供给模型的工具
+	KindError      = "error"      // 处理中的错误，如触发处理限制
+)
+
+// Turn 一轮对话的执行记录
+type Turn struct {
+	SessionID  string            `json:"session_id"`
+	Turn       int               `json:"turn"`   // 会话内从1开始的轮次
+	Source     string            `json:"source"` // workflow, conversation, approval 等
+	Input      string            `json:"input"`
+	Output     string            `json:"output"`
+	Error      string            `json:"error,omitempty"`
+	Prompts    map[string]string `json:"prompts,omitempty"` // 提示词名称 -> 实际使用的版本
+	Events     []Event           `json:"events"`
+	StartedAt  time.Time         `json:"started_at"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// Event 执行中的一个事件
+type Event struct {
+	Kind       string                 `json:"kind"`
+	Name       string                 `json:"name,omitempty"` // 提示词名称、工具名称或模型档位
+	At         time.Time              `json:"at"`
+	DurationMs int64                  `json:"duration_ms,omitempty"`
+	Version    string                 `json:"version,omitempty"`  // 提示词版本
+	Messages   []Message              `json:"messages,omitempty"` // 发给模型的消息
+	Response   *Message               `json:"response,omitempty"` // 模型的回复，含工具调用
+	CallID     string                 `json:"call_id,omitempty"`  // 工具调用ID
+	Args       map[string]interface{} `json:"args,omitempty"`
+	Result     interface{}            `json:"result,omitempty"`
+	Intent     string                 `json:"intent,omitempty"`
+	From       string                 `json:"from,omitempty"`    // 变化前的步骤
+	To         string                 `json:"to,omitempty"`      // 变化后的步骤
+	Tools      []string               `json:"tools,omitempty"`   // 提供给模型的工具
+	Reasons    map[string]string      `json:"reasons,omitempty"` // 工具名称 -> 选中原因
+	Error      string                 `json:"error,omitempty"`
+}
+
+// Message 记录的消息
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall 记录的工具调用，参数保留模型返回的JSON
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Recorder 记录一轮对话的执行过程，可在多个goroutine中使用
+// nil 记录器的所有方法都不做任何事，未开启追踪时调用方无需判断
+type Recorder struct {
+	mu    sync.Mutex
+	turn  *Turn
+	store *Store
+}
+
+// recorderKey 上下文中本轮对话的记录器
+type recorderKey struct{}
+
+// WithRecorder 将记录器挂载到上下文
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	if recorder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// FromContext 获取上下文中的记录器，没有时返回nil
+func FromContext(ctx context.Context) *Recorder {
+	recorder, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder
+}
+
+// Ref 本轮对话记录的位置，用于通过管理接口查询
+func (r *Recorder) Ref() *Ref {
+	if r == nil {
+		return nil
+	}
+	return &Ref{SessionID: r.turn.SessionID, Turn: r.turn.Turn}
+}
+
+// Ref 对话记录的位置
+type Ref struct {
+	SessionID string `json:"session_id"`
+	Turn      int    `json:"turn"`
+}
+
+// Prompt 记录渲染的提示词及实际使用的版本
+func (r *Recorder) Prompt(name, version string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.turn.Prompts == nil {
+		r.turn.Prompts = make(map[string]string)
+	}
+	r.turn.Prompts[name] = version
+	r.turn.Events = append(r.turn.Events, Event{Kind: KindPrompt, Name: name, Version: version, At: time.Now()})
+}
+
+// Model 记录一次模型调用，response 为nil表示调用失败或为流式调用
+func (r *Recorder) Model(name string, messages []*schema.Message, response *schema.Message, start time.Time, err error) {
+	if r == nil {
+		return
+	}
+	event := Event{
+		Kind:       KindModel,
+		Name:       name,
+		At:         start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Messages:   convertMessages(messages),
+		Error:      errorString(err),
+	}
+	if response != nil {
+		message := convertMessage(response)
+		event.Response = &message
+	}
+	r.add(event)
+}
+
+// Tool 记录一次工具调用
+func (r *Recorder) Tool(name, callID string, args map[string]interface{}, result interface{}, start time.Time, errMessage string) {
+	if r == nil {
+		return
+	}
+	r.add(Event{
+		Kind:       KindTool,
+		Name:       name,
+		At:         start,
+		DurationMs: time.Since(start).Milliseconds(),
+		CallID:     callID,
+		Args:       args,
+		Result:     result,
+		Error:      errMessage,
+	})
+}
+
+// Transition 记录多轮对话识别的意图和步骤变化
+func (r *Recorder) Transition(intent, from, to string) {
+	if r == nil {
+		return
+	}
+	r.add(Event{Kind: KindTransition, Intent: intent, From: from, To: to, At: time.Now()})
+}
+
+// Tools 记录按意图选择的提供给模型的工具及选中原因
+func (r *Recorder) Tools(intent string, tools []string, reasons map[string]string) {
+	if r == nil {
+		return
+	}
+	r.add(Event{Kind: KindTools, Intent: intent, Tools: tools, Reasons: reasons, At: time.Now()})
+}
+
+// Error 记录处理中的错误，如触发处理限制，不影响本轮的最终结果
+func (r *Recorder) Error(name, message string) {
+	if r == nil {
+		return
+	}
+	r.add(Event{Kind: KindError, Name: name, Error: message, At: time.Now()})
+}
+
+// Finish 结束本轮记录并保存，多次调用时只保存第一次的结果
+func (r *Recorder) Finish(output string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.store == nil {
+		r.mu.Unlock()
+		return
+	}
+	r.turn.Output = output
+	r.turn.Error = errorString(err)
+	r.turn.DurationMs = time.Since(r.turn.StartedAt).Milliseconds()
+	store := r.store
+	r.store = nil
+	turn := r.snapshot()
+	r.mu.Unlock()
+
+	store.save(turn)
+}
+
+// add 添加事件
+func (r *Recorder) add(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.turn.Events = append(r.turn.Events, event)
+}
+
+// snapshot 复制本轮记录，调用方需持有锁
+func (r *Recorder) snapshot() Turn {
+	turn := *r.turn
+	turn.Events = append([]Event{}, r.turn.Events...)
+	if r.turn.Prompts != nil {
+		turn.Prompts = make(map[string]string, len(r.turn.Prompts))
+		for name, version := range r.turn.Prompts {
+			turn.Prompts[name] = version
+		}
+	}
+	return turn
+}
+
+// convertMessages 转换发给模型的消息
+func convertMessages(messages []*schema.Message) []Message {
+	converted := make([]Message, 0, len(messages))
+	for _, message := range messages {
+		converted = append(converted, convertMessage(message))
+	}
+	return converted
+}
+
+// convertMessage 转换消息
+func convertMessage(message *schema.Message) Message {
+	converted := Message{
+		Role:       string(message.Role),
+		Content:    message.Content,
+		ToolCallID: message.ToolCallID,
+	}
+	for _, call := range message.ToolCalls {
+		converted.ToolCalls = append(converted.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return converted
+}
+
+// errorString 获取错误信息，err 为nil时返回空字符串
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
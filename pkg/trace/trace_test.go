@@ -0,0 +1,83 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestStoreRecordsTurnsBySession(t *testing.T) {
+	store := NewStore(0, 0)
+
+	ctx, first := store.Begin(context.Background(), "s1", "workflow", "查询订单ORD1")
+	recorder := FromContext(ctx)
+	if recorder != first {
+		t.Fatal("FromContext() did not return the recorder from Begin")
+	}
+	start := time.Now()
+	recorder.Prompt("workflow_planner", "v2")
+	response := schema.AssistantMessage("", []schema.ToolCall{{ID: "call_1", Function: schema.FunctionCall{Name: "query_order", Arguments: `{"order_id":"ORD1"}`}}})
+	recorder.Model("", []*schema.Message{schema.SystemMessage("系统提示"), schema.UserMessage("查询订单ORD1")}, response, start, nil)
+	recorder.Tool("query_order", "call_1", map[string]interface{}{"order_id": "ORD1"}, map[string]interface{}{"status": "已发货"}, start, "")
+	recorder.Finish("订单已发货", nil)
+	recorder.Finish("重复结束不应覆盖", nil)
+
+	_, second := store.Begin(context.Background(), "s1", "conversation", "转人工")
+	second.Transition("escalate", "order_query", "greeting")
+	second.Finish("", errors.New("模型调用失败"))
+
+	turns, ok := store.Session("s1")
+	if !ok || len(turns) != 2 {
+		t.Fatalf("Session() = %d turns, %v, want 2", len(turns), ok)
+	}
+	if turns[0].Turn != 1 || turns[0].Output != "订单已发货" || turns[0].Prompts["workflow_planner"] != "v2" {
+		t.Errorf("first turn = %+v", turns[0])
+	}
+	kinds := []string{}
+	for _, event := range turns[0].Events {
+		kinds = append(kinds, event.Kind)
+	}
+	if len(kinds) != 3 || kinds[0] != KindPrompt || kinds[1] != KindModel || kinds[2] != KindTool {
+		t.Errorf("event kinds = %v", kinds)
+	}
+	model := turns[0].Events[1]
+	if len(model.Messages) != 2 || model.Response == nil || model.Response.ToolCalls[0].Arguments != `{"order_id":"ORD1"}` {
+		t.Errorf("model event = %+v", model)
+	}
+
+	turn, ok := store.Turn("s1", 2)
+	if !ok || turn.Error != "模型调用失败" || turn.Events[0].From != "order_query" || turn.Events[0].To != "greeting" {
+		t.Errorf("Turn(s1, 2) = %+v, %v", turn, ok)
+	}
+
+	// 未开启执行记录时记录器为nil，所有方法都可以安全调用
+	var disabled *Store
+	_, recorder = disabled.Begin(context.Background(), "s1", "workflow", "你好")
+	recorder.Prompt("customer_service", "v1")
+	recorder.Finish("你好", nil)
+	if recorder.Ref() != nil {
+		t.Error("Ref() of nil recorder should be nil")
+	}
+}
+
+func TestStoreEvictsOldSessionsAndTurns(t *testing.T) {
+	store := NewStore(2, 2)
+	for _, sessionID := range []string{"s1", "s2", "s2", "s2", "s3"} {
+		_, recorder := store.Begin(context.Background(), sessionID, "conversation", "你好")
+		recorder.Finish("你好", nil)
+	}
+
+	if _, ok := store.Session("s1"); ok {
+		t.Error("oldest session s1 should be evicted")
+	}
+	turns, _ := store.Session("s2")
+	if len(turns) != 2 || turns[0].Turn != 2 || turns[1].Turn != 3 {
+		t.Errorf("s2 turns = %+v, want turns 2 and 3", turns)
+	}
+	if sessions := store.Sessions(); len(sessions) != 2 || sessions[0].SessionID != "s3" {
+		t.Errorf("Sessions() = %+v", sessions)
+	}
+}
@@ -0,0 +1,161 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrPromptTooLong 请求预估token数超过预算
+var ErrPromptTooLong = errors.New("prompt exceeds token budget")
+
+// MeteredModel 计量模型包装器，在每次调用后记录token用量
+type MeteredModel struct {
+	inner           model.BaseChatModel
+	modelName       string
+	tracker         *Tracker
+	maxPromptTokens int
+}
+
+// NewMeteredModel 创建计量模型包装器
+// maxPromptTokens 为单次请求的预估token上限，0表示不限制
+func NewMeteredModel(inner model.BaseChatModel, modelName string, tracker *Tracker, maxPromptTokens int) *MeteredModel {
+	return &MeteredModel{
+		inner:           inner,
+		modelName:       modelName,
+		tracker:         tracker,
+		maxPromptTokens: maxPromptTokens,
+	}
+}
+
+// Unwrap 获取被包装的模型
+func (m *MeteredModel) Unwrap() model.BaseChatModel {
+	return m.inner
+}
+
+// BindTools 绑定工具
+func (m *MeteredModel) BindTools(tools []*schema.ToolInfo) error {
+	if chatModel, ok := m.inner.(model.ChatModel); ok {
+		return chatModel.BindTools(tools)
+	}
+	return nil
+}
+
+// GetTokenCount 使用本地分词规则估算token数量
+func (m *MeteredModel) GetTokenCount(ctx context.Context, messages []*schema.Message) (int, error) {
+	return EstimateMessagesTokens(messages), nil
+}
+
+// Generate 生成回复并记录用量
+func (m *MeteredModel) Generate(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	estimate, modelName, opts, err := m.preflight(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.inner.Generate(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.record(ctx, modelName, estimate, result)
+	return result, nil
+}
+
+// Stream 流式生成回复，流结束后记录用量
+func (m *MeteredModel) Stream(ctx context.Context, messages []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	estimate, modelName, opts, err := m.preflight(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := m.inner.Stream(ctx, messages, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := stream.Copy(2)
+	go func() {
+		defer copies[1].Close()
+
+		chunks := make([]*schema.Message, 0)
+		for {
+			chunk, err := copies[1].Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if len(chunks) == 0 {
+			return
+		}
+		result, err := schema.ConcatMessages(chunks)
+		if err != nil {
+			return
+		}
+		m.record(ctx, modelName, estimate, result)
+	}()
+
+	return copies[0], nil
+}
+
+// preflight 预估请求token数、检查预算并执行准入检查
+// 返回本次调用实际使用的模型名和调用选项
+func (m *MeteredModel) preflight(ctx context.Context, messages []*schema.Message, opts []model.Option) (int, string, []model.Option, error) {
+	modelName := m.modelName
+
+	estimate := EstimateMessagesTokens(messages)
+	if m.maxPromptTokens > 0 && estimate > m.maxPromptTokens {
+		return estimate, modelName, opts, fmt.Errorf("%w: 预估 %d tokens，上限 %d tokens", ErrPromptTooLong, estimate, m.maxPromptTokens)
+	}
+
+	if m.tracker != nil {
+		override, err := m.tracker.Admit(ctx, modelName)
+		if err != nil {
+			return estimate, modelName, opts, err
+		}
+		if override != "" && override != modelName {
+			modelName = override
+			opts = append(opts, model.WithModel(override))
+		}
+	}
+
+	return estimate, modelName, opts, nil
+}
+
+// record 根据模型返回记录用量，提供商未返回usage时使用本地估算
+func (m *MeteredModel) record(ctx context.Context, modelName string, estimate int, result *schema.Message) {
+	var record Record
+	if result.ResponseMeta != nil && result.ResponseMeta.Usage != nil && result.ResponseMeta.Usage.TotalTokens > 0 {
+		record = recordFromUsage(result.ResponseMeta.Usage)
+	} else {
+		completion := EstimateTokens(result.Content)
+		for _, call := range result.ToolCalls {
+			completion += EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+		}
+		record = Record{
+			PromptTokens:     estimate,
+			CompletionTokens: completion,
+			TotalTokens:      estimate + completion,
+			Estimated:        true,
+		}
+	}
+
+	record.Scope = ScopeFromContext(ctx)
+	record.Model = modelName
+
+	if collector := collectorFromContext(ctx); collector != nil {
+		collector.Add(record)
+	}
+	if m.tracker != nil {
+		m.tracker.Record(record)
+	}
+}
@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+type stubModel struct {
+	result *schema.Message
+}
+
+func (m *stubModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	return m.result, nil
+}
+
+func (m *stubModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{m.result}), nil
+}
+
+func TestMeteredModelRecordsProviderUsage(t *testing.T) {
+	result := schema.AssistantMessage("好的", nil)
+	result.ResponseMeta = &schema.ResponseMeta{Usage: &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}
+
+	tracker := NewTracker()
+	metered := NewMeteredModel(&stubModel{result: result}, "gpt-test", tracker, 0)
+
+	ctx := WithScope(context.Background(), Scope{SessionID: "s1", TenantID: "t1"})
+	ctx, collector := WithCollector(ctx)
+
+	if _, err := metered.Generate(ctx, []*schema.Message{schema.UserMessage("查订单")}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	if got := collector.Totals(); got.TotalTokens != 15 || got.Requests != 1 {
+		t.Errorf("collector totals = %+v", got)
+	}
+	if got, ok := tracker.TenantUsage("t1"); !ok || got.PromptTokens != 10 {
+		t.Errorf("tenant usage = %+v, %v", got, ok)
+	}
+	if got, ok := tracker.SessionUsage("s1"); !ok || got.CompletionTokens != 5 {
+		t.Errorf("session usage = %+v, %v", got, ok)
+	}
+	if got, ok := tracker.ModelUsage("gpt-test"); !ok || got.EstimatedRequests != 0 {
+		t.Errorf("model usage = %+v, %v", got, ok)
+	}
+}
+
+func TestMeteredModelEstimatesMissingUsage(t *testing.T) {
+	tracker := NewTracker()
+	metered := NewMeteredModel(&stubModel{result: schema.AssistantMessage("您的订单已发货", nil)}, "mock-model", tracker, 0)
+
+	if _, err := metered.Generate(context.Background(), []*schema.Message{schema.UserMessage("我的订单到哪了")}); err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	got, ok := tracker.ModelUsage("mock-model")
+	if !ok || got.EstimatedRequests != 1 || got.CompletionTokens != EstimateTokens("您的订单已发货") {
+		t.Errorf("model usage = %+v, %v", got, ok)
+	}
+}
+
+func TestMeteredModelRejectsOverBudget(t *testing.T) {
+	metered := NewMeteredModel(&stubModel{result: schema.AssistantMessage("", nil)}, "gpt-test", NewTracker(), 5)
+
+	_, err := metered.Generate(context.Background(), []*schema.Message{schema.UserMessage("这是一条明显超过五个token预算的用户消息")})
+	if !errors.Is(err, ErrPromptTooLong) {
+		t.Errorf("Generate() error = %v, want ErrPromptTooLong", err)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"查订单", 3},
+		{"hello world", 4},
+		{"ORD123456", 3},
+		{"订单ORD123456已发货。", 9},
+	}
+
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.expected {
+			t.Errorf("EstimateTokens(%q) = %d, expected %d", tt.text, got, tt.expected)
+		}
+	}
+}
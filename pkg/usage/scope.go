@@ -0,0 +1,42 @@
+package usage
+
+import "context"
+
+// Scope 用量归属范围，随请求上下文传递
+type Scope struct {
+	SessionID string `json:"session_id,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	APIKey    string `json:"-"`
+}
+
+type scopeKey struct{}
+
+type collectorKey struct{}
+
+// WithScope 将用量归属范围写入上下文
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext 从上下文读取用量归属范围
+func ScopeFromContext(ctx context.Context) Scope {
+	if scope, ok := ctx.Value(scopeKey{}).(Scope); ok {
+		return scope
+	}
+	return Scope{}
+}
+
+// WithCollector 在上下文中挂载一个请求级用量收集器
+func WithCollector(ctx context.Context) (context.Context, *Collector) {
+	collector := &Collector{}
+	return context.WithValue(ctx, collectorKey{}, collector), collector
+}
+
+// collectorFromContext 从上下文读取请求级用量收集器
+func collectorFromContext(ctx context.Context) *Collector {
+	if collector, ok := ctx.Value(collectorKey{}).(*Collector); ok {
+		return collector
+	}
+	return nil
+}
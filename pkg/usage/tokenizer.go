@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// messageOverheadTokens 每条消息的格式开销（角色、分隔符等）
+	messageOverheadTokens = 4
+	// replyPrimingTokens 回复前缀的固定开销
+	replyPrimingTokens = 3
+	// charsPerToken 拉丁字母单词平均每个token的字符数
+	charsPerToken = 4
+)
+
+// EstimateTokens 估算文本的token数量
+// 采用与BPE分词器接近的切分规则：中日韩字符每字约一个token，
+// 英文单词和数字按每4个字符一个token计，标点符号各计一个token
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+
+	flushWord := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + charsPerToken - 1) / charsPerToken
+			wordLen = 0
+		}
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		switch {
+		case isCJK(r):
+			flushWord()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordLen++
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+
+	return tokens
+}
+
+// EstimateMessagesTokens 估算一组消息作为请求发送时的token数量
+func EstimateMessagesTokens(messages []*schema.Message) int {
+	if len(messages) == 0 {
+		return 0
+	}
+
+	total := replyPrimingTokens
+	for _, msg := range messages {
+		if msg == nil {
+			continue
+		}
+		total += messageOverheadTokens
+		total += EstimateTokens(msg.Content)
+		for _, call := range msg.ToolCalls {
+			total += EstimateTokens(call.Function.Name)
+			total += EstimateTokens(call.Function.Arguments)
+		}
+	}
+
+	return total
+}
+
+// isCJK 判断是否为中日韩字符
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
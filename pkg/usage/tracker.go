@@ -0,0 +1,223 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// Totals 累计用量
+type Totals struct {
+	Requests          int `json:"requests"`
+	EstimatedRequests int `json:"estimated_requests"` // 使用本地估算（提供商未返回usage）的请求数
+	PromptTokens      int `json:"prompt_tokens"`
+	CompletionTokens  int `json:"completion_tokens"`
+	TotalTokens       int `json:"total_tokens"`
+}
+
+// add 累加一次调用的用量
+func (t *Totals) add(record Record) {
+	t.Requests++
+	if record.Estimated {
+		t.EstimatedRequests++
+	}
+	t.PromptTokens += record.PromptTokens
+	t.CompletionTokens += record.CompletionTokens
+	t.TotalTokens += record.TotalTokens
+}
+
+// Record 单次模型调用的用量记录
+type Record struct {
+	Time             time.Time `json:"time"`
+	Scope            Scope     `json:"scope"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	Estimated        bool      `json:"estimated"`
+}
+
+// Collector 请求级用量收集器，用于在响应中返回本次请求消耗的token
+type Collector struct {
+	mu     sync.Mutex
+	totals Totals
+}
+
+// Add 累加用量
+func (c *Collector) Add(record Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totals.add(record)
+}
+
+// Totals 获取累计用量
+func (c *Collector) Totals() Totals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totals
+}
+
+// Report 用量汇总报告
+type Report struct {
+	Total    Totals            `json:"total"`
+	Tenants  map[string]Totals `json:"tenants"`
+	Models   map[string]Totals `json:"models"`
+	Sessions int               `json:"sessions"`
+}
+
+// Guard 模型调用前的准入检查
+// 返回错误表示拒绝本次调用；返回非空模型名表示将本次调用改用该模型
+type Guard interface {
+	Admit(ctx context.Context, modelName string) (string, error)
+}
+
+// Observer 用量观察者，每次记录用量后被调用
+type Observer func(record Record)
+
+// Tracker 用量统计器，按会话、租户和模型累计token用量
+type Tracker struct {
+	mu        sync.RWMutex
+	total     Totals
+	sessions  map[string]*Totals
+	tenants   map[string]*Totals
+	models    map[string]*Totals
+	guard     Guard
+	observers []Observer
+}
+
+// NewTracker 创建用量统计器
+func NewTracker() *Tracker {
+	return &Tracker{
+		sessions: make(map[string]*Totals),
+		tenants:  make(map[string]*Totals),
+		models:   make(map[string]*Totals),
+	}
+}
+
+// SetGuard 设置调用前的准入检查
+func (t *Tracker) SetGuard(guard Guard) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.guard = guard
+}
+
+// AddObserver 添加用量观察者
+func (t *Tracker) AddObserver(observer Observer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observers = append(t.observers, observer)
+}
+
+// Admit 执行调用前的准入检查
+func (t *Tracker) Admit(ctx context.Context, modelName string) (string, error) {
+	t.mu.RLock()
+	guard := t.guard
+	t.mu.RUnlock()
+
+	if guard == nil {
+		return "", nil
+	}
+	return guard.Admit(ctx, modelName)
+}
+
+// Record 记录一次模型调用的用量
+func (t *Tracker) Record(record Record) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.TotalTokens == 0 {
+		record.TotalTokens = record.PromptTokens + record.CompletionTokens
+	}
+
+	t.mu.Lock()
+	t.accumulate(record)
+	observers := t.observers
+	t.mu.Unlock()
+
+	for _, observer := range observers {
+		observer(record)
+	}
+}
+
+// accumulate 累加到各维度统计，调用方需持有写锁
+func (t *Tracker) accumulate(record Record) {
+	t.total.add(record)
+	if record.Scope.SessionID != "" {
+		totalsFor(t.sessions, record.Scope.SessionID).add(record)
+	}
+	if record.Scope.TenantID != "" {
+		totalsFor(t.tenants, record.Scope.TenantID).add(record)
+	}
+	if record.Model != "" {
+		totalsFor(t.models, record.Model).add(record)
+	}
+}
+
+// SessionUsage 获取会话用量
+func (t *Tracker) SessionUsage(sessionID string) (Totals, bool) {
+	return t.lookup(t.sessions, sessionID)
+}
+
+// TenantUsage 获取租户用量
+func (t *Tracker) TenantUsage(tenantID string) (Totals, bool) {
+	return t.lookup(t.tenants, tenantID)
+}
+
+// ModelUsage 获取模型用量
+func (t *Tracker) ModelUsage(modelName string) (Totals, bool) {
+	return t.lookup(t.models, modelName)
+}
+
+// Report 获取用量汇总报告
+func (t *Tracker) Report() Report {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	report := Report{
+		Total:    t.total,
+		Tenants:  make(map[string]Totals, len(t.tenants)),
+		Models:   make(map[string]Totals, len(t.models)),
+		Sessions: len(t.sessions),
+	}
+	for tenantID, totals := range t.tenants {
+		report.Tenants[tenantID] = *totals
+	}
+	for modelName, totals := range t.models {
+		report.Models[modelName] = *totals
+	}
+
+	return report
+}
+
+// lookup 读取指定维度的用量
+func (t *Tracker) lookup(index map[string]*Totals, key string) (Totals, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	totals, exists := index[key]
+	if !exists {
+		return Totals{}, false
+	}
+	return *totals, true
+}
+
+// totalsFor 获取或创建指定键的累计用量
+func totalsFor(index map[string]*Totals, key string) *Totals {
+	totals, exists := index[key]
+	if !exists {
+		totals = &Totals{}
+		index[key] = totals
+	}
+	return totals
+}
+
+// recordFromUsage 根据提供商返回的usage构建用量记录
+func recordFromUsage(usage *schema.TokenUsage) Record {
+	return Record{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}